@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pr-service/internal/service"
+)
+
+// runImport выполняет подкоманду: pr-service import -file org.csv [-format csv|json] [-mode atomic|best_effort]
+func runImport(args []string, importService service.IImportService, out io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	path := flags.String("file", "", "path to CSV or JSON file")
+	format := flags.String("format", "", "file format: csv or json (by default from file extension)")
+	mode := flags.String("mode", service.ImportModeAtomic, "import mode: atomic or best_effort")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		return errors.New("-file is required")
+	}
+
+	// формат по расширению файла
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*path)), ".")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := importService.Import(context.Background(), *format, *mode, file)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	if !report.Committed {
+		return fmt.Errorf("import was rolled back: %d row errors", len(report.Errors))
+	}
+
+	return nil
+}
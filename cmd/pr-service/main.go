@@ -1,118 +1,326 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"pr-service/internal/app"
+	"pr-service/internal/auth"
 	"pr-service/internal/config"
 	"pr-service/internal/database"
 	"pr-service/internal/handlers"
-	"pr-service/internal/repository"
+	"pr-service/internal/health"
+	"pr-service/internal/logging"
+	"pr-service/internal/metrics"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/repository/memory"
 	"pr-service/internal/routes.go"
+	"pr-service/internal/server"
 	"pr-service/internal/service"
+	"pr-service/internal/tracing"
+)
+
+const (
+	// как часто проверять, не изменился ли JWKS файл
+	jwksReloadInterval = 5 * time.Second
+	// как часто горутина перезагрузки настроек отмечается для /readyz
+	reloaderHeartbeatInterval = 5 * time.Second
+	// допустимое расхождение часов с SSO
+	jwtLeeway = 30 * time.Second
+	// имя сервиса в трейсах
+	serviceName = "pr-service"
 )
 
 func main() {
-	// создаем логгер
-	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	// настройки: YAML файл, переменные окружения, флаги
+	flags := flag.NewFlagSet("pr-service", flag.ExitOnError)
+	configFlags := config.RegisterFlags(flags)
+	flags.Parse(os.Args[1:])
 
-	// загрузка .env
-	cfg, err := config.Load("./.env")
+	cfg, err := config.Load(configFlags)
 	if err != nil {
-		lgr.With(
-			slog.String("error", err.Error()),
-		).Error("Failed to load config from .env file")
-		return
+		fmt.Fprintf(os.Stderr, "pr-service: invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	// создаем логгер, уровень меняется при перезагрузке настроек
+	logLevel := &slog.LevelVar{}
+	logLevel.Set(cfg.LogLevel)
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+
+	// хранилище и его проверки готовности для /readyz
+	var repositories *app.Repositories
+	var checks []health.Check
+	if cfg.DB.Backend == config.BackendMemory {
+		lgr.Warn("Using in-memory storage, all data will be lost on shutdown")
+		repositories = app.MemoryRepositories(memory.NewStore())
+		checks = []health.Check{
+			{Name: "database", Check: repositories.DB.PingContext},
+		}
+	} else {
+		// получаем адресс  базы данных
+		dbAddr := cfg.DB.Addr()
+
+		// проверяем наличие миграций
+		if err := database.RunMigrations(dbAddr); err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Failed to migrate")
+			return
+		}
+
+		// подключение к БД
+		db, err := database.ConnectDB(dbAddr, cfg.DB.Pool)
+		if err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Failed to connect to DB")
+			return
+		}
+		defer func() {
+			if err := database.CloseDD(db); err != nil {
+				lgr.With(
+					slog.String("error", err.Error()),
+				).Error("Failed to close DB")
+			}
+		}()
+
+		repositories = app.PostgresRepositories(db)
+		checks = []health.Check{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: func(ctx context.Context) error {
+				return database.CheckMigrationVersion(ctx, db)
+			}},
+		}
 	}
 
-	// получаем адресс  базы данных
-	dbAddr := database.GetDbAddres(cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBUser, cfg.DBPassword)
+	// метрики для Prometheus, отдаются по GET /metrics
+	registry := metrics.NewRegistry(lgr)
+
+	// создаем сервисы
+	assignmentPolicies := service.NewAssignmentPolicies(cfg.Assignment)
+	services := app.NewServices(repositories, lgr, registry, assignmentPolicies)
+
+	// хранилище в памяти заполняется фикстурой до начала работы
+	if cfg.DB.Fixture != "" {
+		restored, err := app.LoadFixture(context.Background(), services.Snapshot, cfg.DB.Fixture)
+		if err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Failed to load fixture")
+			return
+		}
 
-	// проверяем наличие миграций
-	if err := database.RunMigrations(dbAddr); err != nil {
 		lgr.With(
-			slog.String("error", err.Error()),
-		).Error("Failed to migrate")
+			slog.String("fixture", cfg.DB.Fixture),
+			slog.Int("teams", restored.Teams),
+			slog.Int("users", restored.Users),
+			slog.Int("pull_requests", restored.PullRequests),
+		).Info("Fixture loaded")
+	}
+
+	// подкоманда импорта оргструктуры из файла вместо запуска сервера. Ошибка, в том числе
+	// откат атомарного импорта, завершает процесс с кодом 1, чтобы ее видели скрипты и CI
+	if args := flags.Args(); len(args) > 0 && args[0] == "import" {
+		if err := runImport(args[1:], services.Import, os.Stdout); err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Import failed")
+			os.Exit(1)
+		}
 		return
 	}
 
-	// подключение к БД
-	db, err := database.ConnectDB(dbAddr)
+	// токены доступа к API
+	staticTokens, err := auth.NewStaticTokens(cfg.Auth.Tokens)
 	if err != nil {
 		lgr.With(
 			slog.String("error", err.Error()),
-		).Error("Failed to connect to DB")
+		).Error("Failed to parse AUTH_TOKENS")
 		return
 	}
-	defer func() {
-		if err := database.CloseDD(db); err != nil {
+	// ключи API хранятся в БД, проверяются по хешу
+	authenticator := auth.Chain{staticTokens, services.APIKeys}
+
+	// JWT от SSO, ключи перечитываются при изменении файла
+	var keySet *auth.KeySet
+	if cfg.Auth.JWKSFile != "" {
+		keySet, err = auth.NewKeySet(cfg.Auth.JWKSFile, jwksReloadInterval, lgr)
+		if err != nil {
 			lgr.With(
 				slog.String("error", err.Error()),
-			).Error("Failed to close DB")
+			).Error("Failed to load JWKS")
+			return
 		}
-	}()
-
-	// создаем репозитории
-	usersRepository := &repository.UsersRepository{Db: db}
-	teamsRepository := &repository.TeamsRepository{Db: db}
-	reviewersRepository := &repository.ReviewersRepository{Db: db}
-	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
 
-	// создаем сервисы
-	usersService := &service.UsersService{
-		UsersRepository:        usersRepository,
-		ReviewersRepository:    reviewersRepository,
-		PullRequestsRepository: pullRequestsRepository,
-		Lgr:                    lgr,
+		authenticator = append(authenticator, &auth.JWTAuthenticator{
+			Keys:     keySet,
+			Issuer:   cfg.Auth.JWTIssuer,
+			Audience: cfg.Auth.JWTAudience,
+			Leeway:   jwtLeeway,
+		})
 	}
 
-	teamsService := &service.TeamsService{
-		UsersRepository: usersRepository,
-		TeamsRepository: teamsRepository,
-		Lgr:             lgr,
+	if staticTokens.Len() == 0 && cfg.Auth.JWKSFile == "" {
+		lgr.Warn("AUTH_TOKENS and JWKS_FILE are empty, only API keys from the DB will be accepted")
 	}
 
-	pullRequestsService := &service.PullRequestsService{
-		UsersRepository:        usersRepository,
-		ReviewersRepository:    reviewersRepository,
-		PullRequestsRepository: pullRequestsRepository,
-		Lgr:                    lgr,
+	// лимиты запросов на клиента, отдельно для чтения и записи
+	limits, err := ratelimit.NewLimits(cfg.RateLimitRead, cfg.RateLimitWrite, cfg.RateLimitAuthFailures)
+	if err != nil {
+		lgr.With(
+			slog.String("error", err.Error()),
+		).Error("Failed to parse rate limits")
+		return
 	}
 
-	statsService := &service.StatsService{
-		UsersRepository:        usersRepository,
-		ReviewersRepository:    reviewersRepository,
-		PullRequestsRepository: pullRequestsRepository,
-		Lgr:                    lgr,
+	// сроки обработки запросов, по истечении срока запросы к БД отменяются
+	timeouts := routes.NewTimeouts(cfg.RequestTimeout, cfg.RequestTimeouts)
+
+	// трассировка включается выбором экспортера, без него tracer == nil
+	var tracer *tracing.Tracer
+	if cfg.Tracing.Exporter != "" {
+		exporter, err := tracing.NewExporter(cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint, serviceName)
+		if err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Failed to create tracing exporter")
+			return
+		}
+
+		tracer = tracing.NewTracer(exporter, cfg.Tracing.SampleRatio, lgr)
 	}
 
 	// создаем handlers
 	usersHandler := &handlers.UsersHandlers{
-		UserService: usersService,
+		UserService: services.Users,
 	}
 
 	teamsHandler := &handlers.TeamsHandlers{
-		TeamService: teamsService,
+		TeamService: services.Teams,
 	}
 
 	pullRequestsHandler := &handlers.PullRequestsHandlers{
-		PullRequestService: pullRequestsService,
+		PullRequestService: services.PullRequests,
 	}
 
 	statsHandler := &handlers.StatsHandlers{
-		StatsService: statsService,
+		StatsService: services.Stats,
+	}
+
+	importHandler := &handlers.ImportHandlers{
+		ImportService: services.Import,
+	}
+
+	// уровень логов, лимиты и правила назначения применяются без перезапуска
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		return config.Load(configFlags)
+	}, func(next *config.Config) error {
+		if err := limits.Update(next.RateLimitRead, next.RateLimitWrite, next.RateLimitAuthFailures); err != nil {
+			return err
+		}
+		logLevel.Set(next.LogLevel)
+		assignmentPolicies.Set(next.Assignment)
+		return nil
+	}, lgr)
+
+	adminHandler := &handlers.AdminHandlers{
+		SnapshotService: services.Snapshot,
+		ConfigReloader:  reloader,
+	}
+
+	apiKeysHandler := &handlers.APIKeysHandlers{
+		APIKeysService: services.APIKeys,
+	}
+
+	metricsHandler := &handlers.MetricsHandlers{
+		Registry: registry,
+	}
+
+	// фоновые горутины отмечаются в своих циклах, /readyz проверяет, что они не встали
+	reloaderHeartbeat := health.NewHeartbeat("config_reloader", 3*reloaderHeartbeatInterval)
+	checks = append(checks, reloaderHeartbeat.Check(), limits.Heartbeat().Check())
+	if keySet != nil {
+		checks = append(checks, keySet.Heartbeat().Check())
+	}
+	if tracer != nil {
+		checks = append(checks, tracer.Heartbeat().Check())
+	}
+
+	healthHandler := &handlers.HealthHandlers{
+		Checker: &health.Checker{Checks: checks},
 	}
 
 	// создаем роутер
-	router := routes.NewRouter(teamsHandler, usersHandler, pullRequestsHandler, statsHandler)
+	// проверка тел запросов по OpenAPI включается через VALIDATE_REQUESTS
+	router := routes.NewRouter(authenticator, limits, timeouts, cfg.ValidateRequests, teamsHandler, usersHandler, pullRequestsHandler, statsHandler, importHandler, adminHandler, apiKeysHandler, metricsHandler, healthHandler)
+
+	var handler http.Handler = router
+
+	// спан запроса и дочерние спаны сервисов и SQL, trace_id в логах запроса
+	handler = tracer.Middleware(handler)
+
+	// счетчики и время ответа по шаблону маршрута, который заполняет роутер
+	handler = metrics.Middleware(registry, handler)
+
+	// X-Request-ID, логгер запроса в контексте и access log снаружи всех проверок
+	handler = logging.Middleware(lgr, handler)
 
 	lgr.Info("Server initialization was passed successfully")
 
-	if err := http.ListenAndServe(":8080", router); err != nil {
+	// SIGINT и SIGTERM останавливают сервер: начатые запросы дорабатывают,
+	// БД закрывается отложенным вызовом уже после этого
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// SIGHUP перечитывает настройки
+	go reloadOnSignal(ctx, reloader, reloaderHeartbeat)
+
+	// очистка корзин лимитов и перечитывание JWKS
+	go limits.RunSweeper(ctx)
+	if keySet != nil {
+		go keySet.Run(ctx)
+	}
+
+	// спаны последних запросов отправляются после остановки сервера
+	hooks := []server.Hook{
+		{Name: "tracing", Stop: tracer.Shutdown},
+	}
+
+	if err := server.Run(ctx, server.New(&cfg.Server, handler), cfg.Server.ShutdownTimeout, lgr, hooks...); err != nil {
 		lgr.With(
 			slog.String("error", err.Error()),
 		).Error("Error during the server's listening")
 		return
 	}
 }
+
+// reloadOnSignal перезагружает настройки по SIGHUP до остановки сервера
+func reloadOnSignal(ctx context.Context, reloader *config.Reloader, heartbeat *health.Heartbeat) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	defer heartbeat.Stop()
+
+	ticker := time.NewTicker(reloaderHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			heartbeat.Beat()
+		case <-hangup:
+			// ошибка уже записана в лог, прежние настройки продолжают действовать
+			reloader.Reload(ctx)
+		}
+	}
+}
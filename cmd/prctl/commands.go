@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pr-service/internal/app"
+	"pr-service/internal/database"
+	"pr-service/internal/dto"
+)
+
+// prctl работает с БД напрямую, поэтому сервисы вызываются с context.Background() без принципала
+// и проверки прав к ним не применяются
+
+// runTeams выполняет prctl teams list
+func runTeams(args []string, services *app.Services, p *printer) error {
+	if len(args) != 1 || args[0] != "list" {
+		return errors.New("usage: prctl teams list")
+	}
+
+	responseDTO, err := services.Teams.ListTeams(context.Background())
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(responseDTO.Teams))
+	for _, team := range responseDTO.Teams {
+		rows = append(rows, []string{
+			team.TeamName,
+			orDash(team.ParentTeamName),
+			strconv.Itoa(team.Members),
+			strconv.Itoa(team.ActiveMembers),
+		})
+	}
+
+	return p.print(responseDTO, []string{"TEAM", "PARENT", "MEMBERS", "ACTIVE"}, rows)
+}
+
+// runUsers выполняет prctl users queue|deactivate
+func runUsers(args []string, services *app.Services, p *printer) error {
+	if len(args) == 0 {
+		return errors.New("usage: prctl users queue|deactivate ...")
+	}
+
+	switch args[0] {
+	case "queue":
+		flags := flag.NewFlagSet("users queue", flag.ContinueOnError)
+		all := flags.Bool("all", false, "include merged pull requests")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New("usage: prctl users queue [-all] <user_id>")
+		}
+
+		// по умолчанию очередь - только открытые PR
+		getPullRequests := services.Users.GetPullRequestsByUserId
+		if *all {
+			getPullRequests = services.Users.GetAllPullRequestsByUserId
+		}
+
+		responseDTO, err := getPullRequests(context.Background(), flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]string, 0, len(responseDTO.PullRequests))
+		for _, pr := range responseDTO.PullRequests {
+			rows = append(rows, []string{pr.PullRequestId, pr.PullRequestName, pr.AuthorID, pr.Status})
+		}
+
+		return p.print(responseDTO, []string{"PULL_REQUEST", "NAME", "AUTHOR", "STATUS"}, rows)
+
+	case "deactivate":
+		if len(args) < 2 {
+			return errors.New("usage: prctl users deactivate <user_id>...")
+		}
+
+		users := []*dto.User{}
+		rows := [][]string{}
+		for _, id := range args[1:] {
+			responseDTO, err := services.Users.SetIsActiveById(context.Background(), &dto.IsActiveUserDTO{Id: id, IsActive: false})
+			if err != nil {
+				return fmt.Errorf("user %q: %w", id, err)
+			}
+
+			users = append(users, responseDTO.User)
+			rows = append(rows, []string{responseDTO.User.UserId, responseDTO.User.Username, orDash(responseDTO.User.TeamName), strconv.FormatBool(responseDTO.User.IsActive)})
+		}
+
+		return p.print(users, []string{"USER", "USERNAME", "TEAM", "ACTIVE"}, rows)
+
+	default:
+		return fmt.Errorf("unknown users command %q", args[0])
+	}
+}
+
+// runPullRequests выполняет prctl pr reassign|merge
+func runPullRequests(args []string, services *app.Services, p *printer) error {
+	if len(args) == 0 {
+		return errors.New("usage: prctl pr reassign|merge ...")
+	}
+
+	header := []string{"PULL_REQUEST", "NAME", "AUTHOR", "STATUS", "REVIEWERS"}
+
+	switch args[0] {
+	case "reassign":
+		if len(args) != 3 {
+			return errors.New("usage: prctl pr reassign <pull_request_id> <old_reviewer_id>")
+		}
+
+		responseDTO, err := services.PullRequests.ReassignReviewer(context.Background(), &dto.RequestReassignDTO{
+			PullRequestId: args[1],
+			OldUserId:     args[2],
+		})
+		if err != nil {
+			return err
+		}
+
+		pr := responseDTO.PR
+		rows := [][]string{{pr.PullRequestId, pr.PullRequestName, pr.AuthorID, pr.Status, strings.Join(pr.AssignedReviewers, ",")}}
+		return p.print(responseDTO, header, rows)
+
+	case "merge":
+		if len(args) != 2 {
+			return errors.New("usage: prctl pr merge <pull_request_id>")
+		}
+
+		responseDTO, err := services.PullRequests.MergePullRequest(context.Background(), args[1])
+		if err != nil {
+			return err
+		}
+
+		pr := responseDTO.PR
+		rows := [][]string{{pr.PullRequestId, pr.PullRequestName, pr.AuthorID, pr.Status, strings.Join(pr.AssignedReviewers, ",")}}
+		return p.print(responseDTO, header, rows)
+
+	default:
+		return fmt.Errorf("unknown pr command %q", args[0])
+	}
+}
+
+// runMigrate выполняет prctl migrate up|down [steps]|version
+func runMigrate(args []string, dbAddr string, p *printer) error {
+	if len(args) == 0 {
+		return errors.New("usage: prctl migrate up|down [steps]|version")
+	}
+
+	switch args[0] {
+	case "up":
+		if err := database.RunMigrations(dbAddr); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		if err := database.RollbackMigrations(dbAddr, steps); err != nil {
+			return err
+		}
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	version, dirty, err := database.GetMigrationVersion(dbAddr)
+	if err != nil {
+		return err
+	}
+
+	value := struct {
+		Version uint `json:"version"`
+		Dirty   bool `json:"dirty"`
+	}{version, dirty}
+
+	return p.print(value, []string{"VERSION", "DIRTY"}, [][]string{{strconv.FormatUint(uint64(version), 10), strconv.FormatBool(dirty)}})
+}
+
+// runStats выполняет prctl stats
+func runStats(services *app.Services, p *printer) error {
+	responseDTO, err := services.Stats.GetStats(context.Background())
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{{"total_prs", "", strconv.Itoa(responseDTO.TotalPRs)}}
+
+	for _, status := range slices.Sorted(maps.Keys(responseDTO.PRsByStatus)) {
+		rows = append(rows, []string{"prs_by_status", status, strconv.Itoa(responseDTO.PRsByStatus[status])})
+	}
+
+	for _, userId := range slices.Sorted(maps.Keys(responseDTO.AssignmentsByUser)) {
+		rows = append(rows, []string{"assignments_by_user", userId, strconv.Itoa(responseDTO.AssignmentsByUser[userId])})
+	}
+
+	// команды выводятся деревом с отступами, значение - PR с учетом подкоманд
+	var addTeams func(teams []*dto.TeamStatsDTO, depth int)
+	addTeams = func(teams []*dto.TeamStatsDTO, depth int) {
+		for _, team := range teams {
+			rows = append(rows, []string{"team_total_prs", strings.Repeat("  ", depth) + team.TeamName, strconv.Itoa(team.Total.TotalPRs)})
+			addTeams(team.Children, depth+1)
+		}
+	}
+	addTeams(responseDTO.Teams, 0)
+
+	return p.print(responseDTO, []string{"METRIC", "KEY", "VALUE"}, rows)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"pr-service/internal/app"
+	"pr-service/internal/config"
+	"pr-service/internal/database"
+	"pr-service/internal/service"
+)
+
+const usage = `prctl - administration of the PR reviewer service
+
+Usage:
+  prctl [-config config.yaml] [-env .env] [-<setting>=<value>] [-o table|json] [-v] <command> [arguments]
+
+Commands:
+  teams list                              list teams with member counts
+  users queue [-all] <user_id>            show pull requests assigned to a user
+  users deactivate <user_id>...           set is_active=false for users
+  pr reassign <pull_request_id> <old_reviewer_id>
+                                          replace a reviewer on an open pull request
+  pr merge <pull_request_id>              merge a pull request
+  migrate up|down [steps]|version         apply, roll back or show migrations
+  stats                                   print review statistics
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "prctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("prctl", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() { fmt.Fprint(errOut, usage) }
+	configFlags := config.RegisterFlags(flags)
+	output := flags.String("o", outputTable, "output format: table or json")
+	verbose := flags.Bool("v", false, "print service logs")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("command is required")
+	}
+
+	// логи сервисов нужны только для отладки, по умолчанию выводятся лишь ошибки
+	level := slog.LevelError
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	lgr := slog.New(slog.NewTextHandler(errOut, &slog.HandlerOptions{Level: level}))
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	dbAddr := cfg.DB.Addr()
+	p := &printer{format: *output, out: out}
+
+	// хранилище в памяти живет внутри процесса pr-service, prctl до него не дотянется
+	if cfg.DB.Backend != config.BackendPostgres {
+		return fmt.Errorf("db.backend=%s is not supported by prctl", cfg.DB.Backend)
+	}
+
+	// миграции работают без сервисного слоя и до того, как схема создана
+	if args[0] == "migrate" {
+		return runMigrate(args[1:], dbAddr, p)
+	}
+
+	db, err := database.ConnectDB(dbAddr, cfg.DB.Pool)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if err := database.CloseDD(db); err != nil {
+			lgr.With(
+				slog.String("error", err.Error()),
+			).Error("Failed to close DB")
+		}
+	}()
+
+	services := app.NewServices(app.PostgresRepositories(db), lgr, nil, service.NewAssignmentPolicies(cfg.Assignment))
+
+	switch args[0] {
+	case "teams":
+		return runTeams(args[1:], services, p)
+	case "users":
+		return runUsers(args[1:], services, p)
+	case "pr":
+		return runPullRequests(args[1:], services, p)
+	case "stats":
+		return runStats(services, p)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+// print выводит value как JSON либо строки rows таблицей с заголовком header
+func (p *printer) print(value any, header []string, rows [][]string) error {
+	if p.format == outputJSON {
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// orDash заменяет пустое значение прочерком, чтобы колонки таблицы не съезжали
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
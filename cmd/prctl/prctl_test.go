@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"pr-service/internal/app"
+	"pr-service/internal/dto"
+	"pr-service/internal/repository/memory"
+	"pr-service/internal/service"
+)
+
+// newTestServices собирает сервисы поверх хранилища в памяти: в backend четыре пользователя,
+// pr-1 открыт, pr-2 слит. u4 активируется после создания pr, поэтому ревьюверы обоих pr - u2 и u3
+func newTestServices(t *testing.T) *app.Services {
+	t.Helper()
+	ctx := context.Background()
+	services := app.NewServices(app.MemoryRepositories(memory.NewStore()), slog.New(slog.DiscardHandler), nil, nil)
+
+	if _, err := services.Teams.AddTeamWithMembers(ctx, &dto.TeamDTO{
+		TeamName: "backend",
+		Members: []*dto.TeamMemberDTO{
+			{UserId: "u1", Username: "Alice", IsActive: true},
+			{UserId: "u2", Username: "Bob", IsActive: true},
+			{UserId: "u3", Username: "Carol", IsActive: true},
+			{UserId: "u4", Username: "Dave", IsActive: false},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"pr-1", "pr-2"} {
+		if _, err := services.PullRequests.AddPullRequest(ctx, &dto.RequestPullrequestDTO{PullRequestId: id, PullRequestName: "Feature " + id, AuthorID: "u1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := services.PullRequests.MergePullRequest(ctx, "pr-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.Users.SetIsActiveById(ctx, &dto.IsActiveUserDTO{Id: "u4", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	return services
+}
+
+func TestCommands(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		run     func(args []string, services *app.Services, p *printer) error
+		args    []string
+		format  string
+		want    []string
+		notWant []string
+		wantErr string
+	}{
+		{
+			name:   "teams list table",
+			run:    runTeams,
+			args:   []string{"list"},
+			format: outputTable,
+			want:   []string{"TEAM     PARENT  MEMBERS  ACTIVE\n", "backend  -       4        4\n"},
+		},
+		{
+			name:   "teams list json",
+			run:    runTeams,
+			args:   []string{"list"},
+			format: outputJSON,
+			want:   []string{`"team_name": "backend"`, `"active_members": 4`},
+		},
+		{
+			name:    "teams without list",
+			run:     runTeams,
+			args:    []string{},
+			wantErr: "usage: prctl teams list",
+		},
+		{
+			name:    "users queue shows open pull requests",
+			run:     runUsers,
+			args:    []string{"queue", "u2"},
+			format:  outputTable,
+			want:    []string{"PULL_REQUEST  NAME          AUTHOR  STATUS\n", "pr-1          Feature pr-1  u1      OPEN\n"},
+			notWant: []string{"pr-2"},
+		},
+		{
+			name:   "users queue -all includes merged",
+			run:    runUsers,
+			args:   []string{"queue", "-all", "u2"},
+			format: outputJSON,
+			want:   []string{`"pull_request_id": "pr-1"`, `"pull_request_id": "pr-2"`, `"status": "MERGED"`},
+		},
+		{
+			name:    "users queue without user",
+			run:     runUsers,
+			args:    []string{"queue", "-all"},
+			wantErr: "usage: prctl users queue [-all] <user_id>",
+		},
+		{
+			name:    "users queue with unknown flag",
+			run:     runUsers,
+			args:    []string{"queue", "-open", "u2"},
+			wantErr: "flag provided but not defined: -open",
+		},
+		{
+			name:    "users queue of unknown user",
+			run:     runUsers,
+			args:    []string{"queue", "u9"},
+			wantErr: service.ErrNoResourse.Error(),
+		},
+		{
+			name:   "users deactivate table",
+			run:    runUsers,
+			args:   []string{"deactivate", "u3", "u4"},
+			format: outputTable,
+			want:   []string{"USER  USERNAME  TEAM     ACTIVE\n", "u3    Carol     backend  false\n", "u4    Dave      backend  false\n"},
+		},
+		{
+			name:   "users deactivate json",
+			run:    runUsers,
+			args:   []string{"deactivate", "u3"},
+			format: outputJSON,
+			want:   []string{`"user_id": "u3"`, `"is_active": false`},
+		},
+		{
+			name:    "users deactivate without users",
+			run:     runUsers,
+			args:    []string{"deactivate"},
+			wantErr: "usage: prctl users deactivate <user_id>...",
+		},
+		{
+			name:    "unknown users command",
+			run:     runUsers,
+			args:    []string{"delete", "u2"},
+			wantErr: `unknown users command "delete"`,
+		},
+		{
+			name:   "pr reassign table",
+			run:    runPullRequests,
+			args:   []string{"reassign", "pr-1", "u2"},
+			format: outputTable,
+			want:   []string{"PULL_REQUEST  NAME          AUTHOR  STATUS  REVIEWERS\n", "pr-1          Feature pr-1  u1      OPEN    "},
+		},
+		{
+			name:   "pr reassign json",
+			run:    runPullRequests,
+			args:   []string{"reassign", "pr-1", "u2"},
+			format: outputJSON,
+			want:   []string{`"replaced_by": "u4"`},
+		},
+		{
+			name:    "pr reassign on merged pull request",
+			run:     runPullRequests,
+			args:    []string{"reassign", "pr-2", "u2"},
+			wantErr: service.ErrPrMerged.Error(),
+		},
+		{
+			name:    "pr reassign without reviewer",
+			run:     runPullRequests,
+			args:    []string{"reassign", "pr-1"},
+			wantErr: "usage: prctl pr reassign <pull_request_id> <old_reviewer_id>",
+		},
+		{
+			name:   "pr merge table",
+			run:    runPullRequests,
+			args:   []string{"merge", "pr-1"},
+			format: outputTable,
+			want:   []string{"PULL_REQUEST  NAME          AUTHOR  STATUS  REVIEWERS\n", "pr-1          Feature pr-1  u1      MERGED  "},
+		},
+		{
+			name:   "pr merge json",
+			run:    runPullRequests,
+			args:   []string{"merge", "pr-1"},
+			format: outputJSON,
+			want:   []string{`"pull_request_id": "pr-1"`, `"status": "MERGED"`},
+		},
+		{
+			name:    "unknown pr command",
+			run:     runPullRequests,
+			args:    []string{"close", "pr-1"},
+			wantErr: `unknown pr command "close"`,
+		},
+		{
+			name: "stats table",
+			run: func(args []string, services *app.Services, p *printer) error {
+				return runStats(services, p)
+			},
+			format: outputTable,
+			want: []string{
+				"METRIC               KEY      VALUE\n",
+				"total_prs                     2\n",
+				"prs_by_status        MERGED   1\n",
+				"assignments_by_user  u2       2\n",
+				"team_total_prs       backend  2\n",
+			},
+		},
+		{
+			name: "stats json",
+			run: func(args []string, services *app.Services, p *printer) error {
+				return runStats(services, p)
+			},
+			format: outputJSON,
+			want:   []string{`"total_prs": 2`},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := test.run(test.args, newTestServices(t), &printer{format: test.format, out: out})
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output must contain %q, got:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output must not contain %q, got:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunArguments(t *testing.T) {
+	// настройки не должны прийти из окружения разработчика
+	for _, name := range []string{"CONFIG_FILE", "DB_BACKEND", "DB_HOST", "DB_NAME", "DB_USER"} {
+		t.Setenv(name, "")
+	}
+	t.Chdir(t.TempDir())
+
+	for _, test := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "help", args: []string{"-h"}},
+		{name: "no command", args: []string{}, wantErr: "command is required"},
+		{name: "unknown output format", args: []string{"-o", "yaml", "teams", "list"}, wantErr: `unknown output format "yaml"`},
+		{name: "unknown flag", args: []string{"-x", "teams", "list"}, wantErr: "flag provided but not defined: -x"},
+		{name: "invalid setting", args: []string{"-log.level=loud", "teams", "list"}, wantErr: "invalid configuration"},
+		{name: "memory backend", args: []string{"-db.backend=memory", "teams", "list"}, wantErr: "db.backend=memory is not supported by prctl"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := run(test.args, &bytes.Buffer{}, &bytes.Buffer{})
+
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
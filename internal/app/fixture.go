@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/service"
+)
+
+// LoadFixture заполняет пустое хранилище снимком данных из файла. Формат тот же,
+// что у GET /api/v1/admin/export, поэтому фикстуру можно выгрузить со стенда
+func LoadFixture(ctx context.Context, snapshotService service.ISnapshotService, path string) (*dto.ResponseRestoreDTO, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot dto.SnapshotDTO
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	restored, err := snapshotService.Restore(ctx, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return restored, nil
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"pr-service/internal/enums"
+	"pr-service/internal/metrics"
+	"pr-service/internal/repository"
+	"pr-service/internal/repository/memory"
+	"pr-service/internal/service"
+)
+
+// Services - сервисный слой, собранный поверх одного хранилища.
+// Используется и HTTP-сервером, и CLI
+type Services struct {
+	Teams        *service.TeamsService
+	Users        *service.UsersService
+	PullRequests *service.PullRequestsService
+	Stats        *service.StatsService
+	Import       *service.ImportService
+	Snapshot     *service.SnapshotService
+	APIKeys      *service.APIKeysService
+}
+
+// Repositories - репозитории одного хранилища и *sql.DB, через который
+// сервисы открывают его транзакции
+type Repositories struct {
+	DB           *sql.DB
+	Users        repository.IUsersRepository
+	Teams        repository.ITeamsRepository
+	Reviewers    repository.IReviewersRepository
+	PullRequests repository.IPullRequestsRepository
+	Events       repository.IEventsRepository
+	Snapshot     repository.ISnapshotRepository
+	APIKeys      repository.IAPIKeysRepository
+}
+
+// PostgresRepositories - репозитории поверх подключения к PostgreSQL
+func PostgresRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		DB:           db,
+		Users:        &repository.UsersRepository{Db: db},
+		Teams:        &repository.TeamsRepository{Db: db},
+		Reviewers:    &repository.ReviewersRepository{Db: db},
+		PullRequests: &repository.PullRequestsRepository{Db: db},
+		Events:       &repository.EventsRepository{Db: db},
+		Snapshot:     &repository.SnapshotRepository{Db: db},
+		APIKeys:      &repository.APIKeysRepository{Db: db},
+	}
+}
+
+// MemoryRepositories - репозитории в памяти процесса, данные живут до остановки
+func MemoryRepositories(store *memory.Store) *Repositories {
+	return &Repositories{
+		DB:           store.DB(),
+		Users:        &memory.UsersRepository{Store: store},
+		Teams:        &memory.TeamsRepository{Store: store},
+		Reviewers:    &memory.ReviewersRepository{Store: store},
+		PullRequests: &memory.PullRequestsRepository{Store: store},
+		Events:       &memory.EventsRepository{Store: store},
+		Snapshot:     &memory.SnapshotRepository{Store: store},
+		APIKeys:      &memory.APIKeysRepository{Store: store},
+	}
+}
+
+// NewServices собирает сервисы. Если reg не nil, в нем регистрируются метрики
+// назначений, открытых pr по командам и пула подключений к БД. policy nil -
+// правила назначения ревьюверов по умолчанию, иначе их можно менять на лету
+func NewServices(repositories *Repositories, lgr *slog.Logger, reg *metrics.Registry, policy *service.AssignmentPolicies) *Services {
+	// многошаговые операции сервисов выполняются в транзакциях хранилища
+	transactor := &repository.Transactor{Db: repositories.DB}
+
+	// создаем сервисы
+	pullRequestsService := &service.PullRequestsService{
+		UsersRepository:        repositories.Users,
+		TeamsRepository:        repositories.Teams,
+		ReviewersRepository:    repositories.Reviewers,
+		PullRequestsRepository: repositories.PullRequests,
+		EventsRepository:       repositories.Events,
+		Transactor:             transactor,
+		Metrics:                metrics.NewReviews(reg),
+		Policy:                 policy,
+		Lgr:                    lgr,
+	}
+
+	usersService := &service.UsersService{
+		UsersRepository:        repositories.Users,
+		TeamsRepository:        repositories.Teams,
+		ReviewersRepository:    repositories.Reviewers,
+		PullRequestsRepository: repositories.PullRequests,
+		PullRequestsService:    pullRequestsService,
+		Transactor:             transactor,
+		Lgr:                    lgr,
+	}
+
+	teamsService := &service.TeamsService{
+		UsersRepository: repositories.Users,
+		TeamsRepository: repositories.Teams,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	statsService := &service.StatsService{
+		UsersRepository:        repositories.Users,
+		TeamsRepository:        repositories.Teams,
+		ReviewersRepository:    repositories.Reviewers,
+		PullRequestsRepository: repositories.PullRequests,
+		Lgr:                    lgr,
+	}
+
+	importService := &service.ImportService{
+		TeamsService:    teamsService,
+		TeamsRepository: repositories.Teams,
+		UsersRepository: repositories.Users,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	snapshotService := &service.SnapshotService{
+		SnapshotRepository:  repositories.Snapshot,
+		TeamsRepository:     repositories.Teams,
+		ReviewersRepository: repositories.Reviewers,
+		EventsRepository:    repositories.Events,
+		Transactor:          transactor,
+		Lgr:                 lgr,
+	}
+
+	apiKeysService := &service.APIKeysService{
+		APIKeysRepository: repositories.APIKeys,
+		Lgr:               lgr,
+	}
+
+	// открытые pr считаются запросом к БД в момент сбора метрик
+	reg.NewGaugeFunc("pr_service_open_pull_requests", "Open pull requests by review team.", []string{"team"}, func(ctx context.Context) ([]metrics.Sample, error) {
+		counts, err := repositories.PullRequests.CountPullRequestsByTeamAndStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		samples := []metrics.Sample{}
+		for _, team := range slices.Sorted(maps.Keys(counts)) {
+			samples = append(samples, metrics.Sample{LabelValues: []string{team}, Value: float64(counts[team][enums.OPEN])})
+		}
+		return samples, nil
+	})
+	metrics.RegisterDBStats(reg, repositories.DB)
+
+	return &Services{
+		Teams:        teamsService,
+		Users:        usersService,
+		PullRequests: pullRequestsService,
+		Stats:        statsService,
+		Import:       importService,
+		Snapshot:     snapshotService,
+		APIKeys:      apiKeysService,
+	}
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"pr-service/internal/helpers"
+)
+
+// роли
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Access - минимальный уровень доступа к маршруту
+type Access int
+
+const (
+	// Public - маршрут доступен без токена
+	Public Access = iota
+	// Authenticated - любой пользователь с действующим токеном, проверки владельца делают хендлеры
+	Authenticated
+	// AdminOnly - только администраторы
+	AdminOnly
+)
+
+var (
+	ErrNoToken      = errors.New("bearer token is missing")
+	ErrInvalidToken = errors.New("bearer token is invalid")
+	ErrForbidden    = errors.New("not enough permissions")
+	ErrNoScope      = errors.New("api key doesn't have the scope required by this route")
+)
+
+// Principal - тот, от чьего имени выполняется запрос
+type Principal struct {
+	UserId string
+	Role   string
+	// для ключей API: имя ключа и разрешенные области. У токенов пользователей Scopes == nil
+	KeyName string
+	Scopes  []string
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// HasScope проверяет область ключа API. Токены пользователей областями не ограничены
+func (p *Principal) HasScope(scope string) bool {
+	return p.Scopes == nil || scope == "" || slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator проверяет токен и возвращает его владельца
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// Chain пробует аутентификаторы по очереди. Если токен никому не подошел,
+// возвращается самая конкретная ошибка, например ErrTokenExpired вместо ErrInvalidToken
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	lastErr := ErrInvalidToken
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(ctx, token)
+		if err == nil {
+			return principal, nil
+		}
+
+		if !errors.Is(err, ErrInvalidToken) {
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// WithoutPrincipal убирает принципала из ctx для доверенных вызовов между сервисами.
+// Отмена, дедлайн, логгер, спан и транзакция запроса сохраняются
+func WithoutPrincipal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, principalKey{}, (*Principal)(nil))
+}
+
+// FromContext возвращает принципала, которого положил Middleware
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// Middleware аутентифицирует запрос по заголовку Authorization: Bearer <token>
+// и проверяет уровень доступа маршрута, а для ключей API - еще и область scope
+func Middleware(authenticator Authenticator, access Access, scope string, next http.HandlerFunc) http.HandlerFunc {
+	if access == Public {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticate(authenticator, r)
+		if err != nil {
+			writeUnauthorized(w, err)
+			return
+		}
+
+		if access == AdminOnly && !principal.IsAdmin() {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", ErrForbidden.Error())
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", ErrNoScope.Error())
+			return
+		}
+
+		next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	}
+}
+
+func authenticate(authenticator Authenticator, r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, ErrNoToken
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrInvalidToken
+	}
+
+	principal, err := authenticator.Authenticate(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		// сроки действия токена отдаются отдельными кодами, остальное не раскрывается
+		if errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrTokenNotYetValid) {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+
+	return principal, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pr-service"`)
+
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		helpers.WriteErrorReponse(w, http.StatusUnauthorized, "TOKEN_EXPIRED", err.Error())
+	case errors.Is(err, ErrTokenNotYetValid):
+		helpers.WriteErrorReponse(w, http.StatusUnauthorized, "TOKEN_NOT_YET_VALID", err.Error())
+	default:
+		helpers.WriteErrorReponse(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+	}
+}
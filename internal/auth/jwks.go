@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"pr-service/internal/health"
+)
+
+// jwk - ключ из JWKS файла. Поддерживаются oct для HS256 и RSA для RS256
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// verificationKey - разобранный ключ для проверки подписи
+type verificationKey struct {
+	kid       string
+	alg       string
+	secret    []byte
+	publicKey *rsa.PublicKey
+}
+
+// KeySet - ключи из локального JWKS файла. Run раз в ReloadInterval проверяет файл
+// и перечитывает его, когда меняется время изменения или размер
+type KeySet struct {
+	Path           string
+	ReloadInterval time.Duration
+	Lgr            *slog.Logger
+
+	mu        sync.Mutex
+	keys      []*verificationKey
+	modTime   time.Time
+	size      int64
+	heartbeat *health.Heartbeat
+}
+
+// NewKeySet читает JWKS файл. Ошибка первого чтения фатальна, ошибки перечитывания
+// оставляют в работе предыдущий набор ключей
+func NewKeySet(path string, reloadInterval time.Duration, lgr *slog.Logger) (*KeySet, error) {
+	ks := &KeySet{
+		Path:           path,
+		ReloadInterval: reloadInterval,
+		Lgr:            lgr,
+		heartbeat:      health.NewHeartbeat("jwks_reloader", 3*reloadInterval),
+	}
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Keys возвращает актуальные ключи
+func (ks *KeySet) Keys() []*verificationKey {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.keys
+}
+
+// Run перечитывает файл раз в ReloadInterval до отмены ctx
+func (ks *KeySet) Run(ctx context.Context) {
+	defer ks.heartbeat.Stop()
+
+	ticker := time.NewTicker(ks.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// при ошибке остаются старые ключи, чтобы полузаписанный файл не отключил вход
+			if err := ks.reload(); err != nil {
+				ks.Lgr.With(
+					slog.String("path", ks.Path),
+					slog.String("error", err.Error()),
+				).Error("failed to reload JWKS, keeping previous keys")
+			}
+			ks.heartbeat.Beat()
+		}
+	}
+}
+
+// Heartbeat - отметки Run для проверки готовности
+func (ks *KeySet) Heartbeat() *health.Heartbeat {
+	return ks.heartbeat
+}
+
+func (ks *KeySet) reload() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	info, err := os.Stat(ks.Path)
+	if err != nil {
+		return err
+	}
+	if ks.keys != nil && info.ModTime().Equal(ks.modTime) && info.Size() == ks.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(ks.Path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ks.Path, err)
+	}
+
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	ks.size = info.Size()
+
+	ks.Lgr.With(
+		slog.String("path", ks.Path),
+		slog.Int("keys", len(keys)),
+	).Info("JWKS loaded")
+
+	return nil
+}
+
+func parseJWKS(data []byte) ([]*verificationKey, error) {
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("JWKS has no keys")
+	}
+
+	keys := make([]*verificationKey, 0, len(set.Keys))
+	for i, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		parsed, err := parseJWK(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		keys = append(keys, parsed)
+	}
+
+	return keys, nil
+}
+
+func parseJWK(key *jwk) (*verificationKey, error) {
+	switch key.Kty {
+	case "oct":
+		if key.Alg != "" && key.Alg != algHS256 {
+			return nil, fmt.Errorf("alg %s doesn't match kty oct", key.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(key.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("k must be a non-empty base64url string")
+		}
+		return &verificationKey{kid: key.Kid, alg: algHS256, secret: secret}, nil
+
+	case "RSA":
+		if key.Alg != "" && key.Alg != algRS256 {
+			return nil, fmt.Errorf("alg %s doesn't match kty RSA", key.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.New("n must be a non-empty base64url string")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 {
+			return nil, errors.New("e must be a non-empty base64url string")
+		}
+		publicKey := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return &verificationKey{kid: key.Kid, alg: algRS256, publicKey: publicKey}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported kty %q", key.Kty)
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+var (
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+)
+
+// JWTAuthenticator проверяет JWT, подписанные ключами из JWKS.
+// Пользователь берется из claim user_id (или sub), роль - из claim role
+type JWTAuthenticator struct {
+	Keys *KeySet
+	// необязательные проверки iss и aud
+	Issuer   string
+	Audience string
+	// допустимое расхождение часов для exp и nbf
+	Leeway time.Duration
+	// для тестов, по умолчанию time.Now
+	Now func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	UserId    string          `json:"user_id"`
+	Role      string          `json:"role"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+}
+
+func (ja *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// алгоритм должен совпадать с типом ключа, иначе открытый RSA ключ можно выдать за HMAC секрет
+	if header.Alg != algHS256 && header.Alg != algRS256 {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !ja.verify(header, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := ja.checkClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{UserId: claims.UserId, Role: claims.Role}
+	if principal.UserId == "" {
+		principal.UserId = claims.Subject
+	}
+
+	switch principal.Role {
+	case RoleAdmin:
+	case RoleUser:
+		if principal.UserId == "" {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	return principal, nil
+}
+
+// verify проверяет подпись ключом с нужным kid, а без kid - всеми ключами алгоритма
+func (ja *JWTAuthenticator) verify(header jwtHeader, signingInput string, signature []byte) bool {
+	hash := sha256.Sum256([]byte(signingInput))
+
+	for _, key := range ja.Keys.Keys() {
+		if key.alg != header.Alg || (header.Kid != "" && key.kid != header.Kid) {
+			continue
+		}
+
+		switch key.alg {
+		case algHS256:
+			mac := hmac.New(sha256.New, key.secret)
+			mac.Write([]byte(signingInput))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case algRS256:
+			if rsa.VerifyPKCS1v15(key.publicKey, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (ja *JWTAuthenticator) checkClaims(claims *jwtClaims) error {
+	now := time.Now()
+	if ja.Now != nil {
+		now = ja.Now()
+	}
+
+	if claims.ExpiresAt != nil && !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(ja.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if claims.NotBefore != nil && now.Add(ja.Leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if ja.Issuer != "" && claims.Issuer != ja.Issuer {
+		return ErrInvalidToken
+	}
+
+	if ja.Audience != "" && !hasAudience(claims.Audience, ja.Audience) {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// hasAudience - aud может быть строкой или массивом строк
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return slices.Contains(list, audience)
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,26 @@
+package auth
+
+// области доступа ключей API. Токены пользователей ограничены ролью, а не областями
+const (
+	ScopeTeamsRead         = "teams:read"
+	ScopeTeamsWrite        = "teams:write"
+	ScopeUsersRead         = "users:read"
+	ScopeUsersWrite        = "users:write"
+	ScopePullRequestsWrite = "pull_requests:write"
+	ScopeStatsRead         = "stats:read"
+	ScopeMetricsRead       = "metrics:read"
+	// импорт, снимки и управление ключами
+	ScopeAdmin = "admin"
+)
+
+// Scopes - все известные области в порядке документации
+var Scopes = []string{
+	ScopeTeamsRead,
+	ScopeTeamsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopePullRequestsWrite,
+	ScopeStatsRead,
+	ScopeMetricsRead,
+	ScopeAdmin,
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// StaticTokens - токены из конфигурации. Хранятся только их SHA-256 хеши
+type StaticTokens struct {
+	tokens map[[sha256.Size]byte]*Principal
+}
+
+// NewStaticTokens разбирает список вида "token1=admin,token2=user:u1".
+// Для роли user идентификатор пользователя обязателен
+func NewStaticTokens(spec string) (*StaticTokens, error) {
+	st := &StaticTokens{tokens: map[[sha256.Size]byte]*Principal{}}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		token, grant, ok := strings.Cut(entry, "=")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("auth token entry must look like token=role[:user_id]")
+		}
+
+		role, userId, _ := strings.Cut(grant, ":")
+		switch role {
+		case RoleAdmin:
+		case RoleUser:
+			if userId == "" {
+				return nil, fmt.Errorf("auth token with role %s must have user_id", RoleUser)
+			}
+		default:
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+
+		hash := sha256.Sum256([]byte(token))
+		if _, ok := st.tokens[hash]; ok {
+			return nil, fmt.Errorf("auth token is listed more than once")
+		}
+		st.tokens[hash] = &Principal{UserId: userId, Role: role}
+	}
+
+	return st, nil
+}
+
+func (st *StaticTokens) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	// сравниваются хеши, поэтому время поиска не зависит от совпавшего префикса токена
+	principal, ok := st.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return principal, nil
+}
+
+// Len возвращает количество настроенных токенов
+func (st *StaticTokens) Len() int {
+	return len(st.tokens)
+}
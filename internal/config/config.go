@@ -1,57 +1,379 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"pr-service/internal/database"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/server"
+	"pr-service/internal/service"
+	"pr-service/internal/tracing"
 )
 
+// Config - настройки сервиса и prctl
 type Config struct {
-	DBHost     string `env:"DB_HOST"`
-	DBPort     string `env:"DB_PORT"`
-	DBName     string `env:"DB_NAME"`
-	DBUser     string `env:"DB_USER"`
-	DBPassword string `env:"DB_PASSWORD"`
+	// адрес HTTP сервера и его сроки
+	Server server.Options
+	// срок обработки запроса и сроки отдельных маршрутов по ключу "METHOD /path"
+	RequestTimeout  time.Duration
+	RequestTimeouts map[string]time.Duration
+	// проверка тел запросов по схемам OpenAPI
+	ValidateRequests bool
 
-	TestDBHost     string `env:"TEST_DB_HOST"`
-	TestDBPort     string `env:"TEST_DB_PORT"`
-	TestDBName     string `env:"TEST_DB_NAME"`
-	TestDBUser     string `env:"TEST_DB_USER"`
-	TestDBPassword string `env:"TEST_DB_PASSWORD"`
+	DB DB
+
+	LogLevel slog.Level
+
+	// правила назначения ревьюверов
+	Assignment service.AssignmentPolicy
+
+	Auth Auth
+
+	// лимиты запросов клиента и неудачных аутентификаций с IP адреса
+	// в формате "rate,burst", пустое значение отключает лимит
+	RateLimitRead         string
+	RateLimitWrite        string
+	RateLimitAuthFailures string
+
+	Tracing Tracing
 }
 
-func Load(path string) (*Config, error) {
+// хранилища данных сервиса
+const (
+	BackendPostgres = "postgres"
+	// данные в памяти процесса, для разработки и mock сервера
+	BackendMemory = "memory"
+)
+
+type DB struct {
+	// postgres или memory
+	Backend string
+	// снимок данных (формат GET /api/v1/admin/export), которым заполняется хранилище в памяти
+	Fixture string
+
+	Host     string
+	Port     string
+	Name     string
+	User     string
+	Password string
+	Pool     database.PoolOptions
+}
 
-	if path != "" {
-		// путь для докера
-		if err := godotenv.Load(path); err != nil {
-			return nil, err
+// Addr - адрес подключения для lib/pq и golang-migrate
+func (db *DB) Addr() string {
+	return database.GetDbAddres(db.Host, db.Port, db.Name, db.User, db.Password)
+}
+
+type Auth struct {
+	// токены доступа в формате "token=admin,token2=user:u1"
+	Tokens string
+	// JWT от SSO: ключи из локального JWKS файла, iss и aud проверяются, если заданы
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+type Tracing struct {
+	// stdout, otlp или пусто - трассировка выключена
+	Exporter     string
+	OTLPEndpoint string
+	// доля записываемых трейсов от 0 до 1
+	SampleRatio float64
+}
+
+// Default возвращает настройки по умолчанию
+func Default() *Config {
+	return &Config{
+		Server:         server.DefaultOptions(),
+		RequestTimeout: server.DefaultRequestTimeout,
+		DB: DB{
+			Backend: BackendPostgres,
+			Host:    "localhost",
+			Port:    "5432",
+			Pool:    database.DefaultPoolOptions,
+		},
+		LogLevel:   slog.LevelInfo,
+		Assignment: service.DefaultAssignmentPolicy,
+		// 10 неверных токенов подряд, дальше одна попытка в 10 секунд
+		RateLimitAuthFailures: "0.1,10",
+		Tracing: Tracing{
+			OTLPEndpoint: tracing.DefaultOTLPEndpoint,
+			SampleRatio:  1,
+		},
+	}
+}
+
+// максимум ревьюверов на pr, больше - скорее опечатка в настройках
+const maxReviewers = 10
+
+// Validate проверяет значения, которые зависят друг от друга или обязательны.
+// Возвращает все ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(c.Server.Addr) == "" {
+		errs = append(errs, errors.New("server.addr (HTTP_ADDR) must not be empty"))
+	}
+
+	if c.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.request_timeout (%s) must not be negative", c.RequestTimeout))
+	}
+	for _, route := range slices.Sorted(maps.Keys(c.RequestTimeouts)) {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("server.request_timeouts: route %q must look like METHOD /path", route))
+		}
+		if timeout := c.RequestTimeouts[route]; timeout < 0 {
+			errs = append(errs, fmt.Errorf("server.request_timeouts: %s (%s) must not be negative", route, timeout))
+		}
+	}
+
+	// хранилищу в памяти подключение к БД не нужно
+	if c.DB.Backend == BackendPostgres {
+		for _, required := range []struct {
+			key   string
+			value string
+		}{
+			{"db.host", c.DB.Host},
+			{"db.port", c.DB.Port},
+			{"db.name", c.DB.Name},
+			{"db.user", c.DB.User},
+		} {
+			if required.value == "" {
+				errs = append(errs, fmt.Errorf("%s (%s) is required", required.key, envName(required.key)))
+			}
 		}
-	} else {
-		// дефолтный путь для локального запуска
-		if err := godotenv.Load("../../.env"); err != nil {
-			return nil, err
+	}
+
+	if c.DB.Fixture != "" && c.DB.Backend != BackendMemory {
+		errs = append(errs, fmt.Errorf("db.fixture (DB_FIXTURE) requires db.backend=%s", BackendMemory))
+	}
+
+	if pool := c.DB.Pool; pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("db.max_idle_conns (%d) must not exceed db.max_open_conns (%d)", pool.MaxIdleConns, pool.MaxOpenConns))
+	}
+
+	if c.Assignment.Reviewers < 1 || c.Assignment.Reviewers > maxReviewers {
+		errs = append(errs, fmt.Errorf("assignment.reviewers (%d) must be from 1 to %d", c.Assignment.Reviewers, maxReviewers))
+	}
+
+	return errors.Join(errs...)
+}
+
+// setting - одна настройка: ключ в YAML файле, он же имя флага, и переменная окружения.
+// reload - настройка применяется при перезагрузке без перезапуска
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	value  value
+	reload bool
+}
+
+// value читает и записывает поле Config в строковом виде
+type value struct {
+	set func(c *Config, value string) error
+	get func(c *Config) string
+}
+
+var settings = []setting{
+	{"server.addr", "HTTP_ADDR", "HTTP listen address", stringValue(func(c *Config) *string { return &c.Server.Addr }), false},
+	{"server.read_timeout", "HTTP_READ_TIMEOUT", "time to read a request", durationValue(func(c *Config) *time.Duration { return &c.Server.ReadTimeout }), false},
+	{"server.write_timeout", "HTTP_WRITE_TIMEOUT", "time to write a response, longer than any route timeout", durationValue(func(c *Config) *time.Duration { return &c.Server.WriteTimeout }), false},
+	{"server.idle_timeout", "HTTP_IDLE_TIMEOUT", "keep-alive connection idle time", durationValue(func(c *Config) *time.Duration { return &c.Server.IdleTimeout }), false},
+	{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "time to finish started requests on shutdown", durationValue(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }), false},
+	{"server.request_timeout", "REQUEST_TIMEOUT", "request processing deadline, 0 disables it", durationValue(func(c *Config) *time.Duration { return &c.RequestTimeout }), false},
+	{"server.request_timeouts", "REQUEST_TIMEOUTS", `per-route deadlines: "POST /api/v1/import=2m,..."`, routeTimeoutsValue(func(c *Config) *map[string]time.Duration { return &c.RequestTimeouts }), false},
+	{"server.validate_requests", "VALIDATE_REQUESTS", "validate request bodies against the OpenAPI schemas", boolValue(func(c *Config) *bool { return &c.ValidateRequests }), false},
+
+	{"db.backend", "DB_BACKEND", "storage: postgres or memory (data lives until the process stops)", value{
+		set: func(c *Config, value string) error {
+			if value != BackendPostgres && value != BackendMemory {
+				return errors.New("expected postgres or memory")
+			}
+			c.DB.Backend = value
+			return nil
+		},
+		get: func(c *Config) string { return c.DB.Backend },
+	}, false},
+	{"db.fixture", "DB_FIXTURE", "snapshot JSON file to seed the memory storage with", stringValue(func(c *Config) *string { return &c.DB.Fixture }), false},
+	{"db.host", "DB_HOST", "database host", stringValue(func(c *Config) *string { return &c.DB.Host }), false},
+	{"db.port", "DB_PORT", "database port", stringValue(func(c *Config) *string { return &c.DB.Port }), false},
+	{"db.name", "DB_NAME", "database name", stringValue(func(c *Config) *string { return &c.DB.Name }), false},
+	{"db.user", "DB_USER", "database user", stringValue(func(c *Config) *string { return &c.DB.User }), false},
+	{"db.password", "DB_PASSWORD", "database password", stringValue(func(c *Config) *string { return &c.DB.Password }), false},
+	{"db.max_open_conns", "DB_MAX_OPEN_CONNS", "maximum open connections, 0 - unlimited", intValue(func(c *Config) *int { return &c.DB.Pool.MaxOpenConns }), false},
+	{"db.max_idle_conns", "DB_MAX_IDLE_CONNS", "maximum idle connections", intValue(func(c *Config) *int { return &c.DB.Pool.MaxIdleConns }), false},
+	{"db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "maximum connection age, 0 - unlimited", durationValue(func(c *Config) *time.Duration { return &c.DB.Pool.ConnMaxLifetime }), false},
+	{"db.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "maximum connection idle time, 0 - unlimited", durationValue(func(c *Config) *time.Duration { return &c.DB.Pool.ConnMaxIdleTime }), false},
+
+	{"log.level", "LOG_LEVEL", "log level: debug, info, warn or error", value{
+		set: func(c *Config, value string) error {
+			if err := c.LogLevel.UnmarshalText([]byte(value)); err != nil {
+				return errors.New("expected debug, info, warn or error")
+			}
+			return nil
+		},
+		get: func(c *Config) string { return strings.ToLower(c.LogLevel.String()) },
+	}, true},
+
+	{"assignment.reviewers", "ASSIGNMENT_REVIEWERS", "reviewers assigned to a new pull request", intValue(func(c *Config) *int { return &c.Assignment.Reviewers }), true},
+	{"assignment.escalate_to_parent", "ASSIGNMENT_ESCALATE_TO_PARENT", "pick missing reviewers from parent teams", boolValue(func(c *Config) *bool { return &c.Assignment.EscalateToParent }), true},
+
+	{"auth.tokens", "AUTH_TOKENS", `static tokens: "token=admin,token2=user:u1"`, stringValue(func(c *Config) *string { return &c.Auth.Tokens }), false},
+	{"auth.jwks_file", "JWKS_FILE", "JWKS file with SSO keys", stringValue(func(c *Config) *string { return &c.Auth.JWKSFile }), false},
+	{"auth.jwt_issuer", "JWT_ISSUER", "expected JWT iss", stringValue(func(c *Config) *string { return &c.Auth.JWTIssuer }), false},
+	{"auth.jwt_audience", "JWT_AUDIENCE", "expected JWT aud", stringValue(func(c *Config) *string { return &c.Auth.JWTAudience }), false},
+
+	{"rate_limit.read", "RATE_LIMIT_READ", `GET requests per client: "rate,burst"`, budgetValue(func(c *Config) *string { return &c.RateLimitRead }), true},
+	{"rate_limit.write", "RATE_LIMIT_WRITE", `other requests per client: "rate,burst"`, budgetValue(func(c *Config) *string { return &c.RateLimitWrite }), true},
+	{"rate_limit.auth_failures", "RATE_LIMIT_AUTH_FAILURES", `failed authentications per IP address: "rate,burst"`, budgetValue(func(c *Config) *string { return &c.RateLimitAuthFailures }), true},
+
+	{"tracing.exporter", "TRACING_EXPORTER", "span exporter: stdout, otlp or empty to disable tracing", value{
+		set: func(c *Config, value string) error {
+			if value != "" && value != "stdout" && value != "otlp" {
+				return errors.New("expected stdout, otlp or empty value")
+			}
+			c.Tracing.Exporter = value
+			return nil
+		},
+		get: func(c *Config) string { return c.Tracing.Exporter },
+	}, false},
+	{"tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT", "OTLP/HTTP collector URL", stringValue(func(c *Config) *string { return &c.Tracing.OTLPEndpoint }), false},
+	{"tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "share of recorded traces from 0 to 1", value{
+		set: func(c *Config, value string) error {
+			ratio, err := tracing.ParseSampleRatio(value)
+			if err != nil {
+				return errors.New("expected a number from 0 to 1")
+			}
+			c.Tracing.SampleRatio = ratio
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatFloat(c.Tracing.SampleRatio, 'g', -1, 64) },
+	}, false},
+}
+
+// секреты не попадают в логи изменений
+var secretSettings = map[string]bool{
+	"db.password": true,
+	"auth.tokens": true,
+}
+
+func envName(key string) string {
+	for _, s := range settings {
+		if s.key == key {
+			return s.env
 		}
 	}
-	c := &Config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBName:     os.Getenv("DB_NAME"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
+	return ""
+}
+
+func stringValue(field func(c *Config) *string) value {
+	return value{
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+// budgetValue - лимит запросов в формате ratelimit.ParseBudget
+func budgetValue(field func(c *Config) *string) value {
+	return value{
+		set: func(c *Config, value string) error {
+			if _, err := ratelimit.ParseBudget(value); err != nil {
+				return errors.New(`expected "rate,burst" such as "20,40" or empty value`)
+			}
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+func durationValue(field func(c *Config) *time.Duration) value {
+	return value{
+		set: func(c *Config, value string) error {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration < 0 {
+				return errors.New(`expected a non-negative duration such as "15s" or "2m"`)
+			}
+			*field(c) = duration
+			return nil
+		},
+		get: func(c *Config) string { return field(c).String() },
+	}
+}
+
+// routeTimeoutsValue - сроки маршрутов в формате "POST /api/v1/import=2m,GET /api/v1/stats=5s"
+func routeTimeoutsValue(field func(c *Config) *map[string]time.Duration) value {
+	return value{
+		set: func(c *Config, value string) error {
+			timeouts := map[string]time.Duration{}
+			for _, item := range strings.Split(value, ",") {
+				item = strings.TrimSpace(item)
+				if item == "" {
+					continue
+				}
 
-		TestDBHost:     os.Getenv("TEST_DB_HOST"),
-		TestDBPort:     os.Getenv("TEST_DB_PORT"),
-		TestDBName:     os.Getenv("TEST_DB_NAME"),
-		TestDBUser:     os.Getenv("TEST_DB_USER"),
-		TestDBPassword: os.Getenv("TEST_DB_PASSWORD"),
+				route, rawTimeout, ok := strings.Cut(item, "=")
+				method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+				if !ok || !hasPath || method == "" || !strings.HasPrefix(path, "/") {
+					return fmt.Errorf("route timeout %q must look like METHOD /path=duration", item)
+				}
+
+				timeout, err := time.ParseDuration(strings.TrimSpace(rawTimeout))
+				if err != nil || timeout < 0 {
+					return fmt.Errorf("route timeout %q has invalid duration", item)
+				}
+				timeouts[strings.ToUpper(method)+" "+path] = timeout
+			}
+			*field(c) = timeouts
+			return nil
+		},
+		get: func(c *Config) string {
+			timeouts := *field(c)
+			items := make([]string, 0, len(timeouts))
+			for _, route := range slices.Sorted(maps.Keys(timeouts)) {
+				items = append(items, route+"="+timeouts[route].String())
+			}
+			return strings.Join(items, ",")
+		},
 	}
+}
 
-	//если запуск в докере, то устанавливаем название хоста для БД как имя контейнера
-	if os.Getenv("DOCKER") == "1" {
-		c.DBHost = "postgresql"
-		c.TestDBHost = "postgres_test" //не успел
+func intValue(field func(c *Config) *int) value {
+	return value{
+		set: func(c *Config, value string) error {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 0 {
+				return errors.New("expected a non-negative integer")
+			}
+			*field(c) = number
+			return nil
+		},
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
 	}
+}
 
-	return c, nil
+func boolValue(field func(c *Config) *bool) value {
+	return value{
+		set: func(c *Config, value string) error {
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("expected true or false")
+			}
+			*field(c) = flag
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+	}
 }
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	// переменная окружения с путем к YAML файлу, если не задан флаг -config
+	configFileEnv = "CONFIG_FILE"
+	// .env читается из рабочего каталога, если он есть
+	defaultEnvFile = ".env"
+)
+
+// Flags - флаги командной строки: файлы настроек и значения отдельных настроек
+type Flags struct {
+	// YAML файл настроек
+	ConfigFile string
+	// .env файл, его переменные не заменяют непустые переменные окружения
+	EnvFile string
+
+	values []flagValue
+}
+
+type flagValue struct {
+	setting setting
+	value   string
+}
+
+// RegisterFlags добавляет в flags -config, -env и флаг для каждой настройки
+// с именем ключа YAML файла, например -server.addr. Значения применяются в Load
+func RegisterFlags(flags *flag.FlagSet) *Flags {
+	f := &Flags{}
+	flags.StringVar(&f.ConfigFile, "config", "", "path to YAML config file (env "+configFileEnv+")")
+	flags.StringVar(&f.EnvFile, "env", "", "path to .env file (default ./.env if it exists)")
+	for _, s := range settings {
+		flags.Func(s.key, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(value string) error {
+			f.values = append(f.values, flagValue{setting: s, value: value})
+			return nil
+		})
+	}
+	return f
+}
+
+// Load собирает настройки. Каждый следующий источник переопределяет предыдущий:
+// значения по умолчанию, YAML файл, переменные окружения (в том числе из .env),
+// флаги. Пустая переменная окружения считается незаданной. Ошибки всех настроек
+// возвращаются вместе. flags может быть nil
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
+	dotenv, err := readEnvFile(flags.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// заданные в окружении переменные главнее .env. Окружение процесса не меняется,
+	// поэтому при перезагрузке настроек изменения .env тоже учитываются
+	getenv := func(name string) string {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return dotenv[name]
+	}
+
+	c := Default()
+	var errs []error
+
+	configFile := flags.ConfigFile
+	if configFile == "" {
+		configFile = getenv(configFileEnv)
+	}
+	if configFile != "" {
+		if err := c.applyFile(configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range settings {
+		value := getenv(s.env)
+		if value == "" {
+			continue
+		}
+		if err := s.value.set(c, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from %s: %w", s.key, value, s.env, err))
+		}
+	}
+
+	for _, fv := range flags.values {
+		if err := fv.setting.value.set(c, fv.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from flag -%s: %w", fv.setting.key, fv.value, fv.setting.key, err))
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return c, nil
+}
+
+// readEnvFile читает переменные из .env. Отсутствие файла по умолчанию не ошибка,
+// а явно указанного - ошибка
+func readEnvFile(path string) (map[string]string, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultEnvFile); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		path = defaultEnvFile
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func (c *Config) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values, err := parseYAML(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	known := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		known[s.key] = s
+	}
+
+	// ошибки в порядке строк файла
+	keys := slices.SortedFunc(maps.Keys(values), func(a, b string) int {
+		return values[a].line - values[b].line
+	})
+
+	var errs []error
+	for _, key := range keys {
+		v := values[key]
+		s, ok := known[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s:%d: unknown setting %q", path, v.line, key))
+			continue
+		}
+		if err := s.value.set(c, v.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %s: invalid value %q: %w", path, v.line, key, v.value, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"pr-service/internal/logging"
+)
+
+// Change - изменение одной настройки, значения секретов скрыты
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// ReloadResult - примененные изменения и изменения, которые требуют перезапуска
+type ReloadResult struct {
+	Applied         []Change
+	RestartRequired []Change
+}
+
+type IReloader interface {
+	Reload(ctx context.Context) (*ReloadResult, error)
+}
+
+// Reloader перечитывает настройки и применяет те, что можно менять без перезапуска:
+// уровень логов, лимиты запросов и правила назначения ревьюверов
+type Reloader struct {
+	// Load собирает новые настройки из тех же источников, что и при запуске
+	Load func() (*Config, error)
+	// Apply применяет новые настройки к работающему сервису. Вызывается только
+	// с проверенными настройками и только если что-то изменилось
+	Apply func(next *Config) error
+	Lgr   *slog.Logger
+
+	mu      sync.Mutex
+	current *Config
+}
+
+func NewReloader(current *Config, load func() (*Config, error), apply func(next *Config) error, lgr *slog.Logger) *Reloader {
+	return &Reloader{
+		Load:    load,
+		Apply:   apply,
+		Lgr:     lgr,
+		current: current,
+	}
+}
+
+// Current возвращает действующие настройки
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload перечитывает настройки. Если они не проходят проверку или не применились,
+// продолжают действовать прежние. Перезагрузки по SIGHUP и через API не пересекаются
+func (r *Reloader) Reload(ctx context.Context) (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lgr := logging.FromContext(ctx, r.Lgr)
+
+	loaded, err := r.Load()
+	if err != nil {
+		lgr.With(
+			slog.String("error", err.Error()),
+		).Error("configuration reload rejected, keeping the current configuration")
+		return nil, err
+	}
+
+	// в действующие настройки переносятся только перезагружаемые значения
+	next := *r.current
+	result := &ReloadResult{Applied: []Change{}, RestartRequired: []Change{}}
+	for _, s := range settings {
+		oldValue, newValue := s.value.get(r.current), s.value.get(loaded)
+		if oldValue == newValue {
+			continue
+		}
+
+		change := Change{Key: s.key, Old: oldValue, New: newValue}
+		if secretSettings[s.key] {
+			change.Old, change.New = "***", "***"
+		}
+
+		if !s.reload {
+			result.RestartRequired = append(result.RestartRequired, change)
+			continue
+		}
+		if err := s.value.set(&next, newValue); err != nil {
+			return nil, err
+		}
+		result.Applied = append(result.Applied, change)
+	}
+
+	for _, change := range result.RestartRequired {
+		lgr.With(
+			slog.String("setting", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		).Warn("setting change requires a restart and was not applied")
+	}
+
+	if len(result.Applied) == 0 {
+		lgr.Info("configuration reloaded, nothing to apply")
+		return result, nil
+	}
+
+	if err := r.Apply(&next); err != nil {
+		lgr.With(
+			slog.String("error", err.Error()),
+		).Error("failed to apply configuration, keeping the current configuration")
+		return nil, err
+	}
+	r.current = &next
+
+	for _, change := range result.Applied {
+		lgr.With(
+			slog.String("setting", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		).Info("setting changed")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlValue - скалярное значение из файла настроек и строка, где оно задано
+type yamlValue struct {
+	value string
+	line  int
+}
+
+// parseYAML разбирает файл настроек и возвращает скалярные значения по полному ключу,
+// например "server.addr". Вложенными могут быть только словари: списки в настройках
+// не используются, поэтому считаются ошибкой
+func parseYAML(data []byte) (map[string]yamlValue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	values := map[string]yamlValue{}
+	// пустой файл
+	if len(doc.Content) == 0 {
+		return values, nil
+	}
+
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected \"key: value\" mapping", root.Line)
+	}
+	if err := flattenYAML(root, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenYAML(mapping *yaml.Node, prefix string, values map[string]yamlValue) error {
+	var errs []error
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keyNode, valueNode := mapping.Content[i], resolveAlias(mapping.Content[i+1])
+		if keyNode.Kind != yaml.ScalarNode {
+			errs = append(errs, fmt.Errorf("line %d: key must be a string", keyNode.Line))
+			continue
+		}
+
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch {
+		case valueNode.Kind == yaml.MappingNode:
+			if err := flattenYAML(valueNode, key, values); err != nil {
+				errs = append(errs, err)
+			}
+		case valueNode.Kind == yaml.ScalarNode && valueNode.Tag == "!!null":
+			// "key:" без значения оставляет значение по умолчанию
+		case valueNode.Kind == yaml.ScalarNode:
+			if _, exists := values[key]; exists {
+				errs = append(errs, fmt.Errorf("line %d: duplicate key %q", keyNode.Line, key))
+				continue
+			}
+			values[key] = yamlValue{value: valueNode.Value, line: valueNode.Line}
+		default:
+			errs = append(errs, fmt.Errorf("line %d: %s: lists are not supported, use a string", valueNode.Line, key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveAlias заменяет *alias узлом, на который он ссылается
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
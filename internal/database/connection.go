@@ -1,20 +1,53 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io/fs"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+
+	"pr-service/internal/tracing"
+	"pr-service/migrations"
 )
 
-func ConnectDB(addr string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", addr)
+// PoolOptions - размер пула и время жизни подключений. Ноль в MaxOpenConns и сроках
+// снимает ограничение, ноль в MaxIdleConns закрывает подключения сразу после запроса
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolOptions держат подключений не больше, чем Postgres разрешает по умолчанию
+// нескольким экземплярам сервиса, и периодически обновляют их после переключения БД
+var DefaultPoolOptions = PoolOptions{
+	MaxOpenConns:    20,
+	MaxIdleConns:    10,
+	ConnMaxLifetime: 30 * time.Minute,
+	ConnMaxIdleTime: 5 * time.Minute,
+}
+
+func ConnectDB(addr string, pool PoolOptions) (*sql.DB, error) {
+	connector, err := pq.NewConnector(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	// SQL запросы попадают в трейс HTTP запроса, если он трассируется
+	db := sql.OpenDB(tracing.WrapConnector(connector))
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
@@ -40,11 +73,22 @@ func GetDbAddres(host, port, name, user, password string) string {
 	return addr
 }
 
+// таблица, в которой golang-migrate хранит версию схемы
+const migrationsTable = "schema_migrations"
+
+// newMigrate применяет миграции, встроенные в бинарник, поэтому версия схемы
+// всегда соответствует коду
+func newMigrate(addr string) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithSourceInstance("iofs", source, addr)
+}
+
 func RunMigrations(addr string) error {
-	m, err := migrate.New(
-		"file://migrations",
-		addr,
-	)
+	m, err := newMigrate(addr)
 	if err != nil {
 		return err
 	}
@@ -55,3 +99,85 @@ func RunMigrations(addr string) error {
 
 	return nil
 }
+
+// RollbackMigrations откатывает steps последних миграций
+func RollbackMigrations(addr string, steps int) error {
+	m, err := newMigrate(addr)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// GetMigrationVersion возвращает текущую версию схемы и признак незавершенной миграции.
+// Если миграции еще не применялись, версия равна 0
+func GetMigrationVersion(addr string) (uint, bool, error) {
+	m, err := newMigrate(addr)
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// LatestMigrationVersion возвращает версию последней миграции, встроенной в бинарник
+func LatestMigrationVersion() (uint, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// CheckMigrationVersion сравнивает версию схемы в БД с последней встроенной миграцией.
+// Версия читается через общий пул подключений, поэтому проверку можно звать часто
+func CheckMigrationVersion(ctx context.Context, db *sql.DB) error {
+	expected, err := LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	var version uint
+	var dirty bool
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM "+migrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version != expected {
+		return fmt.Errorf("database schema version is %d, binary expects %d", version, expected)
+	}
+
+	return nil
+}
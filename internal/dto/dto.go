@@ -50,17 +50,146 @@ type ResponseTeamDTO struct {
 	Team *TeamDTO `json:"team"`
 }
 
-type User struct {
+type FieldChangeDTO struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+type UserChangeDTO struct {
+	UserId  string                     `json:"user_id"`
+	Changes map[string]*FieldChangeDTO `json:"changes"`
+}
+
+type TeamSyncDiffDTO struct {
+	TeamCreated   bool             `json:"team_created"`
+	CreatedUsers  []string         `json:"created_users"`
+	AddedUsers    []string         `json:"added_users"`
+	UpdatedUsers  []*UserChangeDTO `json:"updated_users"`
+	DetachedUsers []string         `json:"detached_users"`
+}
+
+type ResponseTeamSyncDTO struct {
+	Team   *TeamDTO         `json:"team"`
+	Diff   *TeamSyncDiffDTO `json:"diff"`
+	DryRun bool             `json:"dry_run"`
+}
+
+type RequestSetParentDTO struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name"`
+}
+
+type TeamTreeDTO struct {
+	TeamName       string         `json:"team_name"`
+	ParentTeamName string         `json:"parent_team_name,omitempty"`
+	Children       []*TeamTreeDTO `json:"children"`
+}
+
+type ResponseTeamTreeDTO struct {
+	Teams []*TeamTreeDTO `json:"teams"`
+}
+
+type TeamLeadsDTO struct {
+	TeamName string   `json:"team_name"`
+	Leads    []string `json:"leads"`
+}
+
+type TeamSummaryDTO struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	Members        int    `json:"members"`
+	ActiveMembers  int    `json:"active_members"`
+}
+
+type ResponseTeamListDTO struct {
+	Teams []*TeamSummaryDTO `json:"teams"`
+}
+
+// ImportRowDTO - одна строка импорта: команда и, необязательно, ее участник
+type ImportRowDTO struct {
+	Row            int    `json:"row"`
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	UserId         string `json:"user_id,omitempty"`
+	Username       string `json:"username,omitempty"`
+	IsActive       bool   `json:"is_active"`
+}
+
+// ImportMemberDTO - участник команды в JSON файле импорта. Без is_active пользователь
+// активен, как и в CSV
+type ImportMemberDTO struct {
 	UserId   string `json:"user_id"`
 	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type ImportTeamDTO struct {
+	TeamName       string             `json:"team_name"`
+	ParentTeamName string             `json:"parent_team_name"`
+	Members        []*ImportMemberDTO `json:"members"`
+}
+
+// RequestImportJSONDTO - формат JSON файла импорта
+type RequestImportJSONDTO struct {
+	Teams []*ImportTeamDTO `json:"teams"`
+}
+
+type ImportRowErrorDTO struct {
+	Row     int    `json:"row"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ResponseImportDTO struct {
+	Mode             string               `json:"mode"`
+	Committed        bool                 `json:"committed"`
+	TotalRows        int                  `json:"total_rows"`
+	ImportedRows     int                  `json:"imported_rows"`
+	TeamsCreated     int                  `json:"teams_created"`
+	UsersCreated     int                  `json:"users_created"`
+	UsersUpdated     int                  `json:"users_updated"`
+	MembershipsAdded int                  `json:"memberships_added"`
+	Errors           []*ImportRowErrorDTO `json:"errors"`
+}
+
+type User struct {
+	UserId   string            `json:"user_id"`
+	Username string            `json:"username"`
+	TeamName string            `json:"team_name"`
+	IsActive bool              `json:"is_active"`
+	Teams    []string          `json:"teams,omitempty"`
+	Email    string            `json:"email,omitempty"`
+	Handles  map[string]string `json:"handles,omitempty"`
 }
 
 type UserDTO struct {
 	User *User `json:"user"`
 }
 
+// RequestUpdateUserDTO - поля со значением nil не меняются
+type RequestUpdateUserDTO struct {
+	UserId   string            `json:"user_id"`
+	Username *string           `json:"username"`
+	TeamName *string           `json:"team_name"`
+	Email    *string           `json:"email"`
+	Handles  map[string]string `json:"handles"`
+}
+
+type RequestDeleteUserDTO struct {
+	UserId          string `json:"user_id"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type ReassignedReviewDTO struct {
+	PullRequestId string `json:"pull_request_id"`
+	ReplacedBy    string `json:"replaced_by"`
+}
+
+type ResponseDeleteUserDTO struct {
+	UserId     string                 `json:"user_id"`
+	Reassigned []*ReassignedReviewDTO `json:"reassigned"`
+}
+
 type IsActiveUserDTO struct {
 	Id       string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
@@ -70,6 +199,8 @@ type RequestPullrequestDTO struct {
 	PullRequestId   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// необязательно, по умолчанию основная команда автора
+	TeamName string `json:"team_name,omitempty"`
 }
 
 type PullrequestDTO struct {
@@ -78,6 +209,7 @@ type PullrequestDTO struct {
 	AuthorID          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
+	TeamName          string   `json:"team_name,omitempty"`
 }
 
 type ResponsePullrequestDTO struct {
@@ -139,8 +271,150 @@ type UserPullRequestsDTO struct {
 	PullRequests []*UserPullRequestDTO `json:"pull_requests"`
 }
 
+type TeamCountersDTO struct {
+	TotalPRs    int            `json:"total_prs"`
+	PRsByStatus map[string]int `json:"pr_by_status"`
+	Assignments int            `json:"assignments"`
+}
+
+// TeamStatsDTO - Own учитывает только PR самой команды, Total - всего поддерева
+type TeamStatsDTO struct {
+	TeamName string           `json:"team_name"`
+	Own      *TeamCountersDTO `json:"own"`
+	Total    *TeamCountersDTO `json:"total"`
+	Children []*TeamStatsDTO  `json:"children"`
+}
+
 type StatsResponseDTO struct {
-	TotalPRs          int            `json:"total_prs"`
-	PRsByStatus       map[string]int `json:"pr_by_status"`
-	AssignmentsByUser map[string]int `json:"assignments_by_user"`
+	TotalPRs          int             `json:"total_prs"`
+	PRsByStatus       map[string]int  `json:"pr_by_status"`
+	AssignmentsByUser map[string]int  `json:"assignments_by_user"`
+	Teams             []*TeamStatsDTO `json:"teams"`
+}
+
+// версия формата снимка данных
+const SnapshotVersion = 1
+
+type SnapshotTeamDTO struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+}
+
+type SnapshotUserDTO struct {
+	UserId    string            `json:"user_id"`
+	Username  string            `json:"username"`
+	IsActive  bool              `json:"is_active"`
+	Email     string            `json:"email,omitempty"`
+	Handles   map[string]string `json:"handles,omitempty"`
+	DeletedAt *time.Time        `json:"deleted_at,omitempty"`
+}
+
+type SnapshotMembershipDTO struct {
+	TeamName  string `json:"team_name"`
+	UserId    string `json:"user_id"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type SnapshotTeamLeadDTO struct {
+	TeamName string `json:"team_name"`
+	UserId   string `json:"user_id"`
+}
+
+type SnapshotPullRequestDTO struct {
+	PullRequestId   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	Status          string     `json:"status"`
+	TeamName        string     `json:"team_name,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	MergedAt        *time.Time `json:"merged_at,omitempty"`
+}
+
+type SnapshotReviewerDTO struct {
+	PullRequestId string `json:"pull_request_id"`
+	UserId        string `json:"user_id"`
+}
+
+type SnapshotEventDTO struct {
+	PullRequestId string    `json:"pull_request_id"`
+	EventType     string    `json:"event_type"`
+	UserId        string    `json:"user_id,omitempty"`
+	OldUserId     string    `json:"old_user_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SnapshotDTO - полный снимок данных сервиса
+type SnapshotDTO struct {
+	Version      int                       `json:"version"`
+	ExportedAt   time.Time                 `json:"exported_at"`
+	Teams        []*SnapshotTeamDTO        `json:"teams"`
+	Users        []*SnapshotUserDTO        `json:"users"`
+	Memberships  []*SnapshotMembershipDTO  `json:"memberships"`
+	Leads        []*SnapshotTeamLeadDTO    `json:"leads"`
+	PullRequests []*SnapshotPullRequestDTO `json:"pull_requests"`
+	Reviewers    []*SnapshotReviewerDTO    `json:"reviewers"`
+	History      []*SnapshotEventDTO       `json:"history"`
+}
+
+type ResponseRestoreDTO struct {
+	Version      int `json:"version"`
+	Teams        int `json:"teams"`
+	Users        int `json:"users"`
+	Memberships  int `json:"memberships"`
+	Leads        int `json:"leads"`
+	PullRequests int `json:"pull_requests"`
+	Reviewers    int `json:"reviewers"`
+	History      int `json:"history"`
+}
+
+type RequestCreateAPIKeyDTO struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyDTO - ключ без секрета, Prefix помогает узнать ключ в логах и конфигурациях
+type APIKeyDTO struct {
+	KeyId      int        `json:"key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ResponseCreateAPIKeyDTO - Token показывается только один раз, в БД хранится его хеш
+type ResponseCreateAPIKeyDTO struct {
+	Key   *APIKeyDTO `json:"key"`
+	Token string     `json:"token"`
+}
+
+type ResponseAPIKeysDTO struct {
+	Keys []*APIKeyDTO `json:"keys"`
+}
+
+// ResponseHealthDTO - результат проверок готовности, Checks по имени проверки
+type ResponseHealthDTO struct {
+	Status string                     `json:"status"`
+	Checks map[string]*HealthCheckDTO `json:"checks,omitempty"`
+}
+
+type HealthCheckDTO struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// ResponseConfigReloadDTO - изменения настроек после перезагрузки
+type ResponseConfigReloadDTO struct {
+	Applied         []*ConfigChangeDTO `json:"applied"`
+	RestartRequired []*ConfigChangeDTO `json:"restart_required"`
+}
+
+type ConfigChangeDTO struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
 }
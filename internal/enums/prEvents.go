@@ -0,0 +1,8 @@
+package enums
+
+// события истории pr
+var (
+	EVENT_ASSIGNED   = "ASSIGNED"
+	EVENT_REASSIGNED = "REASSIGNED"
+	EVENT_MERGED     = "MERGED"
+)
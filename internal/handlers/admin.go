@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pr-service/internal/config"
+	"pr-service/internal/dto"
+	"pr-service/internal/helpers"
+	"pr-service/internal/service"
+)
+
+// ограничение размера снимка при восстановлении
+const maxSnapshotBodySize = 100 << 20
+
+type IAdminHandlers interface {
+	Export(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	ReloadConfig(w http.ResponseWriter, r *http.Request)
+}
+
+type AdminHandlers struct {
+	SnapshotService service.ISnapshotService
+	// перезагрузка настроек, как по SIGHUP
+	ConfigReloader config.IReloader
+}
+
+func (ah *AdminHandlers) Export(w http.ResponseWriter, r *http.Request) {
+	responseDTO, err := ah.SnapshotService.Export(r.Context())
+	if err != nil {
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (ah *AdminHandlers) Restore(w http.ResponseWriter, r *http.Request) {
+	// десереализуем снимок
+	var requestDTO dto.SnapshotDTO
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSnapshotBodySize)).Decode(&requestDTO); err != nil {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := ah.SnapshotService.Restore(r.Context(), &requestDTO)
+	if err != nil {
+		// если в снимке нарушена ссылочная целостность, в ответе перечисляются все нарушения
+		var validationErr *service.SnapshotValidationError
+		if errors.As(err, &validationErr) {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "INVALID_SNAPSHOT", strings.Join(validationErr.Problems, "; "))
+			return
+		}
+
+		// если в БД уже есть данные
+		if errors.Is(err, service.ErrDatabaseNotEmpty) {
+			helpers.WriteErrorReponse(w, http.StatusConflict, "DB_NOT_EMPTY", errDatabaseNotEmpty.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusCreated, responseDTO)
+}
+
+// ReloadConfig - POST /api/v1/admin/config/reload
+func (ah *AdminHandlers) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	result, err := ah.ConfigReloader.Reload(r.Context())
+	if err != nil {
+		// новые настройки не прошли проверку, действуют прежние
+		helpers.WriteErrorReponse(w, http.StatusUnprocessableEntity, "INVALID_CONFIG", strings.ReplaceAll(err.Error(), "\n", "; "))
+		return
+	}
+
+	responseDTO := &dto.ResponseConfigReloadDTO{
+		Applied:         newConfigChangeDTOs(result.Applied),
+		RestartRequired: newConfigChangeDTOs(result.RestartRequired),
+	}
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func newConfigChangeDTOs(changes []config.Change) []*dto.ConfigChangeDTO {
+	changeDTOs := make([]*dto.ConfigChangeDTO, 0, len(changes))
+	for _, change := range changes {
+		changeDTOs = append(changeDTOs, &dto.ConfigChangeDTO{Key: change.Key, Old: change.Old, New: change.New})
+	}
+	return changeDTOs
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/helpers"
+	"pr-service/internal/service"
+	"pr-service/internal/validators"
+)
+
+type IAPIKeysHandlers interface {
+	CreateKey(w http.ResponseWriter, r *http.Request)
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	RevokeKey(w http.ResponseWriter, r *http.Request)
+}
+
+type APIKeysHandlers struct {
+	APIKeysService service.IAPIKeysService
+}
+
+// CreateKey - POST /api/v1/admin/api-keys
+func (ah *APIKeysHandlers) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var requestDTO dto.RequestCreateAPIKeyDTO
+	if !decodeBody(w, r, &requestDTO) {
+		return
+	}
+
+	// валидация, срок действия должен быть в будущем
+	validator := validators.NewValidator()
+
+	validator.ValidateAPIKeyName(requestDTO.Name)
+	validator.ValidateScopes(requestDTO.Scopes, auth.Scopes)
+
+	if !validator.GetIsValid() || (requestDTO.ExpiresAt != nil && !requestDTO.ExpiresAt.After(time.Now())) {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := ah.APIKeysService.CreateKey(r.Context(), &requestDTO)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если действующий ключ с таким именем уже есть
+		if errors.Is(err, service.ErrAPIKeyExists) {
+			helpers.WriteErrorReponse(w, http.StatusConflict, "API_KEY_EXISTS", errAPIKeyExists.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusCreated, responseDTO)
+}
+
+// ListKeys - GET /api/v1/admin/api-keys
+func (ah *APIKeysHandlers) ListKeys(w http.ResponseWriter, r *http.Request) {
+	responseDTO, err := ah.APIKeysService.ListKeys(r.Context())
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+// RevokeKey - DELETE /api/v1/admin/api-keys/{id}
+func (ah *APIKeysHandlers) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	keyId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || keyId <= 0 {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := ah.APIKeysService.RevokeKey(r.Context(), keyId)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если ключ не существует
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
@@ -5,6 +5,7 @@ import "errors"
 var (
 	errWrongMethod    = errors.New("this method isn't acceptable")
 	errInternalServer = errors.New("server error")
+	errTimeout        = errors.New("request took too long and was canceled")
 	errMissingParam   = errors.New("query parameter is missing")
 	errWrongDataInput = errors.New("wrong format of input data")
 
@@ -17,4 +18,12 @@ var (
 	errNotFound    = errors.New("resourse not found")
 	errUserExists  = errors.New("user_id already exists")
 	errEmptyBody   = errors.New("request body is empty")
+
+	errDuplicatedMember = errors.New("user_id is listed more than once")
+	errHasOpenReviews   = errors.New("user has open reviews, reassign them first")
+	errTeamCycle        = errors.New("team can't be a descendant of itself")
+	errWrongImportFile  = errors.New("import file, format or mode is invalid")
+	errDatabaseNotEmpty = errors.New("restore is allowed only into an empty database")
+	errForbidden        = errors.New("not enough permissions for this action")
+	errAPIKeyExists     = errors.New("active api key with this name already exists")
 )
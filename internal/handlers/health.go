@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/health"
+	"pr-service/internal/helpers"
+)
+
+type IHealthHandlers interface {
+	Liveness(w http.ResponseWriter, r *http.Request)
+	Readiness(w http.ResponseWriter, r *http.Request)
+}
+
+type HealthHandlers struct {
+	Checker *health.Checker
+}
+
+// Liveness - GET /healthz, процесс жив и обслуживает запросы. Зависимости не
+// проверяются, чтобы недоступная БД не приводила к перезапуску
+func (hh *HealthHandlers) Liveness(w http.ResponseWriter, r *http.Request) {
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, &dto.ResponseHealthDTO{Status: health.StatusOK})
+}
+
+// Readiness - GET /readyz, сервис готов принимать трафик
+func (hh *HealthHandlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	responseDTO := hh.Checker.Run(r.Context())
+
+	status := http.StatusOK
+	if responseDTO.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	helpers.WriteSuccessfulResponse(w, status, responseDTO)
+}
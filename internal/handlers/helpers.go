@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"pr-service/internal/helpers"
+)
+
+// decodeBody читает JSON тело запроса в requestDTO. При ошибке пишет ответ и возвращает false
+func decodeBody(w http.ResponseWriter, r *http.Request, requestDTO any) bool {
+	if err := json.NewDecoder(r.Body).Decode(requestDTO); err != nil {
+		if errors.Is(err, io.EOF) {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "EMPTY_BODY", errEmptyBody.Error())
+			return false
+		}
+
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return false
+	}
+
+	return true
+}
+
+// writeServerError отвечает 504, если истек срок обработки запроса, и 500 в остальных случаях
+func writeServerError(w http.ResponseWriter, r *http.Request) {
+	if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		helpers.WriteErrorReponse(w, http.StatusGatewayTimeout, "TIMEOUT", errTimeout.Error())
+		return
+	}
+
+	helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+}
+
+// MethodNotAllowed отвечает 405 для путей, у которых нет обработчика для метода запроса
+func MethodNotAllowed(allowedMethods []string) http.HandlerFunc {
+	allow := strings.Join(allowedMethods, ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
+	}
+}
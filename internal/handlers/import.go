@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"pr-service/internal/helpers"
+	"pr-service/internal/service"
+)
+
+// ограничение размера файла импорта
+const maxImportBodySize = 10 << 20
+
+type IImportHandlers interface {
+	Import(w http.ResponseWriter, r *http.Request)
+}
+
+type ImportHandlers struct {
+	ImportService service.IImportService
+}
+
+func (ih *ImportHandlers) Import(w http.ResponseWriter, r *http.Request) {
+	// формат берется из квери параметра или из Content-Type
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = service.ImportFormatCSV
+		case "application/json":
+			format = service.ImportFormatJSON
+		default:
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "MISSING_PARAM", errMissingParam.Error())
+			return
+		}
+	}
+
+	// по умолчанию импорт по принципу "все или ничего"
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = service.ImportModeAtomic
+	}
+
+	responseDTO, err := ih.ImportService.Import(r.Context(), format, mode, http.MaxBytesReader(w, r.Body, maxImportBodySize))
+	if err != nil {
+		// если файл, формат или режим некорректны
+		if errors.Is(err, service.ErrWrongImportFile) {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongImportFile.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	// отчет возвращается и при откате, признак фиксации - поле committed
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
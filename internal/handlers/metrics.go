@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"pr-service/internal/metrics"
+)
+
+type IMetricsHandlers interface {
+	GetMetrics(w http.ResponseWriter, r *http.Request)
+}
+
+type MetricsHandlers struct {
+	Registry *metrics.Registry
+}
+
+// GetMetrics - GET /metrics
+func (mh *MetricsHandlers) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	// метрики собираются до ответа, чтобы при ошибке вернуть ее в обычном формате
+	var body bytes.Buffer
+	if err := mh.Registry.Write(r.Context(), &body); err != nil {
+		writeServerError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
@@ -16,6 +16,8 @@ type IPullRequestsHandlers interface {
 	AddPullRequest(w http.ResponseWriter, r *http.Request)
 	MergePullRequest(w http.ResponseWriter, r *http.Request)
 	ReassignReviewer(w http.ResponseWriter, r *http.Request)
+	MergePullRequestById(w http.ResponseWriter, r *http.Request)
+	ReassignReviewerById(w http.ResponseWriter, r *http.Request)
 }
 
 type PullRequestsHandlers struct {
@@ -23,13 +25,6 @@ type PullRequestsHandlers struct {
 }
 
 func (ph *PullRequestsHandlers) AddPullRequest(w http.ResponseWriter, r *http.Request) {
-	// проверяем POST метод
-	if r.Method != http.MethodPost {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
-
-		return
-	}
-
 	// чиатет тело запроса
 	var requestDTO dto.RequestPullrequestDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
@@ -48,15 +43,24 @@ func (ph *PullRequestsHandlers) AddPullRequest(w http.ResponseWriter, r *http.Re
 	validator.ValidatePullRequestId(requestDTO.PullRequestId)
 	validator.ValidatePullRequestName(requestDTO.PullRequestName)
 	validator.ValidateUserId(requestDTO.AuthorID)
+	if requestDTO.TeamName != "" {
+		validator.ValidateTeamName(requestDTO.TeamName)
+	}
 	if !validator.GetIsValid() {
 		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
 		return
 	}
 
 	// сервисная логика добавления pr
-	responseDTO, err := ph.PullRequestService.AddPullRequest(&requestDTO)
+	responseDTO, err := ph.PullRequestService.AddPullRequest(r.Context(), &requestDTO)
 	if err != nil {
-		// если автор не найден
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если автор или команда не найдены
 		if errors.Is(err, service.ErrNoResourse) {
 			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
 			return
@@ -69,7 +73,7 @@ func (ph *PullRequestsHandlers) AddPullRequest(w http.ResponseWriter, r *http.Re
 		}
 
 		// если произошла ошибка в процессе сервисной логики
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -78,12 +82,6 @@ func (ph *PullRequestsHandlers) AddPullRequest(w http.ResponseWriter, r *http.Re
 }
 
 func (ph *PullRequestsHandlers) MergePullRequest(w http.ResponseWriter, r *http.Request) {
-	// проверяем POST метод
-	if r.Method != http.MethodPost {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
-		return
-	}
-
 	// чиатет тело запроса
 	var requestDTO dto.PullRequestIdDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
@@ -96,17 +94,26 @@ func (ph *PullRequestsHandlers) MergePullRequest(w http.ResponseWriter, r *http.
 		return
 	}
 
+	ph.mergePullRequest(w, r, requestDTO.PullRequestId)
+}
+
+// MergePullRequestById - POST /api/v1/pull-requests/{id}/merge, тело не нужно
+func (ph *PullRequestsHandlers) MergePullRequestById(w http.ResponseWriter, r *http.Request) {
+	ph.mergePullRequest(w, r, r.PathValue("id"))
+}
+
+func (ph *PullRequestsHandlers) mergePullRequest(w http.ResponseWriter, r *http.Request, pullRequestId string) {
 	validator := validators.NewValidator()
 
 	// валидация
-	validator.ValidatePullRequestId(requestDTO.PullRequestId)
+	validator.ValidatePullRequestId(pullRequestId)
 	if !validator.GetIsValid() {
 		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
 		return
 	}
 
 	// сервисная логика изменения статуса pr
-	responseDTO, err := ph.PullRequestService.MergePullRequest(requestDTO.PullRequestId)
+	responseDTO, err := ph.PullRequestService.MergePullRequest(r.Context(), pullRequestId)
 	if err != nil {
 		// если pr не найден
 		if errors.Is(err, service.ErrNoResourse) {
@@ -121,7 +128,7 @@ func (ph *PullRequestsHandlers) MergePullRequest(w http.ResponseWriter, r *http.
 		}
 
 		// если произошла ошибка в процессе сервисной логики
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -130,12 +137,6 @@ func (ph *PullRequestsHandlers) MergePullRequest(w http.ResponseWriter, r *http.
 }
 
 func (ph *PullRequestsHandlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
-	// проверяем POST метод
-	if r.Method != http.MethodPost {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
-		return
-	}
-
 	// валидируем тело запроса
 	var requestDTO dto.RequestReassignDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
@@ -148,6 +149,23 @@ func (ph *PullRequestsHandlers) ReassignReviewer(w http.ResponseWriter, r *http.
 		return
 	}
 
+	ph.reassignReviewer(w, r, &requestDTO)
+}
+
+// ReassignReviewerById - POST /api/v1/pull-requests/{id}/reassign
+func (ph *PullRequestsHandlers) ReassignReviewerById(w http.ResponseWriter, r *http.Request) {
+	var requestDTO dto.RequestReassignDTO
+	if !decodeBody(w, r, &requestDTO) {
+		return
+	}
+
+	// pr всегда берется из пути
+	requestDTO.PullRequestId = r.PathValue("id")
+
+	ph.reassignReviewer(w, r, &requestDTO)
+}
+
+func (ph *PullRequestsHandlers) reassignReviewer(w http.ResponseWriter, r *http.Request, requestDTO *dto.RequestReassignDTO) {
 	validator := validators.NewValidator()
 
 	// валидация
@@ -159,8 +177,14 @@ func (ph *PullRequestsHandlers) ReassignReviewer(w http.ResponseWriter, r *http.
 	}
 
 	// сервисная логика переназначения ревьювера
-	responseDTO, err := ph.PullRequestService.ReassignReviewer(&requestDTO)
+	responseDTO, err := ph.PullRequestService.ReassignReviewer(r.Context(), requestDTO)
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		// если pr или юзер не найден
 		if errors.Is(err, service.ErrNoResourse) {
 			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
@@ -186,7 +210,7 @@ func (ph *PullRequestsHandlers) ReassignReviewer(w http.ResponseWriter, r *http.
 		}
 
 		// если произошла ошибка в процессе сервисной логики
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"io"
 	"net/http"
 
@@ -17,13 +18,14 @@ type StatsHandlers struct {
 }
 
 func (sh *StatsHandlers) GetStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongDataInput.Error())
-		return
-	}
-
-	responseDTO, err := sh.StatsService.GetStats()
+	responseDTO, err := sh.StatsService.GetStats(r.Context())
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		if err == io.EOF {
 			helpers.WriteErrorReponse(w, http.StatusBadRequest, "EMPTY_BODY", errEmptyBody.Error())
 			return
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/helpers"
+	"pr-service/internal/service"
+	"pr-service/internal/validators"
+)
+
+// GetTeamLeads - GET /api/v1/teams/{name}/leads
+func (th *TeamsHandlers) GetTeamLeads(w http.ResponseWriter, r *http.Request) {
+	teamName := r.PathValue("name")
+
+	validator := validators.NewValidator()
+	validator.ValidateTeamName(teamName)
+	if !validator.GetIsValid() {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := th.TeamService.GetTeamLeads(r.Context(), teamName)
+	writeTeamLeads(w, r, responseDTO, err)
+}
+
+// AddTeamLead - PUT /api/v1/teams/{name}/leads/{user_id}
+func (th *TeamsHandlers) AddTeamLead(w http.ResponseWriter, r *http.Request) {
+	teamName, userId := r.PathValue("name"), r.PathValue("user_id")
+	if !validateTeamLead(w, teamName, userId) {
+		return
+	}
+
+	responseDTO, err := th.TeamService.AddTeamLead(r.Context(), teamName, userId)
+	writeTeamLeads(w, r, responseDTO, err)
+}
+
+// RemoveTeamLead - DELETE /api/v1/teams/{name}/leads/{user_id}
+func (th *TeamsHandlers) RemoveTeamLead(w http.ResponseWriter, r *http.Request) {
+	teamName, userId := r.PathValue("name"), r.PathValue("user_id")
+	if !validateTeamLead(w, teamName, userId) {
+		return
+	}
+
+	responseDTO, err := th.TeamService.RemoveTeamLead(r.Context(), teamName, userId)
+	writeTeamLeads(w, r, responseDTO, err)
+}
+
+func validateTeamLead(w http.ResponseWriter, teamName, userId string) bool {
+	validator := validators.NewValidator()
+
+	// валидация
+	validator.ValidateTeamName(teamName)
+	validator.ValidateUserId(userId)
+	if !validator.GetIsValid() {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return false
+	}
+
+	return true
+}
+
+// writeTeamLeads пишет список лидов команды или ошибку сервисного слоя
+func writeTeamLeads(w http.ResponseWriter, r *http.Request, responseDTO *dto.TeamLeadsDTO, err error) {
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если команда, пользователь или назначение лида не найдены
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
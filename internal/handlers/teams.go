@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
 	"pr-service/internal/dto"
 	"pr-service/internal/helpers"
@@ -15,6 +16,14 @@ import (
 type ITeamsHandlers interface {
 	AddTeam(w http.ResponseWriter, r *http.Request)
 	GetTeam(w http.ResponseWriter, r *http.Request)
+	SetParent(w http.ResponseWriter, r *http.Request)
+	GetTeamTree(w http.ResponseWriter, r *http.Request)
+	GetTeamByName(w http.ResponseWriter, r *http.Request)
+	SetParentByName(w http.ResponseWriter, r *http.Request)
+	GetTeamSubtree(w http.ResponseWriter, r *http.Request)
+	GetTeamLeads(w http.ResponseWriter, r *http.Request)
+	AddTeamLead(w http.ResponseWriter, r *http.Request)
+	RemoveTeamLead(w http.ResponseWriter, r *http.Request)
 }
 
 type TeamsHandlers struct {
@@ -22,12 +31,6 @@ type TeamsHandlers struct {
 }
 
 func (th *TeamsHandlers) AddTeam(w http.ResponseWriter, r *http.Request) {
-	// проверяем POST метод
-	if r.Method != http.MethodPost {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
-		return
-	}
-
 	// чиатет тело запроса
 	var requestDTO dto.TeamDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
@@ -56,9 +59,37 @@ func (th *TeamsHandlers) AddTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// режим синхронизации: команда приводится к переданному составу
+	query := r.URL.Query()
+	switch query.Get("mode") {
+	case "":
+	case "sync":
+		dryRun := false
+		if rawDryRun := query.Get("dry_run"); rawDryRun != "" {
+			var err error
+			dryRun, err = strconv.ParseBool(rawDryRun)
+			if err != nil {
+				helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+				return
+			}
+		}
+
+		th.syncTeam(w, r, &requestDTO, dryRun)
+		return
+	default:
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
 	// испольуем сервисный слой, чтобы добавить команду и ее пользователей
-	responseDTO, err := th.TeamService.AddTeamWithMembers(&requestDTO)
+	responseDTO, err := th.TeamService.AddTeamWithMembers(r.Context(), &requestDTO)
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		// если команда уже существует
 		if errors.Is(err, service.ErrTeamExists) {
 			helpers.WriteErrorReponse(w, http.StatusBadRequest, "TEAM_EXISTS", errTeamExists.Error())
@@ -72,7 +103,7 @@ func (th *TeamsHandlers) AddTeam(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// если ошибка после работы сервисного слоя со стороны сервера
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -80,22 +111,163 @@ func (th *TeamsHandlers) AddTeam(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteSuccessfulResponse(w, http.StatusCreated, responseDTO)
 }
 
-func (th *TeamsHandlers) GetTeam(w http.ResponseWriter, r *http.Request) {
-	// проверяем GET метод
-	if r.Method != http.MethodGet {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
+func (th *TeamsHandlers) syncTeam(w http.ResponseWriter, r *http.Request, requestDTO *dto.TeamDTO, dryRun bool) {
+	responseDTO, err := th.TeamService.SyncTeamWithMembers(r.Context(), requestDTO, dryRun)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если пользователь указан в списке несколько раз
+		if errors.Is(err, service.ErrDuplicatedMember) {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "DUPLICATED_MEMBER", errDuplicatedMember.Error())
+			return
+		}
+
+		// если user_id занят удаленным пользователем
+		if errors.Is(err, service.ErrUserExists) {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "USER_EXISTS", errUserExists.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
 		return
 	}
 
-	// проверяем наличие квери параметра
-	teamName := r.URL.Query().Get("team_name")
+	// сереализируем тело ответа
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (th *TeamsHandlers) GetTeam(w http.ResponseWriter, r *http.Request) {
+	th.getTeam(w, r, r.URL.Query().Get("team_name"))
+}
+
+// GetTeamByName - GET /api/v1/teams/{name}
+func (th *TeamsHandlers) GetTeamByName(w http.ResponseWriter, r *http.Request) {
+	th.getTeam(w, r, r.PathValue("name"))
+}
+
+func (th *TeamsHandlers) getTeam(w http.ResponseWriter, r *http.Request, teamName string) {
+	// проверяем наличие параметра
 	if teamName == "" {
 		helpers.WriteErrorReponse(w, http.StatusBadRequest, "MISSING_PARAM", errMissingParam.Error())
 		return
 	}
 
-	responseDTO, err := th.TeamService.GetTeamWithMembers(teamName)
+	responseDTO, err := th.TeamService.GetTeamWithMembers(r.Context(), teamName)
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если команда не существует
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (th *TeamsHandlers) SetParent(w http.ResponseWriter, r *http.Request) {
+	// чиатет тело запроса
+	var requestDTO dto.RequestSetParentDTO
+	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
+		if err == io.EOF {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "EMPTY_BODY", errEmptyBody.Error())
+			return
+		}
+
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	th.setParent(w, r, &requestDTO)
+}
+
+// SetParentByName - PUT /api/v1/teams/{name}/parent
+func (th *TeamsHandlers) SetParentByName(w http.ResponseWriter, r *http.Request) {
+	var requestDTO dto.RequestSetParentDTO
+	if !decodeBody(w, r, &requestDTO) {
+		return
+	}
+
+	// команда всегда берется из пути
+	requestDTO.TeamName = r.PathValue("name")
+
+	th.setParent(w, r, &requestDTO)
+}
+
+func (th *TeamsHandlers) setParent(w http.ResponseWriter, r *http.Request, requestDTO *dto.RequestSetParentDTO) {
+	validator := validators.NewValidator()
+
+	// валидация, пустой родитель делает команду корневой
+	validator.ValidateTeamName(requestDTO.TeamName)
+	if requestDTO.ParentTeamName != "" {
+		validator.ValidateTeamName(requestDTO.ParentTeamName)
+	}
+	if !validator.GetIsValid() {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := th.TeamService.SetParentTeam(r.Context(), requestDTO)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если команда или родитель не существуют
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если получается цикл в иерархии
+		if errors.Is(err, service.ErrTeamCycle) {
+			helpers.WriteErrorReponse(w, http.StatusConflict, "TEAM_CYCLE", errTeamCycle.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (th *TeamsHandlers) GetTeamTree(w http.ResponseWriter, r *http.Request) {
+	// без квери параметра возвращается все дерево
+	th.getTeamTree(w, r, r.URL.Query().Get("team_name"))
+}
+
+// GetTeamSubtree - GET /api/v1/teams/{name}/subtree
+func (th *TeamsHandlers) GetTeamSubtree(w http.ResponseWriter, r *http.Request) {
+	th.getTeamTree(w, r, r.PathValue("name"))
+}
+
+func (th *TeamsHandlers) getTeamTree(w http.ResponseWriter, r *http.Request, teamName string) {
+	responseDTO, err := th.TeamService.GetTeamTree(r.Context(), teamName)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		// если команда не существует
 		if errors.Is(err, service.ErrNoResourse) {
 			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
@@ -103,7 +275,7 @@ func (th *TeamsHandlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// если ошибка после работы сервисного слоя со стороны сервера
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
 	"pr-service/internal/dto"
 	"pr-service/internal/helpers"
@@ -15,6 +16,14 @@ import (
 type IUsersHandlers interface {
 	SetIsActive(w http.ResponseWriter, r *http.Request)
 	GetReview(w http.ResponseWriter, r *http.Request)
+	GetUser(w http.ResponseWriter, r *http.Request)
+	UpdateUser(w http.ResponseWriter, r *http.Request)
+	DeleteUser(w http.ResponseWriter, r *http.Request)
+	SetIsActiveByUserId(w http.ResponseWriter, r *http.Request)
+	GetReviewByUserId(w http.ResponseWriter, r *http.Request)
+	GetUserById(w http.ResponseWriter, r *http.Request)
+	UpdateUserById(w http.ResponseWriter, r *http.Request)
+	DeleteUserById(w http.ResponseWriter, r *http.Request)
 }
 
 type UsersHandlers struct {
@@ -22,12 +31,6 @@ type UsersHandlers struct {
 }
 
 func (uh *UsersHandlers) SetIsActive(w http.ResponseWriter, r *http.Request) {
-	// проверяем POST метод
-	if r.Method != http.MethodPost {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
-		return
-	}
-
 	// читаем тело запроса
 	var requestDTO dto.IsActiveUserDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
@@ -40,6 +43,23 @@ func (uh *UsersHandlers) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	uh.setIsActive(w, r, &requestDTO)
+}
+
+// SetIsActiveByUserId - PUT /api/v1/users/{id}/active
+func (uh *UsersHandlers) SetIsActiveByUserId(w http.ResponseWriter, r *http.Request) {
+	var requestDTO dto.IsActiveUserDTO
+	if !decodeBody(w, r, &requestDTO) {
+		return
+	}
+
+	// пользователь всегда берется из пути
+	requestDTO.Id = r.PathValue("id")
+
+	uh.setIsActive(w, r, &requestDTO)
+}
+
+func (uh *UsersHandlers) setIsActive(w http.ResponseWriter, r *http.Request, requestDTO *dto.IsActiveUserDTO) {
 	validator := validators.NewValidator()
 
 	// валидация
@@ -49,8 +69,14 @@ func (uh *UsersHandlers) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO, err := uh.UserService.SetIsActiveById(&requestDTO)
+	responseDTO, err := uh.UserService.SetIsActiveById(r.Context(), requestDTO)
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		// если пользователя не существует
 		if errors.Is(err, service.ErrNoResourse) {
 			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
@@ -58,7 +84,7 @@ func (uh *UsersHandlers) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// если ошибка после работы сервисного слоя со стороны сервера
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
 		return
 	}
 
@@ -67,22 +93,69 @@ func (uh *UsersHandlers) SetIsActive(w http.ResponseWriter, r *http.Request) {
 }
 
 func (uh *UsersHandlers) GetReview(w http.ResponseWriter, r *http.Request) {
-	// проверяем GET метод
-	if r.Method != http.MethodGet {
-		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", errWrongMethod.Error())
+	uh.getReview(w, r, r.URL.Query().Get("user_id"))
+}
+
+// GetReviewByUserId - GET /api/v1/users/{id}/reviews
+func (uh *UsersHandlers) GetReviewByUserId(w http.ResponseWriter, r *http.Request) {
+	uh.getReview(w, r, r.PathValue("id"))
+}
+
+func (uh *UsersHandlers) getReview(w http.ResponseWriter, r *http.Request, userId string) {
+	// проверяем наличие параметра
+	if userId == "" {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "MISSING_PARAM", errMissingParam.Error())
+		return
+	}
+
+	// сервисная логика
+	responseDTO, err := uh.UserService.GetPullRequestsByUserId(r.Context(), userId)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если пользователя не существует
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
 		return
 	}
 
-	// проверяем наличие квери параметра
-	userId := r.URL.Query().Get("user_id")
+	// сереализируем тело ответа
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (uh *UsersHandlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	uh.getUser(w, r, r.URL.Query().Get("user_id"))
+}
+
+// GetUserById - GET /api/v1/users/{id}
+func (uh *UsersHandlers) GetUserById(w http.ResponseWriter, r *http.Request) {
+	uh.getUser(w, r, r.PathValue("id"))
+}
+
+func (uh *UsersHandlers) getUser(w http.ResponseWriter, r *http.Request, userId string) {
+	// проверяем наличие параметра
 	if userId == "" {
 		helpers.WriteErrorReponse(w, http.StatusBadRequest, "MISSING_PARAM", errMissingParam.Error())
 		return
 	}
 
-	// сервисная логика
-	responseDTO, err := uh.UserService.GetPullRequestsByUserId(userId)
+	responseDTO, err := uh.UserService.GetUser(r.Context(), userId)
 	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
 		// если пользователя не существует
 		if errors.Is(err, service.ErrNoResourse) {
 			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
@@ -90,7 +163,159 @@ func (uh *UsersHandlers) GetReview(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// если ошибка после работы сервисного слоя со стороны сервера
-		helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", errInternalServer.Error())
+		writeServerError(w, r)
+		return
+	}
+
+	// сереализируем тело ответа
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (uh *UsersHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	// читаем тело запроса
+	var requestDTO dto.RequestUpdateUserDTO
+	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
+		if err == io.EOF {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "EMPTY_BODY", errEmptyBody.Error())
+			return
+		}
+
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	uh.updateUser(w, r, &requestDTO)
+}
+
+// UpdateUserById - PATCH /api/v1/users/{id}
+func (uh *UsersHandlers) UpdateUserById(w http.ResponseWriter, r *http.Request) {
+	var requestDTO dto.RequestUpdateUserDTO
+	if !decodeBody(w, r, &requestDTO) {
+		return
+	}
+
+	// пользователь всегда берется из пути
+	requestDTO.UserId = r.PathValue("id")
+
+	uh.updateUser(w, r, &requestDTO)
+}
+
+func (uh *UsersHandlers) updateUser(w http.ResponseWriter, r *http.Request, requestDTO *dto.RequestUpdateUserDTO) {
+	validator := validators.NewValidator()
+
+	// валидация, проверяем только переданные поля
+	validator.ValidateUserId(requestDTO.UserId)
+	if requestDTO.Username != nil {
+		validator.ValidateUsername(*requestDTO.Username)
+	}
+	if requestDTO.TeamName != nil && *requestDTO.TeamName != "" {
+		validator.ValidateTeamName(*requestDTO.TeamName)
+	}
+	if requestDTO.Email != nil {
+		validator.ValidateEmail(*requestDTO.Email)
+	}
+	validator.ValidateHandles(requestDTO.Handles)
+	if !validator.GetIsValid() {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := uh.UserService.UpdateUser(r.Context(), requestDTO)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если пользователя или команды не существует
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
+		return
+	}
+
+	// сереализируем тело ответа
+	helpers.WriteSuccessfulResponse(w, http.StatusOK, responseDTO)
+}
+
+func (uh *UsersHandlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	// читаем тело запроса
+	var requestDTO dto.RequestDeleteUserDTO
+	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
+		if err == io.EOF {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "EMPTY_BODY", errEmptyBody.Error())
+			return
+		}
+
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	uh.deleteUser(w, r, &requestDTO)
+}
+
+// DeleteUserById - DELETE /api/v1/users/{id}?reassign_reviews=true
+func (uh *UsersHandlers) DeleteUserById(w http.ResponseWriter, r *http.Request) {
+	requestDTO := &dto.RequestDeleteUserDTO{
+		UserId: r.PathValue("id"),
+	}
+
+	// без квери параметра открытые ревью не переназначаются
+	if rawReassign := r.URL.Query().Get("reassign_reviews"); rawReassign != "" {
+		reassign, err := strconv.ParseBool(rawReassign)
+		if err != nil {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+			return
+		}
+		requestDTO.ReassignReviews = reassign
+	}
+
+	uh.deleteUser(w, r, requestDTO)
+}
+
+func (uh *UsersHandlers) deleteUser(w http.ResponseWriter, r *http.Request, requestDTO *dto.RequestDeleteUserDTO) {
+	validator := validators.NewValidator()
+
+	// валидация
+	validator.ValidateUserId(requestDTO.UserId)
+	if !validator.GetIsValid() {
+		helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", errWrongDataInput.Error())
+		return
+	}
+
+	responseDTO, err := uh.UserService.DeleteUser(r.Context(), requestDTO)
+	if err != nil {
+		// если не хватает прав
+		if errors.Is(err, service.ErrForbidden) {
+			helpers.WriteErrorReponse(w, http.StatusForbidden, "FORBIDDEN", errForbidden.Error())
+			return
+		}
+
+		// если пользователя не существует
+		if errors.Is(err, service.ErrNoResourse) {
+			helpers.WriteErrorReponse(w, http.StatusNotFound, "NOT_FOUND", errNotFound.Error())
+			return
+		}
+
+		// если у пользователя есть открытые ревью и переназначение не запрошено
+		if errors.Is(err, service.ErrHasOpenReviews) {
+			helpers.WriteErrorReponse(w, http.StatusConflict, "HAS_OPEN_REVIEWS", errHasOpenReviews.Error())
+			return
+		}
+
+		// если для одного из ревью не нашлось замены
+		if errors.Is(err, service.ErrNoReviewrsToAssign) {
+			helpers.WriteErrorReponse(w, http.StatusConflict, "NO_CANDIDATE", errNoCandidate.Error())
+			return
+		}
+
+		// если ошибка после работы сервисного слоя со стороны сервера
+		writeServerError(w, r)
 		return
 	}
 
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"pr-service/internal/dto"
+)
+
+// DefaultCheckTimeout - сколько ждать одну проверку готовности
+const DefaultCheckTimeout = 2 * time.Second
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check - проверка готовности, например доступность БД
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Checker выполняет проверки готовности параллельно, каждую со своим сроком
+type Checker struct {
+	Checks  []Check
+	Timeout time.Duration
+}
+
+// Run возвращает результат всех проверок. Сервис готов, только если прошли все
+func (c *Checker) Run(ctx context.Context) *dto.ResponseHealthDTO {
+	if c == nil {
+		return &dto.ResponseHealthDTO{Status: StatusOK}
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	responseDTO := &dto.ResponseHealthDTO{
+		Status: StatusOK,
+		Checks: make(map[string]*dto.HealthCheckDTO, len(c.Checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			result := &dto.HealthCheckDTO{
+				Status:     StatusOK,
+				DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			responseDTO.Checks[check.Name] = result
+			if err != nil {
+				responseDTO.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return responseDTO
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var ErrWorkerStopped = errors.New("worker is stopped")
+
+// Heartbeat - отметки фоновой горутины для проверки готовности. Горутина отмечается на
+// каждом шаге своего цикла и вызывает Stop при выходе. Проверка не проходит, если горутина
+// завершилась или не отмечалась дольше Timeout, например зависла на внешнем вызове.
+// Методы nil Heartbeat ничего не делают, чтобы горутины можно было запускать без проверки
+type Heartbeat struct {
+	Name    string
+	Timeout time.Duration
+
+	// время последней отметки в UnixNano
+	last    atomic.Int64
+	stopped atomic.Bool
+}
+
+// NewHeartbeat создает отметку с текущим временем, чтобы проверка проходила до первого шага горутины
+func NewHeartbeat(name string, timeout time.Duration) *Heartbeat {
+	h := &Heartbeat{Name: name, Timeout: timeout}
+	h.Beat()
+	return h
+}
+
+// Beat отмечает, что горутина работает
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Stop отмечает, что горутина завершилась
+func (h *Heartbeat) Stop() {
+	if h == nil {
+		return
+	}
+	h.stopped.Store(true)
+}
+
+// Alive - проверка готовности по отметкам
+func (h *Heartbeat) Alive(ctx context.Context) error {
+	if h.stopped.Load() {
+		return ErrWorkerStopped
+	}
+
+	if since := time.Since(time.Unix(0, h.last.Load())); since > h.Timeout {
+		return fmt.Errorf("no heartbeat for %s", since.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// Check возвращает проверку готовности с именем горутины
+func (h *Heartbeat) Check() Check {
+	return Check{Name: h.Name, Check: h.Alive}
+}
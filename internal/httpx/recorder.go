@@ -0,0 +1,52 @@
+package httpx
+
+import "net/http"
+
+// Recorder запоминает код ответа и размер тела для middleware, которые пишут
+// лог, метрики и спаны уже после обработчика
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// NewRecorder оборачивает w. Пока обработчик не вызвал WriteHeader, код ответа 200.
+// Если w уже *Recorder внешнего middleware, возвращается он же: лог, метрики и спан
+// читают один recorder, а не цепочку оберток вокруг каждого ответа
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	if recorder, ok := w.(*Recorder); ok {
+		return recorder
+	}
+	return &Recorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status - первый записанный код ответа
+func (rr *Recorder) Status() int {
+	return rr.status
+}
+
+// Bytes - сколько байт тела записано
+func (rr *Recorder) Bytes() int {
+	return rr.bytes
+}
+
+func (rr *Recorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *Recorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Unwrap нужен http.ResponseController
+func (rr *Recorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"pr-service/internal/httpx"
+)
+
+// RequestIDHeader - заголовок, в котором клиент или прокси передает идентификатор запроса
+const RequestIDHeader = "X-Request-ID"
+
+// чужие идентификаторы длиннее этого не принимаются, чтобы не раздувать логи
+const maxRequestIDLength = 128
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger кладет в контекст логгер запроса
+func WithLogger(ctx context.Context, lgr *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, lgr)
+}
+
+// FromContext возвращает логгер запроса, а вне HTTP запроса (prctl, тесты) - fallback
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if lgr, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return lgr
+	}
+	return fallback
+}
+
+// RequestID возвращает идентификатор текущего запроса или пустую строку
+func RequestID(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIDKey{}).(string)
+	return requestId
+}
+
+// Middleware берет X-Request-ID из запроса или создает новый, возвращает его в ответе,
+// кладет в контекст логгер с request_id и пишет по одной строке лога на запрос
+func Middleware(lgr *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestId := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestId) {
+			requestId = rand.Text()
+		}
+		w.Header().Set(RequestIDHeader, requestId)
+
+		requestLgr := lgr.With(slog.String("request_id", requestId))
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestId)
+		r = r.WithContext(WithLogger(ctx, requestLgr))
+
+		recorder := httpx.NewRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		// шаблон маршрута заполняет ServeMux, для неизвестных путей он пустой
+		requestLgr.With(
+			slog.String("method", r.Method),
+			slog.String("route", r.Pattern),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", recorder.Bytes()),
+		).Info("request handled")
+	})
+}
+
+// isValidRequestID пропускает только непустые идентификаторы из видимых ASCII символов
+func isValidRequestID(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < '!' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RegisterDBStats публикует статистику пула подключений database/sql
+func RegisterDBStats(reg *Registry, db *sql.DB) {
+	stat := func(value func(sql.DBStats) float64) func(ctx context.Context) ([]Sample, error) {
+		return func(ctx context.Context) ([]Sample, error) {
+			return []Sample{{Value: value(db.Stats())}}, nil
+		}
+	}
+
+	reg.NewGaugeFunc("pr_service_db_max_open_connections", "Maximum number of open connections to the database.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	reg.NewGaugeFunc("pr_service_db_open_connections", "Established connections, both in use and idle.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	reg.NewGaugeFunc("pr_service_db_in_use_connections", "Connections currently in use.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	reg.NewGaugeFunc("pr_service_db_idle_connections", "Idle connections.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	reg.NewCounterFunc("pr_service_db_wait_count_total", "Connections waited for.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	reg.NewCounterFunc("pr_service_db_wait_duration_seconds_total", "Time blocked waiting for a new connection.", nil,
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	reg.NewCounterFunc("pr_service_db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	reg.NewCounterFunc("pr_service_db_max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+	reg.NewCounterFunc("pr_service_db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", nil,
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
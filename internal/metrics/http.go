@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"pr-service/internal/httpx"
+)
+
+// маршрут запросов, которые не совпали ни с одним шаблоном ServeMux. Путь в метку
+// не попадает, иначе сканер адресов создаст по ряду на каждый путь
+const unmatchedRoute = "unmatched"
+
+// Middleware считает запросы и время ответа по маршруту (шаблону ServeMux) и коду ответа
+func Middleware(reg *Registry, next http.Handler) http.Handler {
+	if reg == nil {
+		return next
+	}
+
+	requests := reg.NewCounter("pr_service_http_requests_total", "HTTP requests by route and status.", "route", "status")
+	duration := reg.NewHistogram("pr_service_http_request_duration_seconds", "HTTP request latency by route and status.", DefaultBuckets, "route", "status")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		recorder := httpx.NewRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		// шаблон заполняет ServeMux на том же запросе
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(recorder.Status())
+
+		requests.Inc(route, status)
+		duration.Observe(time.Since(start).Seconds(), route, status)
+	})
+}
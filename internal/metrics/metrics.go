@@ -0,0 +1,322 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"pr-service/internal/logging"
+)
+
+// ContentType - текстовый формат Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets - границы гистограммы времени ответа в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Registry хранит метрики и отдает их в текстовом формате Prometheus.
+// Методы нулевого Registry создают нулевые метрики, которые ничего не считают,
+// поэтому сервисы работают и без метрик (prctl, тесты)
+type Registry struct {
+	Lgr *slog.Logger
+
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+// family - одна метрика со всеми сочетаниями меток
+type family interface {
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+func NewRegistry(lgr *slog.Logger) *Registry {
+	return &Registry{Lgr: lgr, names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// повторная регистрация - ошибка в коде, а не во входных данных
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// Write пишет все метрики в порядке регистрации. Метрика, которую не удалось
+// собрать (например, БД недоступна), пропускается, остальные отдаются
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		if err := f.write(ctx, bw); err != nil {
+			logging.FromContext(ctx, r.Lgr).With(
+				slog.String("error", err.Error()),
+			).Error("failed to collect metric")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Counter - счетчик с метками
+type Counter struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	if r == nil {
+		return nil
+	}
+
+	c := &Counter{name: name, help: help, labelNames: labelNames, values: map[string]*counterValue{}}
+	// счетчик без меток виден с нулем сразу, чтобы rate() работал с первого сбора
+	if len(labelNames) == 0 {
+		c.values[""] = &counterValue{}
+	}
+	r.register(name, c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if c == nil {
+		return
+	}
+	checkLabels(c.name, c.labelNames, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := labelsKey(labelValues)
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: slices.Clone(labelValues)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+func (c *Counter) write(ctx context.Context, w *bufio.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		writeSample(w, c.name, c.labelNames, value.labelValues, "", "", value.value)
+	}
+	return nil
+}
+
+// Histogram - гистограмма с метками
+type Histogram struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	// counts[i] - число наблюдений не больше buckets[i], без накопления
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if r == nil {
+		return nil
+	}
+
+	h := &Histogram{name: name, help: help, labelNames: labelNames, buckets: buckets, values: map[string]*histogramValue{}}
+	r.register(name, h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	checkLabels(h.name, h.labelNames, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := labelsKey(labelValues)
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{labelValues: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += value
+}
+
+func (h *Histogram) write(ctx context.Context, w *bufio.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hv.counts[i]
+			writeSample(w, h.name+"_bucket", h.labelNames, hv.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labelNames, hv.labelValues, "le", "+Inf", float64(hv.count))
+		writeSample(w, h.name+"_sum", h.labelNames, hv.labelValues, "", "", hv.sum)
+		writeSample(w, h.name+"_count", h.labelNames, hv.labelValues, "", "", float64(hv.count))
+	}
+	return nil
+}
+
+// Sample - значение метрики, которое собирается в момент запроса /metrics
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// funcFamily - метрика, значения которой читаются из источника при каждом сборе
+type funcFamily struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	collect    func(ctx context.Context) ([]Sample, error)
+}
+
+// NewGaugeFunc регистрирует текущее значение, например число открытых pr
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, collect func(ctx context.Context) ([]Sample, error)) {
+	r.newFunc(name, help, "gauge", labelNames, collect)
+}
+
+// NewCounterFunc регистрирует счетчик, который ведет сам источник, например database/sql
+func (r *Registry) NewCounterFunc(name, help string, labelNames []string, collect func(ctx context.Context) ([]Sample, error)) {
+	r.newFunc(name, help, "counter", labelNames, collect)
+}
+
+func (r *Registry) newFunc(name, help, kind string, labelNames []string, collect func(ctx context.Context) ([]Sample, error)) {
+	if r == nil {
+		return
+	}
+	r.register(name, &funcFamily{name: name, help: help, kind: kind, labelNames: labelNames, collect: collect})
+}
+
+func (f *funcFamily) write(ctx context.Context, w *bufio.Writer) error {
+	samples, err := f.collect(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.name, err)
+	}
+
+	writeHeader(w, f.name, f.help, f.kind)
+	for _, sample := range samples {
+		checkLabels(f.name, f.labelNames, sample.LabelValues)
+		writeSample(w, f.name, f.labelNames, sample.LabelValues, "", "", sample.Value)
+	}
+	return nil
+}
+
+func checkLabels(name string, labelNames, labelValues []string) {
+	if len(labelNames) != len(labelValues) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labelNames), len(labelValues)))
+	}
+}
+
+// labelsKey склеивает значения меток через байт, которого нет в UTF-8
+func labelsKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample пишет строку метрики, extraName и extraValue - дополнительная метка le гистограммы
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, labelName, escaper.Replace(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+// Reviews - счетчики назначения ревьюверов. Нулевой указатель ничего не считает
+type Reviews struct {
+	assignments   *Counter
+	reassignments *Counter
+	merges        *Counter
+	noCandidate   *Counter
+}
+
+func NewReviews(reg *Registry) *Reviews {
+	if reg == nil {
+		return nil
+	}
+
+	return &Reviews{
+		assignments:   reg.NewCounter("pr_service_reviewer_assignments_total", "Reviewers assigned to pull requests, including first assignment on reassign."),
+		reassignments: reg.NewCounter("pr_service_reviewer_reassignments_total", "Reviewers replaced on pull requests."),
+		merges:        reg.NewCounter("pr_service_pull_request_merges_total", "Pull requests merged."),
+		noCandidate:   reg.NewCounter("pr_service_no_candidate_total", "Reassignments that failed with NO_CANDIDATE."),
+	}
+}
+
+func (rv *Reviews) Assigned(count int) {
+	if rv == nil {
+		return
+	}
+	rv.assignments.Add(float64(count))
+}
+
+func (rv *Reviews) Reassigned() {
+	if rv == nil {
+		return
+	}
+	rv.reassignments.Inc()
+}
+
+func (rv *Reviews) Merged() {
+	if rv == nil {
+		return
+	}
+	rv.merges.Inc()
+}
+
+func (rv *Reviews) NoCandidate() {
+	if rv == nil {
+		return
+	}
+	rv.noCandidate.Inc()
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type APIKeyModel struct {
+	KeyId      int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
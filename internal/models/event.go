@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type PullRequestEventModel struct {
+	EventId       int
+	PullRequestId string
+	EventType     string
+	UserId        string
+	OldUserId     string
+	CreatedAt     time.Time
+}
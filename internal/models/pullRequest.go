@@ -7,6 +7,7 @@ type PullRequestModel struct {
 	PullRequestName string
 	AuthorID        string
 	Status          string
+	TeamName        string
 	CreatedAt       time.Time
 	MergedAt        *time.Time
 }
@@ -0,0 +1,6 @@
+package models
+
+type TeamModel struct {
+	TeamName       string
+	ParentTeamName string
+}
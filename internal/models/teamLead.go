@@ -0,0 +1,6 @@
+package models
+
+type TeamLeadModel struct {
+	TeamName string
+	UserId   string
+}
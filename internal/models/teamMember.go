@@ -0,0 +1,7 @@
+package models
+
+type TeamMemberModel struct {
+	TeamName  string
+	UserId    string
+	IsPrimary bool
+}
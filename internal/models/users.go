@@ -1,8 +1,14 @@
 package models
 
+import "time"
+
 type UserModel struct {
-	Id       string
-	Username string
-	TeamName string
-	IsActive bool
+	Id        string
+	Username  string
+	TeamName  string
+	Teams     []string
+	IsActive  bool
+	Email     string
+	Handles   map[string]string
+	DeletedAt *time.Time
 }
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"pr-service/internal/helpers"
+)
+
+// спецификация собирается в бинарник, чтобы /openapi.json всегда совпадал с версией сервиса
+//
+//go:embed openapi.json
+var specJSON []byte
+
+type document struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *Schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+var (
+	parseOnce sync.Once
+	parsed    *document
+	parseErr  error
+)
+
+// Spec возвращает исходный JSON спецификации
+func Spec() []byte {
+	return specJSON
+}
+
+func loadDocument() (*document, error) {
+	parseOnce.Do(func() {
+		parsed = &document{}
+		parseErr = json.Unmarshal(specJSON, parsed)
+	})
+	return parsed, parseErr
+}
+
+// ServeSpec отдает спецификацию по GET /openapi.json
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		helpers.WriteErrorReponse(w, http.StatusMethodNotAllowed, "WRONG_METHOD", "this method isn't acceptable")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(specJSON)
+}
+
+// requestSchema возвращает JSON-схему тела запроса для метода и шаблона пути из спецификации
+func requestSchema(method, path string) (*Schema, error) {
+	doc, err := loadDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := doc.Paths[path][strings.ToLower(method)]
+	if !ok || op.RequestBody == nil {
+		return nil, nil
+	}
+
+	content, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil, nil
+	}
+
+	return content.Schema, nil
+}
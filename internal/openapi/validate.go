@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pr-service/internal/helpers"
+)
+
+// ограничение размера тела, которое валидатор читает в память. Обычные запросы
+// намного меньше, а импорт и восстановление снимка проверяют данные сами
+const maxValidatedBodySize = 1 << 20
+
+// Schema - подмножество JSON Schema из OpenAPI 3.0, которого достаточно для схем сервиса
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MaxItems             *int               `json:"maxItems"`
+	Minimum              *float64           `json:"minimum"`
+	Nullable             bool               `json:"nullable"`
+}
+
+// additional - значение additionalProperties: false запрещает лишние поля, схема описывает их значения
+type additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+// ValidateRoute проверяет JSON-тела запросов к маршруту method path (шаблон пути, как в
+// спецификации) по схеме из спецификации. Запрос с телом, не подходящим под схему,
+// получает 400 WRONG_DATA_INPUT с перечнем нарушений. Ставится после аутентификации,
+// чтобы тела анонимных запросов не читались в память. Маршрут без схемы тела не меняется
+func ValidateRoute(method, path string, next http.HandlerFunc) http.HandlerFunc {
+	schema, err := requestSchema(method, path)
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			helpers.WriteErrorReponse(w, http.StatusInternalServerError, "SERVER_ERROR", "server error")
+		}
+	}
+	if schema == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// тела в других форматах (например, CSV для /import) не проверяются
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Body == nil || (mediaType != "" && mediaType != "application/json") {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValidatedBodySize))
+		if err != nil {
+			helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", "wrong format of input data")
+			return
+		}
+
+		// пустое тело обрабатывает сам хендлер, чтобы сохранить код EMPTY_BODY
+		if len(bytes.TrimSpace(body)) != 0 {
+			var value any
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			if err := decoder.Decode(&value); err != nil {
+				helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", "wrong format of input data")
+				return
+			}
+
+			if problems := Validate(schema, value); len(problems) != 0 {
+				helpers.WriteErrorReponse(w, http.StatusBadRequest, "WRONG_DATA_INPUT", strings.Join(problems, "; "))
+				return
+			}
+		}
+
+		// хендлер читает тело заново
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
+
+// Validate проверяет значение, разобранное encoding/json с UseNumber, и возвращает все нарушения
+func Validate(schema *Schema, value any) []string {
+	v := &validator{regexps: map[string]*regexp.Regexp{}}
+	v.validate(schema, value, "body")
+	return v.problems
+}
+
+type validator struct {
+	problems []string
+	regexps  map[string]*regexp.Regexp
+}
+
+func (v *validator) addProblem(path, format string, args ...any) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validate(schema *Schema, value any, path string) {
+	schema, err := resolve(schema)
+	if err != nil {
+		v.addProblem(path, "%s", err.Error())
+		return
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			v.addProblem(path, "must not be null")
+		}
+		return
+	}
+
+	if len(schema.Enum) != 0 && !slices.ContainsFunc(schema.Enum, func(option any) bool { return fmt.Sprint(option) == fmt.Sprint(value) }) {
+		v.addProblem(path, "must be one of %v", schema.Enum)
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			v.addProblem(path, "must be an object")
+			return
+		}
+		v.validateObject(schema, object, path)
+
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			v.addProblem(path, "must be an array")
+			return
+		}
+		if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			v.addProblem(path, "must have at most %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range array {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.addProblem(path, "must be a string")
+			return
+		}
+		v.validateString(schema, str, path)
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			v.addProblem(path, "must be a %s", schema.Type)
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				v.addProblem(path, "must be an integer")
+				return
+			}
+		}
+		if schema.Minimum != nil {
+			if f, err := number.Float64(); err == nil && f < *schema.Minimum {
+				v.addProblem(path, "must be at least %v", *schema.Minimum)
+			}
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.addProblem(path, "must be a boolean")
+		}
+	}
+}
+
+func (v *validator) validateObject(schema *Schema, object map[string]any, path string) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			v.addProblem(path+"."+name, "is required")
+		}
+	}
+
+	// поля обходятся по алфавиту, чтобы текст ошибки был стабильным
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		if property, ok := schema.Properties[name]; ok {
+			v.validate(property, object[name], path+"."+name)
+			continue
+		}
+
+		if schema.AdditionalProperties == nil {
+			continue
+		}
+		if !schema.AdditionalProperties.Allowed {
+			v.addProblem(path+"."+name, "unknown field")
+			continue
+		}
+		if schema.AdditionalProperties.Schema != nil {
+			v.validate(schema.AdditionalProperties.Schema, object[name], path+"."+name)
+		}
+	}
+}
+
+func (v *validator) validateString(schema *Schema, str, path string) {
+	length := utf8.RuneCountInString(str)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.addProblem(path, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.addProblem(path, "must be at most %d characters", *schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		re, ok := v.regexps[schema.Pattern]
+		if !ok {
+			re = regexp.MustCompile(schema.Pattern)
+			v.regexps[schema.Pattern] = re
+		}
+		if !re.MatchString(str) {
+			v.addProblem(path, "must match %s", schema.Pattern)
+		}
+	}
+
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			v.addProblem(path, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+// resolve заменяет ссылку #/components/schemas/Name на саму схему
+func resolve(schema *Schema) (*Schema, error) {
+	for schema.Ref != "" {
+		doc, err := loadDocument()
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		target, ok := doc.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema %s", schema.Ref)
+		}
+		schema = target
+	}
+
+	return schema, nil
+}
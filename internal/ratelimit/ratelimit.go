@@ -0,0 +1,329 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/health"
+	"pr-service/internal/helpers"
+	"pr-service/internal/httpx"
+)
+
+// как часто удалять корзины клиентов, которые успели наполниться
+const sweepInterval = time.Minute
+
+var ErrTooManyRequests = errors.New("too many requests, retry later")
+
+// Budget - бюджет клиента: Rate запросов в секунду в среднем и не больше Burst подряд
+type Budget struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseBudget разбирает бюджет в формате "rate,burst", например "10,20".
+// Пустая строка отключает ограничение
+func ParseBudget(spec string) (*Budget, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	rawRate, rawBurst, ok := strings.Cut(spec, ",")
+	if !ok {
+		return nil, fmt.Errorf("rate limit %q must look like rate,burst", spec)
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rawRate), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("rate limit %q has invalid rate", spec)
+	}
+
+	burst, err := strconv.Atoi(strings.TrimSpace(rawBurst))
+	if err != nil || burst < 1 {
+		return nil, fmt.Errorf("rate limit %q has invalid burst", spec)
+	}
+
+	return &Budget{Rate: rate, Burst: burst}, nil
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter - корзины токенов по ключу клиента с общим бюджетом
+type Limiter struct {
+	Budget Budget
+	// часы, подменяются в тестах
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewLimiter(budget Budget) *Limiter {
+	return &Limiter{
+		Budget:  budget,
+		Now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow забирает токен из корзины клиента. Если токенов нет, возвращает,
+// через сколько появится следующий
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.take(key, true)
+}
+
+// Check проверяет, есть ли токен в корзине клиента, но не забирает его
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Budget.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	// пополняем корзину за прошедшее время
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(l.Budget.Burst), b.tokens+elapsed*l.Budget.Rate)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.Budget.Rate * float64(time.Second))
+	return false, wait
+}
+
+// SetBudget меняет бюджет на лету. Накопленные токены сохраняются, но не больше нового Burst
+func (l *Limiter) SetBudget(budget Budget) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Budget = budget
+}
+
+// sweep удаляет полные корзины: новая корзина клиента будет такой же
+func (l *Limiter) sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.Budget.Rate >= float64(l.Budget.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Limits - отдельные бюджеты для чтения (GET) и записи (остальные методы)
+// и бюджет неудачных аутентификаций с одного IP адреса.
+// nil вместо лимитера отключает ограничение
+type Limits struct {
+	Read  *Limiter
+	Write *Limiter
+	// каждый ответ 401 забирает токен из корзины IP адреса
+	AuthFailures *Limiter
+
+	// лимитеры меняются при перезагрузке настроек
+	mu sync.RWMutex
+
+	heartbeat *health.Heartbeat
+}
+
+// NewLimits создает лимитеры по бюджетам в формате ParseBudget
+func NewLimits(readSpec, writeSpec, authFailuresSpec string) (*Limits, error) {
+	limits := &Limits{heartbeat: health.NewHeartbeat("ratelimit_sweeper", 2*sweepInterval)}
+	if err := limits.Update(readSpec, writeSpec, authFailuresSpec); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// Update применяет новые бюджеты в формате ParseBudget. Если хотя бы один не разобран,
+// действуют прежние. Корзины клиентов сохраняются
+func (l *Limits) Update(readSpec, writeSpec, authFailuresSpec string) error {
+	readBudget, err := ParseBudget(readSpec)
+	if err != nil {
+		return err
+	}
+	writeBudget, err := ParseBudget(writeSpec)
+	if err != nil {
+		return err
+	}
+	authFailuresBudget, err := ParseBudget(authFailuresSpec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Read = updateLimiter(l.Read, readBudget)
+	l.Write = updateLimiter(l.Write, writeBudget)
+	l.AuthFailures = updateLimiter(l.AuthFailures, authFailuresBudget)
+
+	return nil
+}
+
+func updateLimiter(limiter *Limiter, budget *Budget) *Limiter {
+	switch {
+	case budget == nil:
+		return nil
+	case limiter == nil:
+		return NewLimiter(*budget)
+	}
+	limiter.SetBudget(*budget)
+	return limiter
+}
+
+// RunSweeper раз в sweepInterval удаляет полные корзины клиентов, чтобы разовые клиенты
+// не копили память. Работает до отмены ctx
+func (l *Limits) RunSweeper(ctx context.Context) {
+	defer l.heartbeat.Stop()
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mu.RLock()
+			limiters := []*Limiter{l.Read, l.Write, l.AuthFailures}
+			l.mu.RUnlock()
+
+			for _, limiter := range limiters {
+				if limiter != nil {
+					limiter.sweep()
+				}
+			}
+			l.heartbeat.Beat()
+		}
+	}
+}
+
+// Heartbeat - отметки RunSweeper для проверки готовности
+func (l *Limits) Heartbeat() *health.Heartbeat {
+	return l.heartbeat
+}
+
+func (l *Limits) limiter(method string) *Limiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if method == http.MethodGet {
+		return l.Read
+	}
+	return l.Write
+}
+
+func (l *Limits) authFailures() *Limiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.AuthFailures
+}
+
+// Middleware ограничивает частоту запросов клиента к маршруту с методом method.
+// Должен стоять после auth.Middleware, чтобы различать клиентов по ключу или пользователю.
+// Лимитер выбирается при каждом запросе, поэтому Update действует на уже созданные маршруты
+func (l *Limits) Middleware(method string, next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter := l.limiter(method)
+		if limiter == nil {
+			next(w, r)
+			return
+		}
+
+		if ok, wait := limiter.Allow(ClientKey(r)); !ok {
+			writeTooManyRequests(w, wait)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// AuthMiddleware ограничивает подбор токенов: должен стоять перед auth.Middleware и считает
+// ответы 401 по IP адресу клиента. Пока бюджет IP адреса исчерпан, его запросы отклоняются
+// с 429 еще до проверки токена, поэтому перебор ключей API не нагружает БД
+func (l *Limits) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter := l.authFailures()
+		if limiter == nil {
+			next(w, r)
+			return
+		}
+
+		key := ipKey(r)
+		if ok, wait := limiter.Check(key); !ok {
+			writeTooManyRequests(w, wait)
+			return
+		}
+
+		recorder := httpx.NewRecorder(w)
+		next(recorder, r)
+
+		if recorder.Status() == http.StatusUnauthorized {
+			limiter.Allow(key)
+		}
+	}
+}
+
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	helpers.WriteErrorReponse(w, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", ErrTooManyRequests.Error())
+}
+
+// ClientKey - ключ API, затем пользователь, а для остальных запросов IP адрес
+func ClientKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		if principal.KeyName != "" {
+			return "key:" + principal.KeyName
+		}
+		if principal.UserId != "" {
+			return "user:" + principal.UserId
+		}
+	}
+
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
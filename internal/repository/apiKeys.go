@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"pr-service/internal/models"
+)
+
+type IAPIKeysRepository interface {
+	AddKey(ctx context.Context, key *models.APIKeyModel) (int, error)
+	GetKeys(ctx context.Context) ([]*models.APIKeyModel, error)
+	GetKeyByHash(ctx context.Context, keyHash string) (*models.APIKeyModel, error)
+	RevokeKey(ctx context.Context, keyId int, revokedAt time.Time) (*models.APIKeyModel, error)
+	TouchKey(ctx context.Context, keyId int, usedAt time.Time, interval time.Duration) error
+}
+
+type APIKeysRepository struct {
+	Db *sql.DB
+}
+
+const apiKeyColumns = "key_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+func (ar *APIKeysRepository) AddKey(ctx context.Context, key *models.APIKeyModel) (int, error) {
+	stmt := `INSERT INTO api_keys(name, prefix, key_hash, scopes, created_at, expires_at)
+	VALUES($1, $2, $3, $4, $5, $6) RETURNING key_id`
+
+	var keyId int
+	err := conn(ctx, ar.Db).QueryRowContext(ctx, stmt, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.CreatedAt, key.ExpiresAt).Scan(&keyId)
+	if err != nil {
+		// имя уже занято действующим ключом
+		var sqlError *pq.Error
+		if errors.As(err, &sqlError) && sqlError.Code == "23505" {
+			return 0, ErrDuplicatedKeyName
+		}
+		return 0, err
+	}
+
+	return keyId, nil
+}
+
+// GetKeys возвращает все ключи, включая отозванные, новые первыми
+func (ar *APIKeysRepository) GetKeys(ctx context.Context) ([]*models.APIKeyModel, error) {
+	stmt := "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY key_id DESC"
+
+	rows, err := conn(ctx, ar.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	keys := []*models.APIKeyModel{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (ar *APIKeysRepository) GetKeyByHash(ctx context.Context, keyHash string) (*models.APIKeyModel, error) {
+	stmt := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+
+	key, err := scanAPIKey(conn(ctx, ar.Db).QueryRowContext(ctx, stmt, keyHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// RevokeKey отзывает ключ. Повторный отзыв не меняет дату первого
+func (ar *APIKeysRepository) RevokeKey(ctx context.Context, keyId int, revokedAt time.Time) (*models.APIKeyModel, error) {
+	stmt := "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE key_id = $2 RETURNING " + apiKeyColumns
+
+	key, err := scanAPIKey(conn(ctx, ar.Db).QueryRowContext(ctx, stmt, revokedAt, keyId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// TouchKey обновляет last_used_at не чаще, чем раз в interval, чтобы не писать в БД на каждый запрос
+func (ar *APIKeysRepository) TouchKey(ctx context.Context, keyId int, usedAt time.Time, interval time.Duration) error {
+	stmt := `UPDATE api_keys SET last_used_at = $1
+	WHERE key_id = $2 AND (last_used_at IS NULL OR last_used_at < $3)`
+
+	if _, err := conn(ctx, ar.Db).ExecContext(ctx, stmt, usedAt, keyId, usedAt.Add(-interval)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKeyModel, error) {
+	var key models.APIKeyModel
+	err := row.Scan(&key.KeyId, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
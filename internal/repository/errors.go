@@ -6,5 +6,6 @@ var (
 	ErrDuplicatedTeamName = errors.New("duplicated team_name")
 	ErrDuplicatedPRid     = errors.New("duplicated pull_request_id")
 	ErrDuplicatedUserId   = errors.New("duplicated user_id")
+	ErrDuplicatedKeyName  = errors.New("duplicated api key name")
 	ErrNoRecord           = errors.New("no rows after query")
 )
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"pr-service/internal/models"
+)
+
+type IEventsRepository interface {
+	AddEvent(ctx context.Context, event *models.PullRequestEventModel) error
+}
+
+type EventsRepository struct {
+	Db *sql.DB
+}
+
+func (er *EventsRepository) AddEvent(ctx context.Context, event *models.PullRequestEventModel) error {
+	stmt := `INSERT INTO pull_request_events(pull_request_id, event_type, user_id, old_user_id, created_at)
+	VALUES($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)`
+
+	_, err := conn(ctx, er.Db).ExecContext(ctx, stmt, event.PullRequestId, event.EventType, event.UserId, event.OldUserId, event.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
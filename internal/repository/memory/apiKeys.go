@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+type APIKeysRepository struct {
+	Store *Store
+}
+
+func (ar *APIKeysRepository) AddKey(ctx context.Context, key *models.APIKeyModel) (int, error) {
+	var keyId int
+	err := ar.Store.write(ctx, func(d *data) error {
+		for _, stored := range d.apiKeys {
+			// имя уже занято действующим ключом
+			if stored.Name == key.Name && stored.RevokedAt == nil {
+				return repository.ErrDuplicatedKeyName
+			}
+			if stored.KeyHash == key.KeyHash {
+				return errUniqueViolation
+			}
+		}
+
+		d.lastKeyId++
+		keyId = d.lastKeyId
+		d.apiKeys = append(d.apiKeys, models.APIKeyModel{
+			KeyId:     keyId,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			KeyHash:   key.KeyHash,
+			Scopes:    slices.Clone(key.Scopes),
+			CreatedAt: key.CreatedAt,
+			ExpiresAt: key.ExpiresAt,
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return keyId, nil
+}
+
+// GetKeys возвращает все ключи, включая отозванные, новые первыми
+func (ar *APIKeysRepository) GetKeys(ctx context.Context) ([]*models.APIKeyModel, error) {
+	keys := []*models.APIKeyModel{}
+	err := ar.Store.read(ctx, func(d *data) error {
+		for _, key := range slices.Backward(d.apiKeys) {
+			keys = append(keys, copyKey(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (ar *APIKeysRepository) GetKeyByHash(ctx context.Context, keyHash string) (*models.APIKeyModel, error) {
+	var result *models.APIKeyModel
+	err := ar.Store.read(ctx, func(d *data) error {
+		for _, key := range d.apiKeys {
+			if key.KeyHash == keyHash {
+				result = copyKey(key)
+				return nil
+			}
+		}
+		return repository.ErrNoRecord
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RevokeKey отзывает ключ. Повторный отзыв не меняет дату первого
+func (ar *APIKeysRepository) RevokeKey(ctx context.Context, keyId int, revokedAt time.Time) (*models.APIKeyModel, error) {
+	var result *models.APIKeyModel
+	err := ar.Store.write(ctx, func(d *data) error {
+		for i, key := range d.apiKeys {
+			if key.KeyId != keyId {
+				continue
+			}
+
+			if key.RevokedAt == nil {
+				d.apiKeys[i].RevokedAt = &revokedAt
+			}
+			result = copyKey(d.apiKeys[i])
+			return nil
+		}
+		return repository.ErrNoRecord
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TouchKey обновляет last_used_at не чаще, чем раз в interval
+func (ar *APIKeysRepository) TouchKey(ctx context.Context, keyId int, usedAt time.Time, interval time.Duration) error {
+	return ar.Store.write(ctx, func(d *data) error {
+		for i, key := range d.apiKeys {
+			if key.KeyId == keyId && (key.LastUsedAt == nil || key.LastUsedAt.Before(usedAt.Add(-interval))) {
+				d.apiKeys[i].LastUsedAt = &usedAt
+			}
+		}
+		return nil
+	})
+}
+
+// copyKey отдает копию, чтобы вызывающий код не менял хранимые scopes
+func copyKey(key models.APIKeyModel) *models.APIKeyModel {
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key
+}
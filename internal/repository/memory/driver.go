@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SQL драйвер нужен только для транзакций: сервисы открывают их через *sql.DB
+// и передают *sql.Tx в репозитории. Из SQL понимаются лишь точки сохранения
+var errNoSQL = errors.New("memory: SQL statements are not supported")
+
+type connector struct {
+	store *Store
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{store: c.store}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return memoryDriver{}
+}
+
+type memoryDriver struct{}
+
+func (memoryDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("memory: use Store.DB instead of sql.Open")
+}
+
+type conn struct {
+	store *Store
+	tx    *transaction
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("%w: %q", errNoSQL, query)
+}
+
+// Close откатывает транзакцию, если подключение закрывается посреди нее
+func (c *conn) Close() error {
+	if c.tx != nil {
+		c.store.finish(c.tx, false)
+		c.tx = nil
+	}
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx не различает уровни изоляции: транзакции идут по одной, то есть
+// изоляция не слабее запрошенной
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.store.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = tx
+	return &connTx{conn: c}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	return nil
+}
+
+// ExecContext выполняет команды точек сохранения из repository.Savepoint и соседних функций
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.tx == nil {
+		return nil, fmt.Errorf("%w: %q", errNoSQL, query)
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(query, "SAVEPOINT "):
+		err = c.store.savepoint(c.tx, strings.TrimPrefix(query, "SAVEPOINT "))
+	case strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT "):
+		err = c.store.rollbackToSavepoint(c.tx, strings.TrimPrefix(query, "ROLLBACK TO SAVEPOINT "), false)
+	case strings.HasPrefix(query, "RELEASE SAVEPOINT "):
+		err = c.store.rollbackToSavepoint(c.tx, strings.TrimPrefix(query, "RELEASE SAVEPOINT "), true)
+	default:
+		err = fmt.Errorf("%w: %q", errNoSQL, query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+type connTx struct {
+	conn *conn
+}
+
+func (t *connTx) Commit() error {
+	return t.finish(true)
+}
+
+func (t *connTx) Rollback() error {
+	return t.finish(false)
+}
+
+func (t *connTx) finish(commit bool) error {
+	tx := t.conn.tx
+	t.conn.tx = nil
+	if tx == nil {
+		return errors.New("memory: transaction is not open")
+	}
+
+	return t.conn.store.finish(tx, commit)
+}
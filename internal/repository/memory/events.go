@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+
+	"pr-service/internal/models"
+)
+
+type EventsRepository struct {
+	Store *Store
+}
+
+func (er *EventsRepository) AddEvent(ctx context.Context, event *models.PullRequestEventModel) error {
+	return er.Store.write(ctx, func(d *data) error {
+		if _, ok := d.pullRequests[event.PullRequestId]; !ok {
+			return errForeignKey
+		}
+		for _, userId := range []string{event.UserId, event.OldUserId} {
+			if userId != "" && !d.userExists(userId) {
+				return errForeignKey
+			}
+		}
+
+		d.lastEventId++
+		d.events = append(d.events, models.PullRequestEventModel{
+			EventId:       d.lastEventId,
+			PullRequestId: event.PullRequestId,
+			EventType:     event.EventType,
+			UserId:        event.UserId,
+			OldUserId:     event.OldUserId,
+			CreatedAt:     event.CreatedAt,
+		})
+		return nil
+	})
+}
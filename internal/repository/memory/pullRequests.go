@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"pr-service/internal/enums"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+type PullRequestsRepository struct {
+	Store *Store
+}
+
+func (pr *PullRequestsRepository) AddPullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error {
+	return pr.Store.write(ctx, func(d *data) error {
+		if _, ok := d.pullRequests[pullRequest.PullRequestId]; ok {
+			return repository.ErrDuplicatedPRid
+		}
+		if !d.userExists(pullRequest.AuthorID) || (pullRequest.TeamName != "" && !d.teamExists(pullRequest.TeamName)) {
+			return errForeignKey
+		}
+
+		d.pullRequests[pullRequest.PullRequestId] = models.PullRequestModel{
+			PullRequestId:   pullRequest.PullRequestId,
+			PullRequestName: pullRequest.PullRequestName,
+			AuthorID:        pullRequest.AuthorID,
+			Status:          enums.OPEN,
+			TeamName:        pullRequest.TeamName,
+			CreatedAt:       pullRequest.CreatedAt,
+		}
+		return nil
+	})
+}
+
+func (pr *PullRequestsRepository) MergePullRequest(ctx context.Context, mergedAt time.Time, id string) error {
+	return pr.Store.write(ctx, func(d *data) error {
+		if pullRequest, ok := d.pullRequests[id]; ok {
+			pullRequest.Status = enums.MERGED
+			pullRequest.MergedAt = &mergedAt
+			d.pullRequests[id] = pullRequest
+		}
+		return nil
+	})
+}
+
+func (pr *PullRequestsRepository) GetPullRequestById(ctx context.Context, id string) (*models.PullRequestModel, error) {
+	var model *models.PullRequestModel
+	err := pr.Store.read(ctx, func(d *data) error {
+		pullRequest, ok := d.pullRequests[id]
+		if !ok {
+			return repository.ErrNoRecord
+		}
+
+		model = &pullRequest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// GetPullRequestByIdForUpdate не блокирует отдельный pr: транзакции хранилища
+// и так выполняются по одной
+func (pr *PullRequestsRepository) GetPullRequestByIdForUpdate(ctx context.Context, id string) (*models.PullRequestModel, error) {
+	return pr.GetPullRequestById(ctx, id)
+}
+
+func (pr *PullRequestsRepository) CountAllPullRequests(ctx context.Context) (int, error) {
+	var count int
+	err := pr.Store.read(ctx, func(d *data) error {
+		count = len(d.pullRequests)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (pr *PullRequestsRepository) CountPullRequestsByStatus(ctx context.Context) (map[string]int, error) {
+	result := make(map[string]int)
+	err := pr.Store.read(ctx, func(d *data) error {
+		for _, pullRequest := range d.pullRequests {
+			result[pullRequest.Status]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CountPullRequestsByTeamAndStatus считает PR каждой команды по статусам
+func (pr *PullRequestsRepository) CountPullRequestsByTeamAndStatus(ctx context.Context) (map[string]map[string]int, error) {
+	result := make(map[string]map[string]int)
+	err := pr.Store.read(ctx, func(d *data) error {
+		for _, pullRequest := range d.pullRequests {
+			if pullRequest.TeamName == "" {
+				continue
+			}
+			if result[pullRequest.TeamName] == nil {
+				result[pullRequest.TeamName] = make(map[string]int)
+			}
+			result[pullRequest.TeamName][pullRequest.Status]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+
+	"pr-service/internal/enums"
+	"pr-service/internal/models"
+)
+
+type ReviewersRepository struct {
+	Store *Store
+}
+
+func (rr *ReviewersRepository) AddReviewer(ctx context.Context, model *models.ReviewerModel) error {
+	return rr.Store.write(ctx, func(d *data) error {
+		if _, ok := d.pullRequests[model.PullRequestId]; !ok || !d.userExists(model.UserId) {
+			return errForeignKey
+		}
+
+		d.lastReviewerId++
+		d.reviewers = append(d.reviewers, reviewer{
+			id:            d.lastReviewerId,
+			userId:        model.UserId,
+			pullRequestId: model.PullRequestId,
+		})
+		return nil
+	})
+}
+
+func (rr *ReviewersRepository) GetReviewersIdByPullRequestId(ctx context.Context, id string) ([]string, error) {
+	reviewerIds := []string{}
+	err := rr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			if reviewer.pullRequestId == id {
+				reviewerIds = append(reviewerIds, reviewer.userId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewerIds, nil
+}
+
+func (rr *ReviewersRepository) ChangeReviewer(ctx context.Context, pullRequestId, oldReviewerId, newReviewerId string) error {
+	return rr.Store.write(ctx, func(d *data) error {
+		if !d.userExists(newReviewerId) {
+			return errForeignKey
+		}
+
+		for i, reviewer := range d.reviewers {
+			if reviewer.pullRequestId == pullRequestId && reviewer.userId == oldReviewerId {
+				d.reviewers[i].userId = newReviewerId
+			}
+		}
+		return nil
+	})
+}
+
+// GetPullRequestIDsWithReviewersByUserId возвращает открытые pr, где пользователь - ревьювер
+func (rr *ReviewersRepository) GetPullRequestIDsWithReviewersByUserId(ctx context.Context, id string) ([]string, error) {
+	pullRequestIds := []string{}
+	err := rr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			if reviewer.userId == id && d.pullRequests[reviewer.pullRequestId].Status == enums.OPEN {
+				pullRequestIds = append(pullRequestIds, reviewer.pullRequestId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pullRequestIds, nil
+}
+
+// GetAllPullRequestIDsByUserId возвращает pr, где пользователь - ревьювер, вместе со слитыми
+func (rr *ReviewersRepository) GetAllPullRequestIDsByUserId(ctx context.Context, id string) ([]string, error) {
+	pullRequestIds := []string{}
+	err := rr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			if reviewer.userId == id {
+				pullRequestIds = append(pullRequestIds, reviewer.pullRequestId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pullRequestIds, nil
+}
+
+func (rr *ReviewersRepository) CountAssignmentsByUser(ctx context.Context) (map[string]int, error) {
+	result := make(map[string]int)
+	err := rr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			result[reviewer.userId]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CountAssignmentsByTeam считает назначения ревьюверов на PR каждой команды
+func (rr *ReviewersRepository) CountAssignmentsByTeam(ctx context.Context) (map[string]int, error) {
+	result := make(map[string]int)
+	err := rr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			if teamName := d.pullRequests[reviewer.pullRequestId].TeamName; teamName != "" {
+				result[teamName]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
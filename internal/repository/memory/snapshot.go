@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"pr-service/internal/enums"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+type SnapshotRepository struct {
+	Store *Store
+}
+
+// IsEmpty проверяет, что в хранилище нет ни команд, ни пользователей, ни pr
+func (sr *SnapshotRepository) IsEmpty(ctx context.Context) (bool, error) {
+	var isEmpty bool
+	err := sr.Store.read(ctx, func(d *data) error {
+		isEmpty = len(d.teams) == 0 && len(d.users) == 0 && len(d.pullRequests) == 0
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return isEmpty, nil
+}
+
+// GetAllUsers возвращает всех пользователей, включая удаленных
+func (sr *SnapshotRepository) GetAllUsers(ctx context.Context) ([]*models.UserModel, error) {
+	users := []*models.UserModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for _, user := range d.users {
+			user.Handles = maps.Clone(user.Handles)
+			users = append(users, &user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(users, func(a, b *models.UserModel) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return users, nil
+}
+
+func (sr *SnapshotRepository) GetAllMemberships(ctx context.Context) ([]*models.TeamMemberModel, error) {
+	members := []*models.TeamMemberModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for key, isPrimary := range d.members {
+			members = append(members, &models.TeamMemberModel{TeamName: key.teamName, UserId: key.userId, IsPrimary: isPrimary})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(members, func(a, b *models.TeamMemberModel) int {
+		return cmp.Or(strings.Compare(a.TeamName, b.TeamName), strings.Compare(a.UserId, b.UserId))
+	})
+
+	return members, nil
+}
+
+func (sr *SnapshotRepository) GetAllTeamLeads(ctx context.Context) ([]*models.TeamLeadModel, error) {
+	leads := []*models.TeamLeadModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for key := range d.leads {
+			leads = append(leads, &models.TeamLeadModel{TeamName: key.teamName, UserId: key.userId})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(leads, func(a, b *models.TeamLeadModel) int {
+		return cmp.Or(strings.Compare(a.TeamName, b.TeamName), strings.Compare(a.UserId, b.UserId))
+	})
+
+	return leads, nil
+}
+
+func (sr *SnapshotRepository) GetAllPullRequests(ctx context.Context) ([]*models.PullRequestModel, error) {
+	pullRequests := []*models.PullRequestModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for _, pullRequest := range d.pullRequests {
+			pullRequests = append(pullRequests, &pullRequest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(pullRequests, func(a, b *models.PullRequestModel) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.PullRequestId, b.PullRequestId))
+	})
+
+	return pullRequests, nil
+}
+
+func (sr *SnapshotRepository) GetAllReviewers(ctx context.Context) ([]*models.ReviewerModel, error) {
+	reviewers := []*models.ReviewerModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for _, reviewer := range d.reviewers {
+			reviewers = append(reviewers, &models.ReviewerModel{UserId: reviewer.userId, PullRequestId: reviewer.pullRequestId})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviewers, nil
+}
+
+func (sr *SnapshotRepository) GetAllEvents(ctx context.Context) ([]*models.PullRequestEventModel, error) {
+	events := []*models.PullRequestEventModel{}
+	err := sr.Store.read(ctx, func(d *data) error {
+		for _, event := range d.events {
+			events = append(events, &event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// RestoreUser добавляет пользователя со всеми полями, включая отметку об удалении
+func (sr *SnapshotRepository) RestoreUser(ctx context.Context, user *models.UserModel) error {
+	// пустой map хранится как пустой объект, а не null
+	handles := maps.Clone(user.Handles)
+	if handles == nil {
+		handles = map[string]string{}
+	}
+
+	return sr.Store.write(ctx, func(d *data) error {
+		if d.userExists(user.Id) {
+			return errUniqueViolation
+		}
+
+		d.users[user.Id] = models.UserModel{
+			Id:        user.Id,
+			Username:  user.Username,
+			IsActive:  user.IsActive,
+			Email:     user.Email,
+			Handles:   handles,
+			DeletedAt: user.DeletedAt,
+		}
+		return nil
+	})
+}
+
+func (sr *SnapshotRepository) RestoreMembership(ctx context.Context, model *models.TeamMemberModel) error {
+	return sr.Store.write(ctx, func(d *data) error {
+		key := member{teamName: model.TeamName, userId: model.UserId}
+		if _, ok := d.members[key]; ok || (model.IsPrimary && d.primaryTeam(model.UserId) != "") {
+			return errUniqueViolation
+		}
+		if !d.teamExists(model.TeamName) || !d.userExists(model.UserId) {
+			return errForeignKey
+		}
+
+		d.members[key] = model.IsPrimary
+		return nil
+	})
+}
+
+func (sr *SnapshotRepository) RestoreTeamLead(ctx context.Context, lead *models.TeamLeadModel) error {
+	return sr.Store.write(ctx, func(d *data) error {
+		key := member{teamName: lead.TeamName, userId: lead.UserId}
+		if _, ok := d.leads[key]; ok {
+			return errUniqueViolation
+		}
+		if !d.teamExists(lead.TeamName) || !d.userExists(lead.UserId) {
+			return errForeignKey
+		}
+
+		d.leads[key] = struct{}{}
+		return nil
+	})
+}
+
+// RestorePullRequest добавляет pr с исходным статусом и датами
+func (sr *SnapshotRepository) RestorePullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error {
+	// неизвестный статус
+	if pullRequest.Status != enums.OPEN && pullRequest.Status != enums.MERGED {
+		return repository.ErrNoRecord
+	}
+
+	return sr.Store.write(ctx, func(d *data) error {
+		if _, ok := d.pullRequests[pullRequest.PullRequestId]; ok {
+			return repository.ErrDuplicatedPRid
+		}
+		if !d.userExists(pullRequest.AuthorID) || (pullRequest.TeamName != "" && !d.teamExists(pullRequest.TeamName)) {
+			return errForeignKey
+		}
+
+		d.pullRequests[pullRequest.PullRequestId] = models.PullRequestModel{
+			PullRequestId:   pullRequest.PullRequestId,
+			PullRequestName: pullRequest.PullRequestName,
+			AuthorID:        pullRequest.AuthorID,
+			Status:          pullRequest.Status,
+			TeamName:        pullRequest.TeamName,
+			CreatedAt:       pullRequest.CreatedAt,
+			MergedAt:        pullRequest.MergedAt,
+		}
+		return nil
+	})
+}
@@ -0,0 +1,293 @@
+// Package memory - хранилище сервиса в памяти процесса для локальной разработки,
+// mock сервера и тестов без PostgreSQL. Репозитории пакета реализуют те же
+// интерфейсы, что и репозитории PostgreSQL, и ведут себя так же, включая ошибки
+// дубликатов и проверки внешних ключей
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+var (
+	// нарушение внешнего ключа: строка ссылается на несуществующую запись
+	errForeignKey = errors.New("memory: foreign key violation")
+	// нарушение уникальности, для которого в repository нет своей ошибки
+	errUniqueViolation = errors.New("memory: unique violation")
+)
+
+// Store хранит данные и транзакции. Транзакции открываются через DB().BeginTx,
+// обычно repository.Transactor, как и для PostgreSQL, и выполняются по одной: следующая ждет окончания предыдущей.
+// Транзакция работает с копией данных, коммит подменяет данные копией, откат ее
+// отбрасывает. Запросы без транзакции видят только подтвержденные данные.
+//
+// Слот транзакции один, поэтому код внутри транзакции не должен открывать новую с контекстом
+// без этой транзакции, например context.Background(): BeginTx будет ждать освобождения слота,
+// которое наступит только после выхода из внешней транзакции, то есть никогда. Вложенные
+// вызовы WithTx должны получать контекст внешней транзакции, тогда они к ней присоединяются.
+// Ожидание слота прерывается отменой контекста, поэтому такие вызовы стоит ограничивать таймаутом
+type Store struct {
+	mu        sync.Mutex
+	committed *data
+	tx        *transaction
+
+	// занят, пока открыта транзакция
+	txSlot chan struct{}
+
+	db *sql.DB
+}
+
+func NewStore() *Store {
+	store := &Store{
+		committed: newData(),
+		txSlot:    make(chan struct{}, 1),
+	}
+	store.db = sql.OpenDB(&connector{store: store})
+
+	return store
+}
+
+// DB возвращает *sql.DB, через который сервисы открывают транзакции хранилища.
+// SQL запросы он не выполняет
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+type member struct {
+	teamName string
+	userId   string
+}
+
+type reviewer struct {
+	id            int
+	userId        string
+	pullRequestId string
+}
+
+// data - содержимое всех таблиц. Записи хранятся по значению, вложенные map и
+// срезы не меняются на месте, поэтому копии данных достаточно неглубокого копирования
+type data struct {
+	// команда -> родительская команда, "" у корневой
+	teams map[string]string
+	// TeamName и Teams не хранятся, они считаются по members
+	users        map[string]models.UserModel
+	members      map[member]bool // -> is_primary
+	leads        map[member]struct{}
+	pullRequests map[string]models.PullRequestModel
+	// в порядке reviewer_id, event_id и key_id
+	reviewers []reviewer
+	events    []models.PullRequestEventModel
+	apiKeys   []models.APIKeyModel
+
+	lastReviewerId int
+	lastEventId    int
+	lastKeyId      int
+}
+
+func newData() *data {
+	return &data{
+		teams:        make(map[string]string),
+		users:        make(map[string]models.UserModel),
+		members:      make(map[member]bool),
+		leads:        make(map[member]struct{}),
+		pullRequests: make(map[string]models.PullRequestModel),
+	}
+}
+
+func (d *data) clone() *data {
+	cloned := *d
+	cloned.teams = maps.Clone(d.teams)
+	cloned.users = maps.Clone(d.users)
+	cloned.members = maps.Clone(d.members)
+	cloned.leads = maps.Clone(d.leads)
+	cloned.pullRequests = maps.Clone(d.pullRequests)
+	cloned.reviewers = slices.Clone(d.reviewers)
+	cloned.events = slices.Clone(d.events)
+	cloned.apiKeys = slices.Clone(d.apiKeys)
+
+	return &cloned
+}
+
+// primaryTeam возвращает основную команду пользователя или пустую строку
+func (d *data) primaryTeam(userId string) string {
+	for key, isPrimary := range d.members {
+		if key.userId == userId && isPrimary {
+			return key.teamName
+		}
+	}
+	return ""
+}
+
+func (d *data) teamExists(teamName string) bool {
+	_, ok := d.teams[teamName]
+	return ok
+}
+
+// userExists проверяет внешний ключ, поэтому учитывает и удаленных пользователей
+func (d *data) userExists(userId string) bool {
+	_, ok := d.users[userId]
+	return ok
+}
+
+type savepoint struct {
+	name string
+	data *data
+}
+
+type transaction struct {
+	data       *data
+	savepoints []savepoint
+}
+
+func (s *Store) begin(ctx context.Context) (*transaction, error) {
+	select {
+	case s.txSlot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tx = &transaction{data: s.committed.clone()}
+	return s.tx, nil
+}
+
+// finish завершает транзакцию коммитом или откатом и пропускает следующую
+func (s *Store) finish(tx *transaction, commit bool) error {
+	s.mu.Lock()
+	if s.tx != tx {
+		s.mu.Unlock()
+		return sql.ErrTxDone
+	}
+	if commit {
+		s.committed = tx.data
+	}
+	s.tx = nil
+	s.mu.Unlock()
+
+	<-s.txSlot
+	return nil
+}
+
+func (s *Store) savepoint(tx *transaction, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tx != tx {
+		return sql.ErrTxDone
+	}
+
+	tx.savepoints = append(tx.savepoints, savepoint{name: name, data: tx.data.clone()})
+	return nil
+}
+
+// rollbackToSavepoint возвращает данные к точке сохранения, сама точка остается,
+// более поздние удаляются. release удаляет точку и все более поздние
+func (s *Store) rollbackToSavepoint(tx *transaction, name string, release bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tx != tx {
+		return sql.ErrTxDone
+	}
+
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i].name != name {
+			continue
+		}
+
+		if release {
+			tx.savepoints = tx.savepoints[:i]
+		} else {
+			tx.data = tx.savepoints[i].data.clone()
+			tx.savepoints = tx.savepoints[:i+1]
+		}
+		return nil
+	}
+
+	return fmt.Errorf("memory: savepoint %q does not exist", name)
+}
+
+// view возвращает данные, которые видит запрос: запросу в транзакции из контекста -
+// ее копию, запросу без транзакции - подтвержденные данные
+func (s *Store) view(ctx context.Context) (*data, error) {
+	if repository.TxFromContext(ctx) == nil {
+		return s.committed, nil
+	}
+	if s.tx == nil {
+		return nil, sql.ErrTxDone
+	}
+
+	return s.tx.data, nil
+}
+
+func (s *Store) read(ctx context.Context, fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, err := s.view(ctx)
+	if err != nil {
+		return err
+	}
+
+	return fn(d)
+}
+
+// write изменяет данные. fn сначала проверяет все условия и только потом меняет
+// данные, чтобы ошибка не оставляла изменение наполовину.
+// Запись без транзакции применяется и к открытой транзакции вместе с ее точками
+// сохранения, иначе коммит или откат транзакции затер бы эту запись. Если запись
+// противоречит данным транзакции, например добавляет уже созданного в ней пользователя,
+// она не применяется нигде и возвращается ошибка
+func (s *Store) write(ctx context.Context, fn func(d *data) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repository.TxFromContext(ctx) != nil {
+		d, err := s.view(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(d)
+	}
+
+	if s.tx == nil {
+		return fn(s.committed)
+	}
+
+	// копии транзакции меняются на клонах, поэтому ошибка в них ничего не меняет
+	var errs []error
+	txData := s.tx.data.clone()
+	if err := fn(txData); err != nil {
+		errs = append(errs, err)
+	}
+
+	savepoints := slices.Clone(s.tx.savepoints)
+	for i := range savepoints {
+		savepoints[i].data = savepoints[i].data.clone()
+		if err := fn(savepoints[i].data); err != nil {
+			errs = append(errs, fmt.Errorf("savepoint %q: %w", savepoints[i].name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("memory: write conflicts with the open transaction: %w", err)
+	}
+
+	if err := fn(s.committed); err != nil {
+		return err
+	}
+
+	s.tx.data = txData
+	s.tx.savepoints = savepoints
+	return nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+// как и запрос в PostgreSQL, цепочка родителей ограничена на случай цикла
+const maxAncestors = 100
+
+type TeamsRepository struct {
+	Store *Store
+}
+
+func (tr *TeamsRepository) AddTeam(ctx context.Context, teamName string) error {
+	return tr.Store.write(ctx, func(d *data) error {
+		if d.teamExists(teamName) {
+			return repository.ErrDuplicatedTeamName
+		}
+
+		d.teams[teamName] = ""
+		return nil
+	})
+}
+
+// AddTeamIfNotExists возвращает true, если команда была создана
+func (tr *TeamsRepository) AddTeamIfNotExists(ctx context.Context, teamName string) (bool, error) {
+	var created bool
+	err := tr.Store.write(ctx, func(d *data) error {
+		created = !d.teamExists(teamName)
+		if created {
+			d.teams[teamName] = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (tr *TeamsRepository) IsExist(ctx context.Context, teamName string) (bool, error) {
+	var isExist bool
+	err := tr.Store.read(ctx, func(d *data) error {
+		isExist = d.teamExists(teamName)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return isExist, nil
+}
+
+// SetParent меняет родительскую команду, пустое название делает команду корневой
+func (tr *TeamsRepository) SetParent(ctx context.Context, teamName, parentTeamName string) error {
+	return tr.Store.write(ctx, func(d *data) error {
+		if parentTeamName != "" && !d.teamExists(parentTeamName) {
+			return errForeignKey
+		}
+
+		if d.teamExists(teamName) {
+			d.teams[teamName] = parentTeamName
+		}
+		return nil
+	})
+}
+
+// GetAncestors возвращает цепочку родительских команд, начиная с ближайшей
+func (tr *TeamsRepository) GetAncestors(ctx context.Context, teamName string) ([]string, error) {
+	ancestors := []string{}
+	err := tr.Store.read(ctx, func(d *data) error {
+		for parent := d.teams[teamName]; parent != "" && len(ancestors) < maxAncestors; parent = d.teams[parent] {
+			ancestors = append(ancestors, parent)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+func (tr *TeamsRepository) GetAllTeams(ctx context.Context) ([]*models.TeamModel, error) {
+	teams := []*models.TeamModel{}
+	err := tr.Store.read(ctx, func(d *data) error {
+		for name, parent := range d.teams {
+			teams = append(teams, &models.TeamModel{TeamName: name, ParentTeamName: parent})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(teams, func(a, b *models.TeamModel) int {
+		return strings.Compare(a.TeamName, b.TeamName)
+	})
+
+	return teams, nil
+}
+
+// AddTeamLead назначает лида, повторное назначение ничего не меняет
+func (tr *TeamsRepository) AddTeamLead(ctx context.Context, teamName, userId string) error {
+	return tr.Store.write(ctx, func(d *data) error {
+		if !d.teamExists(teamName) || !d.userExists(userId) {
+			return errForeignKey
+		}
+
+		d.leads[member{teamName: teamName, userId: userId}] = struct{}{}
+		return nil
+	})
+}
+
+func (tr *TeamsRepository) RemoveTeamLead(ctx context.Context, teamName, userId string) error {
+	return tr.Store.write(ctx, func(d *data) error {
+		key := member{teamName: teamName, userId: userId}
+		if _, ok := d.leads[key]; !ok {
+			return repository.ErrNoRecord
+		}
+
+		delete(d.leads, key)
+		return nil
+	})
+}
+
+func (tr *TeamsRepository) GetTeamLeads(ctx context.Context, teamName string) ([]string, error) {
+	return tr.leads(ctx, func(key member) (string, bool) {
+		return key.userId, key.teamName == teamName
+	})
+}
+
+// GetLedTeams возвращает команды, в которых пользователь - лид
+func (tr *TeamsRepository) GetLedTeams(ctx context.Context, userId string) ([]string, error) {
+	return tr.leads(ctx, func(key member) (string, bool) {
+		return key.teamName, key.userId == userId
+	})
+}
+
+// leads возвращает отсортированные значения, которые pick выбрал из назначений лидов
+func (tr *TeamsRepository) leads(ctx context.Context, pick func(key member) (string, bool)) ([]string, error) {
+	names := []string{}
+	err := tr.Store.read(ctx, func(d *data) error {
+		for key := range d.leads {
+			if name, ok := pick(key); ok {
+				names = append(names, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+)
+
+type UsersRepository struct {
+	Store *Store
+}
+
+func (us *UsersRepository) AddUser(ctx context.Context, user *models.UserModel) error {
+	return us.Store.write(ctx, func(d *data) error {
+		if d.userExists(user.Id) {
+			return repository.ErrDuplicatedUserId
+		}
+		if user.TeamName != "" && !d.teamExists(user.TeamName) {
+			return errForeignKey
+		}
+
+		d.users[user.Id] = models.UserModel{
+			Id:       user.Id,
+			Username: user.Username,
+			IsActive: user.IsActive,
+			Handles:  map[string]string{},
+		}
+		if user.TeamName != "" {
+			d.members[member{teamName: user.TeamName, userId: user.Id}] = true
+		}
+		return nil
+	})
+}
+
+// GetUsersByTeam возвращает участников команды по user_id, в team_name - основная команда
+func (us *UsersRepository) GetUsersByTeam(ctx context.Context, teamName string) ([]*models.UserModel, error) {
+	var users []*models.UserModel
+	err := us.Store.read(ctx, func(d *data) error {
+		for key := range d.members {
+			user, ok := d.users[key.userId]
+			if key.teamName != teamName || !ok || user.DeletedAt != nil {
+				continue
+			}
+
+			users = append(users, &models.UserModel{
+				Id:       user.Id,
+				Username: user.Username,
+				IsActive: user.IsActive,
+				TeamName: d.primaryTeam(user.Id),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(users, func(a, b *models.UserModel) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return users, nil
+}
+
+func (us *UsersRepository) GetUserById(ctx context.Context, id string) (*models.UserModel, error) {
+	var result *models.UserModel
+	err := us.Store.read(ctx, func(d *data) error {
+		user, ok := d.users[id]
+		if !ok || user.DeletedAt != nil {
+			return repository.ErrNoRecord
+		}
+
+		// основная команда идет первой, остальные по алфавиту
+		user.TeamName = d.primaryTeam(id)
+		user.Teams = []string{}
+		for key := range d.members {
+			if key.userId == id && key.teamName != user.TeamName {
+				user.Teams = append(user.Teams, key.teamName)
+			}
+		}
+		slices.Sort(user.Teams)
+		if user.TeamName != "" {
+			user.Teams = append([]string{user.TeamName}, user.Teams...)
+		}
+
+		user.Handles = maps.Clone(user.Handles)
+		result = &user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (us *UsersRepository) UpdateUserIsActive(ctx context.Context, id string, isActive bool) error {
+	return us.Store.write(ctx, func(d *data) error {
+		user, ok := d.users[id]
+		if !ok || user.DeletedAt != nil {
+			return repository.ErrNoRecord
+		}
+
+		user.IsActive = isActive
+		d.users[id] = user
+		return nil
+	})
+}
+
+func (us *UsersRepository) UpdateUser(ctx context.Context, user *models.UserModel) error {
+	// пустой map хранится как пустой объект, а не null
+	handles := maps.Clone(user.Handles)
+	if handles == nil {
+		handles = map[string]string{}
+	}
+
+	return us.Store.write(ctx, func(d *data) error {
+		stored, ok := d.users[user.Id]
+		if !ok {
+			return nil
+		}
+
+		stored.Username = user.Username
+		stored.IsActive = user.IsActive
+		stored.Email = user.Email
+		stored.Handles = handles
+		d.users[user.Id] = stored
+		return nil
+	})
+}
+
+// AddMembership добавляет пользователя в команду и возвращает true, если его там не было.
+// Первая команда пользователя становится основной
+func (us *UsersRepository) AddMembership(ctx context.Context, teamName, id string) (bool, error) {
+	var added bool
+	err := us.Store.write(ctx, func(d *data) error {
+		key := member{teamName: teamName, userId: id}
+		if _, ok := d.members[key]; ok {
+			added = false
+			return nil
+		}
+		if !d.teamExists(teamName) || !d.userExists(id) {
+			return errForeignKey
+		}
+
+		d.members[key] = d.primaryTeam(id) == ""
+		added = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return added, nil
+}
+
+// RemoveMembership убирает пользователя из команды. Если команда была основной,
+// основной становится первая по алфавиту из оставшихся
+func (us *UsersRepository) RemoveMembership(ctx context.Context, teamName, id string) error {
+	return us.Store.write(ctx, func(d *data) error {
+		delete(d.members, member{teamName: teamName, userId: id})
+		if d.primaryTeam(id) != "" {
+			return nil
+		}
+
+		first := ""
+		for key := range d.members {
+			if key.userId == id && (first == "" || key.teamName < first) {
+				first = key.teamName
+			}
+		}
+		if first != "" {
+			d.members[member{teamName: first, userId: id}] = true
+		}
+		return nil
+	})
+}
+
+// SetPrimaryTeam переводит пользователя в новую основную команду, членство в прежней
+// основной команде удаляется. Пустое название только открепляет от основной команды
+func (us *UsersRepository) SetPrimaryTeam(ctx context.Context, id, teamName string) error {
+	return us.Store.write(ctx, func(d *data) error {
+		if teamName != "" && (!d.teamExists(teamName) || !d.userExists(id)) {
+			return errForeignKey
+		}
+
+		if primary := d.primaryTeam(id); primary != "" && primary != teamName {
+			delete(d.members, member{teamName: primary, userId: id})
+		}
+		if teamName != "" {
+			d.members[member{teamName: teamName, userId: id}] = true
+		}
+		return nil
+	})
+}
+
+// DeleteUser удаляет пользователя мягко: запись остается для истории PR и статистики,
+// а членство в командах и роли лида удаляются
+func (us *UsersRepository) DeleteUser(ctx context.Context, id string) error {
+	deletedAt := time.Now()
+
+	return us.Store.write(ctx, func(d *data) error {
+		user, ok := d.users[id]
+		if !ok || user.DeletedAt != nil {
+			return nil
+		}
+
+		user.DeletedAt = &deletedAt
+		user.IsActive = false
+		d.users[id] = user
+
+		for key := range d.members {
+			if key.userId == id {
+				delete(d.members, key)
+			}
+		}
+		for key := range d.leads {
+			if key.userId == id {
+				delete(d.leads, key)
+			}
+		}
+		return nil
+	})
+}
+
+func (us *UsersRepository) IsExist(ctx context.Context, id string) (bool, error) {
+	var isExist bool
+	err := us.Store.read(ctx, func(d *data) error {
+		user, ok := d.users[id]
+		isExist = ok && user.DeletedAt == nil
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return isExist, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,25 +13,24 @@ import (
 )
 
 type IPullRequestsRepository interface {
-	GetDB() *sql.DB
-	AddPullRequest(tx *sql.Tx, pullRequest *models.PullRequestModel) error
-	MergePullRequest(mergedAt time.Time, id string) error
-	GetPullRequestById(id string) (*models.PullRequestModel, error)
+	AddPullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error
+	MergePullRequest(ctx context.Context, mergedAt time.Time, id string) error
+	GetPullRequestById(ctx context.Context, id string) (*models.PullRequestModel, error)
+	GetPullRequestByIdForUpdate(ctx context.Context, id string) (*models.PullRequestModel, error)
+	CountAllPullRequests(ctx context.Context) (int, error)
+	CountPullRequestsByStatus(ctx context.Context) (map[string]int, error)
+	CountPullRequestsByTeamAndStatus(ctx context.Context) (map[string]map[string]int, error)
 }
 
 type PullRequestsRepository struct {
 	Db *sql.DB
 }
 
-func (pr *PullRequestsRepository) AddPullRequest(tx *sql.Tx, pullRequest *models.PullRequestModel) error {
-	stmt := "INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, created_at, status_id) VALUES($1, $2, $3, $4, $5)"
+func (pr *PullRequestsRepository) AddPullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error {
+	stmt := `INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, created_at, status_id, team_name)
+	VALUES($1, $2, $3, $4, $5, NULLIF($6, ''))`
 
-	var err error
-	if tx != nil {
-		_, err = tx.Exec(stmt, pullRequest.PullRequestId, pullRequest.PullRequestName, pullRequest.AuthorID, pullRequest.CreatedAt, 1) // 1 - OPEN статус
-	} else {
-		_, err = pr.Db.Exec(stmt, pullRequest.PullRequestId, pullRequest.PullRequestName, pullRequest.AuthorID, pullRequest.CreatedAt, 1)
-	}
+	_, err := conn(ctx, pr.Db).ExecContext(ctx, stmt, pullRequest.PullRequestId, pullRequest.PullRequestName, pullRequest.AuthorID, pullRequest.CreatedAt, 1, pullRequest.TeamName) // 1 - OPEN статус
 
 	// ошибка во время операции или из-за дубликата id pr
 	if err != nil {
@@ -46,26 +46,37 @@ func (pr *PullRequestsRepository) AddPullRequest(tx *sql.Tx, pullRequest *models
 	return nil
 }
 
-func (pr *PullRequestsRepository) MergePullRequest(mergedAt time.Time, id string) error {
+func (pr *PullRequestsRepository) MergePullRequest(ctx context.Context, mergedAt time.Time, id string) error {
 	stmt := "UPDATE pull_requests SET status_id = $1, merged_at = $2  WHERE pull_request_id = $3"
 
 	// 2 - статус MERGED
-	if _, err := pr.Db.Exec(stmt, 2, mergedAt, id); err != nil {
+	if _, err := conn(ctx, pr.Db).ExecContext(ctx, stmt, 2, mergedAt, id); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (pr *PullRequestsRepository) GetPullRequestById(id string) (*models.PullRequestModel, error) {
-	stmt := `SELECT pull_request_id, pull_request_name, author_id, merged_at, pull_requests_status.status
+func (pr *PullRequestsRepository) GetPullRequestById(ctx context.Context, id string) (*models.PullRequestModel, error) {
+	return pr.getPullRequest(ctx, id, "")
+}
+
+// GetPullRequestByIdForUpdate читает pr и блокирует его строку до конца транзакции WithTx:
+// другие транзакции, которые читают этот pr так же, ждут ее коммита или отката
+func (pr *PullRequestsRepository) GetPullRequestByIdForUpdate(ctx context.Context, id string) (*models.PullRequestModel, error) {
+	return pr.getPullRequest(ctx, id, " FOR UPDATE OF pull_requests")
+}
+
+// getPullRequest читает pr, lock дописывается в конец запроса
+func (pr *PullRequestsRepository) getPullRequest(ctx context.Context, id string, lock string) (*models.PullRequestModel, error) {
+	stmt := `SELECT pull_request_id, pull_request_name, author_id, merged_at, pull_requests_status.status, COALESCE(team_name, '')
 	FROM pull_requests 
 	JOIN pull_requests_status 
 	ON pull_requests.status_id = pull_requests_status.pr_status_id 
-	WHERE pull_request_id = $1`
+	WHERE pull_request_id = $1` + lock
 
 	model := &models.PullRequestModel{}
-	if err := pr.Db.QueryRow(stmt, id).Scan(&model.PullRequestId, &model.PullRequestName, &model.AuthorID, &model.MergedAt, &model.Status); err != nil {
+	if err := conn(ctx, pr.Db).QueryRowContext(ctx, stmt, id).Scan(&model.PullRequestId, &model.PullRequestName, &model.AuthorID, &model.MergedAt, &model.Status, &model.TeamName); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
 		}
@@ -76,15 +87,11 @@ func (pr *PullRequestsRepository) GetPullRequestById(id string) (*models.PullReq
 	return model, nil
 }
 
-func (pr *PullRequestsRepository) GetDB() *sql.DB {
-	return pr.Db
-}
-
-func (pr *PullRequestsRepository) CountAllPullRequests() (int, error) {
+func (pr *PullRequestsRepository) CountAllPullRequests(ctx context.Context) (int, error) {
 	stmt := "SELECT COUNT(*) FROM pull_requests"
 
 	var count int
-	err := pr.Db.QueryRow(stmt).Scan(&count)
+	err := conn(ctx, pr.Db).QueryRowContext(ctx, stmt).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -92,14 +99,14 @@ func (pr *PullRequestsRepository) CountAllPullRequests() (int, error) {
 	return count, nil
 }
 
-func (pr *PullRequestsRepository) CountPullRequestsByStatus() (map[string]int, error) {
+func (pr *PullRequestsRepository) CountPullRequestsByStatus(ctx context.Context) (map[string]int, error) {
 	stmt := `SELECT pull_requests_status.status, COUNT(*)
 	FROM pull_requests
 	JOIN pull_requests_status
 	ON pull_requests.status_id = pull_requests_status.pr_status_id
 	GROUP BY pull_requests_status.status`
 
-	rows, err := pr.Db.Query(stmt)
+	rows, err := conn(ctx, pr.Db).QueryContext(ctx, stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -124,3 +131,41 @@ func (pr *PullRequestsRepository) CountPullRequestsByStatus() (map[string]int, e
 
 	return result, nil
 }
+
+// CountPullRequestsByTeamAndStatus считает PR каждой команды по статусам
+func (pr *PullRequestsRepository) CountPullRequestsByTeamAndStatus(ctx context.Context) (map[string]map[string]int, error) {
+	stmt := `SELECT pull_requests.team_name, pull_requests_status.status, COUNT(*)
+	FROM pull_requests
+	JOIN pull_requests_status
+	ON pull_requests.status_id = pull_requests_status.pr_status_id
+	WHERE pull_requests.team_name IS NOT NULL
+	GROUP BY pull_requests.team_name, pull_requests_status.status`
+
+	rows, err := conn(ctx, pr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	result := make(map[string]map[string]int)
+	for rows.Next() {
+		var teamName, status string
+		var count int
+		if err := rows.Scan(&teamName, &status, &count); err != nil {
+			return nil, err
+		}
+		if result[teamName] == nil {
+			result[teamName] = make(map[string]int)
+		}
+		result[teamName][status] = count
+	}
+
+	return result, nil
+}
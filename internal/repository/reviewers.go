@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -8,26 +9,23 @@ import (
 )
 
 type IReviewersRepository interface {
-	AddReviewer(tx *sql.Tx, reviewer *models.ReviewerModel) error
-	GetReviewersIdByPullRequestId(id string) ([]string, error)
-	ChangeReviewer(pullRequestId, oldReviewerId, newReviewerId string) error
-	GetPullRequestIDsWithReviewersByUserId(id string) ([]string, error)
-	CountAssignmentsByUser() (map[string]int, error)
+	AddReviewer(ctx context.Context, reviewer *models.ReviewerModel) error
+	GetReviewersIdByPullRequestId(ctx context.Context, id string) ([]string, error)
+	ChangeReviewer(ctx context.Context, pullRequestId, oldReviewerId, newReviewerId string) error
+	GetPullRequestIDsWithReviewersByUserId(ctx context.Context, id string) ([]string, error)
+	GetAllPullRequestIDsByUserId(ctx context.Context, id string) ([]string, error)
+	CountAssignmentsByUser(ctx context.Context) (map[string]int, error)
+	CountAssignmentsByTeam(ctx context.Context) (map[string]int, error)
 }
 
 type ReviewersRepository struct {
 	Db *sql.DB
 }
 
-func (rr *ReviewersRepository) AddReviewer(tx *sql.Tx, reviewer *models.ReviewerModel) error {
+func (rr *ReviewersRepository) AddReviewer(ctx context.Context, reviewer *models.ReviewerModel) error {
 	stmt := "INSERT INTO reviewers(user_id, pull_request_id) VALUES($1, $2)"
 
-	var err error
-	if tx != nil {
-		_, err = tx.Exec(stmt, reviewer.UserId, reviewer.PullRequestId)
-	} else {
-		_, err = rr.Db.Exec(stmt, reviewer.UserId, reviewer.PullRequestId)
-	}
+	_, err := conn(ctx, rr.Db).ExecContext(ctx, stmt, reviewer.UserId, reviewer.PullRequestId)
 
 	if err != nil {
 		return err
@@ -36,10 +34,10 @@ func (rr *ReviewersRepository) AddReviewer(tx *sql.Tx, reviewer *models.Reviewer
 	return nil
 }
 
-func (rr *ReviewersRepository) GetReviewersIdByPullRequestId(id string) ([]string, error) {
+func (rr *ReviewersRepository) GetReviewersIdByPullRequestId(ctx context.Context, id string) ([]string, error) {
 	stmt := "SELECT user_id FROM reviewers WHERE pull_request_id = $1"
 
-	rows, err := rr.Db.Query(stmt, id)
+	rows, err := conn(ctx, rr.Db).QueryContext(ctx, stmt, id)
 	if err != nil {
 		return nil, err
 	}
@@ -64,23 +62,34 @@ func (rr *ReviewersRepository) GetReviewersIdByPullRequestId(id string) ([]strin
 	return reviewrIds, nil
 }
 
-func (rr *ReviewersRepository) ChangeReviewer(pullRequestId, oldReviewerId, newReviewerId string) error {
+func (rr *ReviewersRepository) ChangeReviewer(ctx context.Context, pullRequestId, oldReviewerId, newReviewerId string) error {
 	stmt := "UPDATE reviewers SET user_id = $1 WHERE pull_request_id = $2 and user_id = $3"
 
-	if _, err := rr.Db.Exec(stmt, newReviewerId, pullRequestId, oldReviewerId); err != nil {
+	if _, err := conn(ctx, rr.Db).ExecContext(ctx, stmt, newReviewerId, pullRequestId, oldReviewerId); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (rr *ReviewersRepository) GetPullRequestIDsWithReviewersByUserId(id string) ([]string, error) {
+func (rr *ReviewersRepository) GetPullRequestIDsWithReviewersByUserId(ctx context.Context, id string) ([]string, error) {
 	stmt := `SELECT reviewers.pull_request_id 
     FROM reviewers 
     JOIN pull_requests ON reviewers.pull_request_id = pull_requests.pull_request_id
     WHERE user_id = $1 AND pull_requests.status_id = $2`
 
-	rows, err := rr.Db.Query(stmt, id, 1)
+	return rr.queryPullRequestIds(ctx, stmt, id, 1)
+}
+
+// GetAllPullRequestIDsByUserId возвращает pr, где пользователь - ревьювер, вместе со слитыми
+func (rr *ReviewersRepository) GetAllPullRequestIDsByUserId(ctx context.Context, id string) ([]string, error) {
+	stmt := "SELECT pull_request_id FROM reviewers WHERE user_id = $1"
+
+	return rr.queryPullRequestIds(ctx, stmt, id)
+}
+
+func (rr *ReviewersRepository) queryPullRequestIds(ctx context.Context, stmt string, args ...any) (pullRequestIds []string, err error) {
+	rows, err := conn(ctx, rr.Db).QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +102,7 @@ func (rr *ReviewersRepository) GetPullRequestIDsWithReviewersByUserId(id string)
 		}
 	}()
 
-	pullRequestIds := []string{}
+	pullRequestIds = []string{}
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
@@ -105,10 +114,10 @@ func (rr *ReviewersRepository) GetPullRequestIDsWithReviewersByUserId(id string)
 	return pullRequestIds, nil
 }
 
-func (rr *ReviewersRepository) CountAssignmentsByUser() (map[string]int, error) {
+func (rr *ReviewersRepository) CountAssignmentsByUser(ctx context.Context) (map[string]int, error) {
 	stmt := "SELECT user_id, COUNT(*) FROM reviewers GROUP BY user_id"
 
-	rows, err := rr.Db.Query(stmt)
+	rows, err := conn(ctx, rr.Db).QueryContext(ctx, stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -133,3 +142,37 @@ func (rr *ReviewersRepository) CountAssignmentsByUser() (map[string]int, error)
 
 	return result, nil
 }
+
+// CountAssignmentsByTeam считает назначения ревьюверов на PR каждой команды
+func (rr *ReviewersRepository) CountAssignmentsByTeam(ctx context.Context) (map[string]int, error) {
+	stmt := `SELECT pull_requests.team_name, COUNT(*)
+	FROM reviewers
+	JOIN pull_requests ON reviewers.pull_request_id = pull_requests.pull_request_id
+	WHERE pull_requests.team_name IS NOT NULL
+	GROUP BY pull_requests.team_name`
+
+	rows, err := conn(ctx, rr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	result := make(map[string]int)
+	for rows.Next() {
+		var teamName string
+		var count int
+		if err := rows.Scan(&teamName, &count); err != nil {
+			return nil, err
+		}
+		result[teamName] = count
+	}
+
+	return result, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+var errNoTx = errors.New("savepoint requires a transaction opened by WithTx")
+
+// Savepoint позволяет откатить часть транзакции, не теряя остальные изменения.
+// Название точки сохранения не экранируется, передавать только константы
+func Savepoint(ctx context.Context, name string) error {
+	return execInTx(ctx, "SAVEPOINT "+name)
+}
+
+func RollbackToSavepoint(ctx context.Context, name string) error {
+	return execInTx(ctx, "ROLLBACK TO SAVEPOINT "+name)
+}
+
+func ReleaseSavepoint(ctx context.Context, name string) error {
+	return execInTx(ctx, "RELEASE SAVEPOINT "+name)
+}
+
+// execInTx выполняет команду в транзакции из контекста, вне транзакции точки сохранения не имеют смысла
+func execInTx(ctx context.Context, stmt string) error {
+	tx := TxFromContext(ctx)
+	if tx == nil {
+		return errNoTx
+	}
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"pr-service/internal/models"
+)
+
+// ISnapshotRepository читает и записывает все данные сервиса целиком.
+// Методы работают только внутри транзакции, чтобы снимок был согласованным
+type ISnapshotRepository interface {
+	IsEmpty(ctx context.Context) (bool, error)
+	GetAllUsers(ctx context.Context) ([]*models.UserModel, error)
+	GetAllMemberships(ctx context.Context) ([]*models.TeamMemberModel, error)
+	GetAllTeamLeads(ctx context.Context) ([]*models.TeamLeadModel, error)
+	GetAllPullRequests(ctx context.Context) ([]*models.PullRequestModel, error)
+	GetAllReviewers(ctx context.Context) ([]*models.ReviewerModel, error)
+	GetAllEvents(ctx context.Context) ([]*models.PullRequestEventModel, error)
+	RestoreUser(ctx context.Context, user *models.UserModel) error
+	RestoreMembership(ctx context.Context, member *models.TeamMemberModel) error
+	RestoreTeamLead(ctx context.Context, lead *models.TeamLeadModel) error
+	RestorePullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error
+}
+
+type SnapshotRepository struct {
+	Db *sql.DB
+}
+
+// IsEmpty проверяет, что в БД нет ни команд, ни пользователей, ни pr
+func (sr *SnapshotRepository) IsEmpty(ctx context.Context) (bool, error) {
+	stmt := `SELECT NOT EXISTS(SELECT 1 FROM teams)
+	AND NOT EXISTS(SELECT 1 FROM users)
+	AND NOT EXISTS(SELECT 1 FROM pull_requests)`
+
+	var isEmpty bool
+	if err := conn(ctx, sr.Db).QueryRowContext(ctx, stmt).Scan(&isEmpty); err != nil {
+		return false, err
+	}
+
+	return isEmpty, nil
+}
+
+// GetAllUsers возвращает всех пользователей, включая удаленных
+func (sr *SnapshotRepository) GetAllUsers(ctx context.Context) (users []*models.UserModel, err error) {
+	stmt := `SELECT user_id, username, is_active, COALESCE(email, ''), handles, deleted_at
+	FROM users ORDER BY user_id`
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	users = []*models.UserModel{}
+	for rows.Next() {
+		var user models.UserModel
+		var handles []byte
+		if err := rows.Scan(&user.Id, &user.Username, &user.IsActive, &user.Email, &handles, &user.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(handles, &user.Handles); err != nil {
+			return nil, fmt.Errorf("failed to decode user handles: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (sr *SnapshotRepository) GetAllMemberships(ctx context.Context) (members []*models.TeamMemberModel, err error) {
+	stmt := "SELECT team_name, user_id, is_primary FROM team_members ORDER BY team_name, user_id"
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	members = []*models.TeamMemberModel{}
+	for rows.Next() {
+		var member models.TeamMemberModel
+		if err := rows.Scan(&member.TeamName, &member.UserId, &member.IsPrimary); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (sr *SnapshotRepository) GetAllPullRequests(ctx context.Context) (pullRequests []*models.PullRequestModel, err error) {
+	stmt := `SELECT pull_request_id, pull_request_name, author_id, pull_requests_status.status, COALESCE(team_name, ''), created_at, merged_at
+	FROM pull_requests
+	JOIN pull_requests_status
+	ON pull_requests.status_id = pull_requests_status.pr_status_id
+	ORDER BY created_at, pull_request_id`
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	pullRequests = []*models.PullRequestModel{}
+	for rows.Next() {
+		var model models.PullRequestModel
+		if err := rows.Scan(&model.PullRequestId, &model.PullRequestName, &model.AuthorID, &model.Status, &model.TeamName, &model.CreatedAt, &model.MergedAt); err != nil {
+			return nil, err
+		}
+		pullRequests = append(pullRequests, &model)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pullRequests, nil
+}
+
+func (sr *SnapshotRepository) GetAllReviewers(ctx context.Context) (reviewers []*models.ReviewerModel, err error) {
+	stmt := "SELECT user_id, pull_request_id FROM reviewers ORDER BY reviewer_id"
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	reviewers = []*models.ReviewerModel{}
+	for rows.Next() {
+		var reviewer models.ReviewerModel
+		if err := rows.Scan(&reviewer.UserId, &reviewer.PullRequestId); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, &reviewer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviewers, nil
+}
+
+func (sr *SnapshotRepository) GetAllEvents(ctx context.Context) (events []*models.PullRequestEventModel, err error) {
+	stmt := `SELECT event_id, pull_request_id, event_type, COALESCE(user_id, ''), COALESCE(old_user_id, ''), created_at
+	FROM pull_request_events ORDER BY event_id`
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	events = []*models.PullRequestEventModel{}
+	for rows.Next() {
+		var event models.PullRequestEventModel
+		if err := rows.Scan(&event.EventId, &event.PullRequestId, &event.EventType, &event.UserId, &event.OldUserId, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// RestoreUser добавляет пользователя со всеми полями, включая отметку об удалении
+func (sr *SnapshotRepository) RestoreUser(ctx context.Context, user *models.UserModel) error {
+	stmt := `INSERT INTO users(user_id, username, is_active, email, handles, deleted_at)
+	VALUES($1, $2, $3, NULLIF($4, ''), $5, $6)`
+
+	handles, err := json.Marshal(user.Handles)
+	if err != nil {
+		return err
+	}
+
+	// пустой map хранится как пустой объект, а не null
+	if user.Handles == nil {
+		handles = []byte("{}")
+	}
+
+	if _, err := conn(ctx, sr.Db).ExecContext(ctx, stmt, user.Id, user.Username, user.IsActive, user.Email, handles, user.DeletedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *SnapshotRepository) GetAllTeamLeads(ctx context.Context) (leads []*models.TeamLeadModel, err error) {
+	stmt := "SELECT team_name, user_id FROM team_leads ORDER BY team_name, user_id"
+
+	rows, err := conn(ctx, sr.Db).QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	leads = []*models.TeamLeadModel{}
+	for rows.Next() {
+		var lead models.TeamLeadModel
+		if err := rows.Scan(&lead.TeamName, &lead.UserId); err != nil {
+			return nil, err
+		}
+		leads = append(leads, &lead)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return leads, nil
+}
+
+func (sr *SnapshotRepository) RestoreTeamLead(ctx context.Context, lead *models.TeamLeadModel) error {
+	stmt := "INSERT INTO team_leads(team_name, user_id) VALUES($1, $2)"
+
+	if _, err := conn(ctx, sr.Db).ExecContext(ctx, stmt, lead.TeamName, lead.UserId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *SnapshotRepository) RestoreMembership(ctx context.Context, member *models.TeamMemberModel) error {
+	stmt := "INSERT INTO team_members(team_name, user_id, is_primary) VALUES($1, $2, $3)"
+
+	if _, err := conn(ctx, sr.Db).ExecContext(ctx, stmt, member.TeamName, member.UserId, member.IsPrimary); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestorePullRequest добавляет pr с исходным статусом и датами
+func (sr *SnapshotRepository) RestorePullRequest(ctx context.Context, pullRequest *models.PullRequestModel) error {
+	stmt := `INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, created_at, merged_at, team_name, status_id)
+	SELECT $1, $2, $3, $4, $5, NULLIF($6, ''), pr_status_id FROM pull_requests_status WHERE status = $7`
+
+	result, err := conn(ctx, sr.Db).ExecContext(ctx, stmt, pullRequest.PullRequestId, pullRequest.PullRequestName, pullRequest.AuthorID,
+		pullRequest.CreatedAt, pullRequest.MergedAt, pullRequest.TeamName, pullRequest.Status)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// неизвестный статус
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
@@ -1,31 +1,37 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/lib/pq"
+
+	"pr-service/internal/models"
 )
 
 type ITeamsRepository interface {
-	AddTeam(tx *sql.Tx, teamName string) error
-	IsExist(teamName string) (bool, error)
-	GetDB() *sql.DB
+	AddTeam(ctx context.Context, teamName string) error
+	AddTeamIfNotExists(ctx context.Context, teamName string) (bool, error)
+	IsExist(ctx context.Context, teamName string) (bool, error)
+	SetParent(ctx context.Context, teamName, parentTeamName string) error
+	GetAncestors(ctx context.Context, teamName string) ([]string, error)
+	GetAllTeams(ctx context.Context) ([]*models.TeamModel, error)
+	AddTeamLead(ctx context.Context, teamName, userId string) error
+	RemoveTeamLead(ctx context.Context, teamName, userId string) error
+	GetTeamLeads(ctx context.Context, teamName string) ([]string, error)
+	GetLedTeams(ctx context.Context, userId string) ([]string, error)
 }
 
 type TeamsRepository struct {
 	Db *sql.DB
 }
 
-func (tr *TeamsRepository) AddTeam(tx *sql.Tx, teamName string) error {
+func (tr *TeamsRepository) AddTeam(ctx context.Context, teamName string) error {
 	stmt := "INSERT INTO teams(team_name) VALUES($1)"
 
-	var err error
-	if tx != nil {
-		_, err = tx.Exec(stmt, teamName)
-	} else {
-		_, err = tr.Db.Exec(stmt, teamName)
-	}
+	_, err := conn(ctx, tr.Db).ExecContext(ctx, stmt, teamName)
 
 	// ошибка во время операции или из-за дубликата названия команды
 	if err != nil {
@@ -41,17 +47,177 @@ func (tr *TeamsRepository) AddTeam(tx *sql.Tx, teamName string) error {
 	return nil
 }
 
-func (tr *TeamsRepository) IsExist(teamName string) (bool, error) {
+// AddTeamIfNotExists возвращает true, если команда была создана
+func (tr *TeamsRepository) AddTeamIfNotExists(ctx context.Context, teamName string) (bool, error) {
+	stmt := "INSERT INTO teams(team_name) VALUES($1) ON CONFLICT (team_name) DO NOTHING"
+
+	result, err := conn(ctx, tr.Db).ExecContext(ctx, stmt, teamName)
+
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (tr *TeamsRepository) IsExist(ctx context.Context, teamName string) (bool, error) {
 	stmt := `SELECT EXISTS(SELECT team_name FROM teams WHERE team_name = $1)`
 
 	var isExist bool
-	if err := tr.Db.QueryRow(stmt, teamName).Scan(&isExist); err != nil {
+	if err := conn(ctx, tr.Db).QueryRowContext(ctx, stmt, teamName).Scan(&isExist); err != nil {
 		return false, err
 	}
 
 	return isExist, nil
 }
 
-func (tr *TeamsRepository) GetDB() *sql.DB {
-	return tr.Db
+// SetParent меняет родительскую команду, пустое название делает команду корневой
+func (tr *TeamsRepository) SetParent(ctx context.Context, teamName, parentTeamName string) error {
+	stmt := "UPDATE teams SET parent_team_name = NULLIF($1, '') WHERE team_name = $2"
+
+	_, err := conn(ctx, tr.Db).ExecContext(ctx, stmt, parentTeamName, teamName)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAncestors возвращает цепочку родительских команд, начиная с ближайшей
+func (tr *TeamsRepository) GetAncestors(ctx context.Context, teamName string) ([]string, error) {
+	// глубина ограничена на случай цикла в данных
+	stmt := `WITH RECURSIVE ancestors(team_name, depth) AS (
+		SELECT parent_team_name, 1 FROM teams WHERE team_name = $1 AND parent_team_name IS NOT NULL
+		UNION ALL
+		SELECT teams.parent_team_name, ancestors.depth + 1
+		FROM teams
+		JOIN ancestors ON teams.team_name = ancestors.team_name
+		WHERE teams.parent_team_name IS NOT NULL AND ancestors.depth < 100
+	)
+	SELECT team_name FROM ancestors ORDER BY depth`
+
+	rows, err := conn(ctx, tr.Db).QueryContext(ctx, stmt, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	ancestors := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, name)
+	}
+
+	return ancestors, nil
+}
+
+func (tr *TeamsRepository) GetAllTeams(ctx context.Context) ([]*models.TeamModel, error) {
+	stmt := "SELECT team_name, COALESCE(parent_team_name, '') FROM teams ORDER BY team_name"
+
+	rows, err := conn(ctx, tr.Db).QueryContext(ctx, stmt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	teams := []*models.TeamModel{}
+	for rows.Next() {
+		var team models.TeamModel
+		if err := rows.Scan(&team.TeamName, &team.ParentTeamName); err != nil {
+			return nil, err
+		}
+		teams = append(teams, &team)
+	}
+
+	return teams, nil
+}
+
+// AddTeamLead назначает лида, повторное назначение ничего не меняет
+func (tr *TeamsRepository) AddTeamLead(ctx context.Context, teamName, userId string) error {
+	stmt := "INSERT INTO team_leads(team_name, user_id) VALUES($1, $2) ON CONFLICT DO NOTHING"
+
+	if _, err := conn(ctx, tr.Db).ExecContext(ctx, stmt, teamName, userId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tr *TeamsRepository) RemoveTeamLead(ctx context.Context, teamName, userId string) error {
+	stmt := "DELETE FROM team_leads WHERE team_name = $1 AND user_id = $2"
+
+	result, err := conn(ctx, tr.Db).ExecContext(ctx, stmt, teamName, userId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (tr *TeamsRepository) GetTeamLeads(ctx context.Context, teamName string) ([]string, error) {
+	return tr.queryNames(ctx, "SELECT user_id FROM team_leads WHERE team_name = $1 ORDER BY user_id", teamName)
+}
+
+// GetLedTeams возвращает команды, в которых пользователь - лид
+func (tr *TeamsRepository) GetLedTeams(ctx context.Context, userId string) ([]string, error) {
+	return tr.queryNames(ctx, "SELECT team_name FROM team_leads WHERE user_id = $1 ORDER BY team_name", userId)
+}
+
+func (tr *TeamsRepository) queryNames(ctx context.Context, stmt string, arg string) ([]string, error) {
+	rows, err := conn(ctx, tr.Db).QueryContext(ctx, stmt, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close database rows: %w", errClose)
+			}
+		}
+	}()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
 }
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// сколько раз выполнять транзакцию, которую PostgreSQL отменил из-за конфликта
+	txMaxAttempts = 3
+	// пауза перед повтором, растет с каждой попыткой
+	txRetryDelay = 10 * time.Millisecond
+)
+
+// Querier - общие методы *sql.DB и *sql.Tx, через которые репозитории выполняют запросы
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ITransactor выполняет несколько операций репозиториев как одну единицу работы
+type ITransactor interface {
+	WithTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+}
+
+// Transactor открывает транзакции в Db и передает их репозиториям через контекст
+type Transactor struct {
+	Db *sql.DB
+}
+
+type txKey struct{}
+
+// txState - транзакция и действия, отложенные до ее коммита
+type txState struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+// WithTx выполняет fn в транзакции: коммит, если fn вернула nil, иначе откат.
+// Репозитории, вызванные с контекстом fn, работают внутри этой транзакции.
+// Если транзакция уже открыта выше по стеку, fn выполняется в ней.
+// При конфликте сериализации или взаимной блокировке fn выполняется заново,
+// поэтому побочные эффекты вне БД нужно делать после WithTx или через AfterCommit
+func (t *Transactor) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		err = t.runTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			break
+		}
+
+		// случайная добавка, чтобы конфликтующие транзакции не столкнулись снова
+		delay := txRetryDelay*time.Duration(attempt) + rand.N(txRetryDelay)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+
+	return err
+}
+
+func (t *Transactor) runTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := t.Db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// транзакция откатывается при любой ошибке и при панике в fn
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+				err = errors.Join(err, errRollback)
+			}
+		}
+	}()
+
+	// отложенные действия у каждой попытки свои
+	state := &txState{tx: tx}
+	if err = fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, action := range state.afterCommit {
+		action()
+	}
+	return nil
+}
+
+// AfterCommit откладывает fn до коммита транзакции из ctx, а без транзакции выполняет сразу.
+// После отката или перед повтором транзакции fn не выполняется
+func AfterCommit(ctx context.Context, fn func()) {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	if state == nil {
+		fn()
+		return
+	}
+	state.afterCommit = append(state.afterCommit, fn)
+}
+
+// TxFromContext возвращает транзакцию, открытую WithTx, или nil
+func TxFromContext(ctx context.Context) *sql.Tx {
+	if state, _ := ctx.Value(txKey{}).(*txState); state != nil {
+		return state.tx
+	}
+	return nil
+}
+
+// conn возвращает транзакцию из контекста, а без нее - пул соединений
+func conn(ctx context.Context, db *sql.DB) Querier {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return db
+}
+
+// isRetryable - ошибки, после которых транзакцию можно просто повторить:
+// 40001 - конфликт сериализации, 40P01 - взаимная блокировка
+func isRetryable(err error) bool {
+	var sqlError *pq.Error
+	if errors.As(err, &sqlError) {
+		return sqlError.Code == "40001" || sqlError.Code == "40P01"
+	}
+	return false
+}
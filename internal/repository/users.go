@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -11,26 +14,31 @@ import (
 )
 
 type IUsersRepository interface {
-	AddUser(tx *sql.Tx, user *models.UserModel) error
-	GetUsersByTeam(tx *sql.Tx, teamName string) ([]*models.UserModel, error)
-	GetUserById(tx *sql.Tx, id string) (*models.UserModel, error)
-	UpdateUserIsActive(id string, isActive bool) error
-	IsExist(tx *sql.Tx, id string) (bool, error)
+	AddUser(ctx context.Context, user *models.UserModel) error
+	GetUsersByTeam(ctx context.Context, teamName string) ([]*models.UserModel, error)
+	GetUserById(ctx context.Context, id string) (*models.UserModel, error)
+	UpdateUserIsActive(ctx context.Context, id string, isActive bool) error
+	UpdateUser(ctx context.Context, user *models.UserModel) error
+	AddMembership(ctx context.Context, teamName, id string) (bool, error)
+	RemoveMembership(ctx context.Context, teamName, id string) error
+	SetPrimaryTeam(ctx context.Context, id, teamName string) error
+	DeleteUser(ctx context.Context, id string) error
+	IsExist(ctx context.Context, id string) (bool, error)
 }
 
 type UsersRepository struct {
 	Db *sql.DB
 }
 
-func (us *UsersRepository) AddUser(tx *sql.Tx, user *models.UserModel) error {
-	stmt := "INSERT INTO users (user_id, username, team_name, is_active) VALUES($1, $2, $3, $4)"
+func (us *UsersRepository) AddUser(ctx context.Context, user *models.UserModel) error {
+	// пользователь и его основная команда добавляются одним запросом
+	stmt := `WITH new_user AS (
+		INSERT INTO users (user_id, username, is_active) VALUES($1, $2, $4) RETURNING user_id
+	)
+	INSERT INTO team_members (team_name, user_id, is_primary)
+	SELECT $3, user_id, true FROM new_user WHERE $3 <> ''`
 
-	var err error
-	if tx != nil {
-		_, err = tx.Exec(stmt, user.Id, user.Username, user.TeamName, user.IsActive)
-	} else {
-		_, err = us.Db.Exec(stmt, user.Id, user.Username, user.TeamName, user.IsActive)
-	}
+	_, err := conn(ctx, us.Db).ExecContext(ctx, stmt, user.Id, user.Username, user.TeamName, user.IsActive)
 
 	// ошибка во время операции или из-за дубликата user_id
 	if err != nil {
@@ -46,16 +54,15 @@ func (us *UsersRepository) AddUser(tx *sql.Tx, user *models.UserModel) error {
 	return nil
 }
 
-func (us *UsersRepository) GetUsersByTeam(tx *sql.Tx, teamName string) ([]*models.UserModel, error) {
-	stmt := "SELECT user_id, username, is_active, team_name FROM users WHERE team_name = $1"
+func (us *UsersRepository) GetUsersByTeam(ctx context.Context, teamName string) ([]*models.UserModel, error) {
+	// в team_name возвращается основная команда пользователя
+	stmt := `SELECT users.user_id, users.username, users.is_active, COALESCE(primary_team.team_name, '')
+	FROM users
+	JOIN team_members ON team_members.user_id = users.user_id
+	LEFT JOIN team_members primary_team ON primary_team.user_id = users.user_id AND primary_team.is_primary
+	WHERE team_members.team_name = $1 AND users.deleted_at IS NULL`
 
-	var err error
-	var rows *sql.Rows
-	if tx != nil {
-		rows, err = tx.Query(stmt, teamName)
-	} else {
-		rows, err = us.Db.Query(stmt, teamName)
-	}
+	rows, err := conn(ctx, us.Db).QueryContext(ctx, stmt, teamName)
 
 	// ошибки может быть только в процессе запроса
 	if err != nil {
@@ -82,17 +89,17 @@ func (us *UsersRepository) GetUsersByTeam(tx *sql.Tx, teamName string) ([]*model
 	return users, nil
 }
 
-func (us *UsersRepository) GetUserById(tx *sql.Tx, id string) (*models.UserModel, error) {
-	stmt := "SELECT user_id, username, is_active, team_name FROM users WHERE user_id = $1"
+func (us *UsersRepository) GetUserById(ctx context.Context, id string) (*models.UserModel, error) {
+	// у пользователя без основной команды team_name пустой, основная команда идет первой в списке
+	stmt := `SELECT users.user_id, users.username, users.is_active, COALESCE(primary_team.team_name, ''), COALESCE(users.email, ''), users.handles,
+		ARRAY(SELECT team_name FROM team_members WHERE user_id = users.user_id ORDER BY is_primary DESC, team_name)
+	FROM users
+	LEFT JOIN team_members primary_team ON primary_team.user_id = users.user_id AND primary_team.is_primary
+	WHERE users.user_id = $1 AND users.deleted_at IS NULL`
 
-	var err error
+	var handles []byte
 	user := models.UserModel{}
-	if tx != nil {
-		err = tx.QueryRow(stmt, id).Scan(&user.Id, &user.Username, &user.IsActive, &user.TeamName)
-	} else {
-		err = us.Db.QueryRow(stmt, id).Scan(&user.Id, &user.Username, &user.IsActive, &user.TeamName)
-	}
-
+	err := conn(ctx, us.Db).QueryRowContext(ctx, stmt, id).Scan(&user.Id, &user.Username, &user.IsActive, &user.TeamName, &user.Email, &handles, pq.Array(&user.Teams))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -100,31 +107,155 @@ func (us *UsersRepository) GetUserById(tx *sql.Tx, id string) (*models.UserModel
 		return nil, err
 	}
 
+	if err := json.Unmarshal(handles, &user.Handles); err != nil {
+		return nil, fmt.Errorf("failed to decode user handles: %w", err)
+	}
+
 	return &user, nil
 }
 
-func (us *UsersRepository) UpdateUserIsActive(id string, isActive bool) error {
-	stmt := "UPDATE users SET is_active = $1 WHERE user_id = $2"
+func (us *UsersRepository) UpdateUserIsActive(ctx context.Context, id string, isActive bool) error {
+	stmt := "UPDATE users SET is_active = $1 WHERE user_id = $2 AND deleted_at IS NULL"
+
+	result, err := conn(ctx, us.Db).ExecContext(ctx, stmt, isActive, id)
+	if err != nil {
+		return err
+	}
 
-	if _, err := us.Db.Exec(stmt, isActive, id); err != nil {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
 		return err
 	}
 
+	// пользователя нет или он удален
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
 	return nil
 }
 
-func (tr *UsersRepository) IsExist(tx *sql.Tx, id string) (bool, error) {
-	stmt := `SELECT EXISTS(SELECT user_id FROM users WHERE user_id = $1)`
+func (us *UsersRepository) UpdateUser(ctx context.Context, user *models.UserModel) error {
+	// членство в командах меняется отдельными методами
+	stmt := `UPDATE users
+	SET username = $1, is_active = $2, email = NULLIF($3, ''), handles = $4
+	WHERE user_id = $5`
 
-	var err error
-	var isExist bool
-	if tx != nil {
-		err = tx.QueryRow(stmt, id).Scan(&isExist)
-	} else {
-		err = tr.Db.QueryRow(stmt, id).Scan(&isExist)
+	handles, err := json.Marshal(user.Handles)
+	if err != nil {
+		return err
+	}
+
+	// пустой map хранится как пустой объект, а не null
+	if user.Handles == nil {
+		handles = []byte("{}")
+	}
+
+	_, err = conn(ctx, us.Db).ExecContext(ctx, stmt, user.Username, user.IsActive, user.Email, handles, user.Id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddMembership добавляет пользователя в команду и возвращает true, если его там не было.
+// Первая команда пользователя становится основной
+func (us *UsersRepository) AddMembership(ctx context.Context, teamName, id string) (bool, error) {
+	stmt := `INSERT INTO team_members (team_name, user_id, is_primary)
+	VALUES($1, $2, NOT EXISTS(SELECT 1 FROM team_members WHERE user_id = $2 AND is_primary))
+	ON CONFLICT (team_name, user_id) DO NOTHING`
+
+	result, err := conn(ctx, us.Db).ExecContext(ctx, stmt, teamName, id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// RemoveMembership убирает пользователя из команды. Если команда была основной,
+// основной становится первая по алфавиту из оставшихся
+func (us *UsersRepository) RemoveMembership(ctx context.Context, teamName, id string) error {
+	deleteStmt := "DELETE FROM team_members WHERE team_name = $1 AND user_id = $2"
+	promoteStmt := `UPDATE team_members SET is_primary = true
+	WHERE user_id = $1 AND team_name = (SELECT MIN(team_name) FROM team_members WHERE user_id = $1)
+	AND NOT EXISTS(SELECT 1 FROM team_members WHERE user_id = $1 AND is_primary)`
+
+	_, err := conn(ctx, us.Db).ExecContext(ctx, deleteStmt, teamName, id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, us.Db).ExecContext(ctx, promoteStmt, id)
+
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+// SetPrimaryTeam переводит пользователя в новую основную команду, членство в прежней
+// основной команде удаляется. Пустое название только открепляет от основной команды
+func (us *UsersRepository) SetPrimaryTeam(ctx context.Context, id, teamName string) error {
+	deleteStmt := "DELETE FROM team_members WHERE user_id = $1 AND is_primary AND team_name <> $2"
+	upsertStmt := `INSERT INTO team_members (team_name, user_id, is_primary)
+	VALUES($2, $1, true)
+	ON CONFLICT (team_name, user_id) DO UPDATE SET is_primary = true`
+
+	_, err := conn(ctx, us.Db).ExecContext(ctx, deleteStmt, id, teamName)
+
 	if err != nil {
+		return err
+	}
+
+	// пустое название команды - только открепление
+	if teamName == "" {
+		return nil
+	}
+
+	_, err = conn(ctx, us.Db).ExecContext(ctx, upsertStmt, id, teamName)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser удаляет пользователя мягко: запись остается для истории PR и статистики,
+// а членство в командах и роли лида удаляются тем же запросом
+func (us *UsersRepository) DeleteUser(ctx context.Context, id string) error {
+	stmt := `WITH deleted AS (
+		UPDATE users SET deleted_at = $1, is_active = false WHERE user_id = $2 AND deleted_at IS NULL RETURNING user_id
+	), leads AS (
+		DELETE FROM team_leads WHERE user_id IN (SELECT user_id FROM deleted)
+	)
+	DELETE FROM team_members WHERE user_id IN (SELECT user_id FROM deleted)`
+
+	_, err := conn(ctx, us.Db).ExecContext(ctx, stmt, time.Now(), id)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tr *UsersRepository) IsExist(ctx context.Context, id string) (bool, error) {
+	stmt := `SELECT EXISTS(SELECT user_id FROM users WHERE user_id = $1 AND deleted_at IS NULL)`
+
+	var isExist bool
+	if err := conn(ctx, tr.Db).QueryRowContext(ctx, stmt, id).Scan(&isExist); err != nil {
 		return false, err
 	}
 
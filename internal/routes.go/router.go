@@ -2,28 +2,170 @@ package routes
 
 import (
 	"net/http"
+	"slices"
 
+	"pr-service/internal/auth"
 	"pr-service/internal/handlers"
+	"pr-service/internal/openapi"
+	"pr-service/internal/ratelimit"
 )
 
-func NewRouter(teamsHandler handlers.ITeamsHandlers,
+// Route - маршрут в формате шаблонов ServeMux из Go 1.22: метод и путь с параметрами {name}
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+	// кто может вызывать маршрут
+	Access auth.Access
+	// область, которая нужна ключу API
+	Scope string
+}
+
+// Routes возвращает все маршруты сервиса: версионированные /api/v1 и старые RPC-пути,
+// которые остаются псевдонимами на время перехода клиентов.
+// Маршруты auth.Authenticated доступны всем с токеном, а права на конкретную команду,
+// пользователя или pr (свои pr, команды лида) проверяет сервисный слой
+func Routes(teamsHandler handlers.ITeamsHandlers,
 	usersHandler handlers.IUsersHandlers,
 	pullRequestsHandler handlers.IPullRequestsHandlers,
 	statsHandler handlers.IStatsHandlers,
+	importHandler handlers.IImportHandlers,
+	adminHandler handlers.IAdminHandlers,
+	apiKeysHandler handlers.IAPIKeysHandlers,
+	metricsHandler handlers.IMetricsHandlers,
+	healthHandler handlers.IHealthHandlers,
+) []Route {
+	return []Route{
+		{http.MethodPost, "/api/v1/teams", teamsHandler.AddTeam, auth.Authenticated, auth.ScopeTeamsWrite},
+		{http.MethodGet, "/api/v1/teams", teamsHandler.GetTeamTree, auth.AdminOnly, auth.ScopeTeamsRead},
+		{http.MethodGet, "/api/v1/teams/{name}", teamsHandler.GetTeamByName, auth.Authenticated, auth.ScopeTeamsRead},
+		{http.MethodGet, "/api/v1/teams/{name}/subtree", teamsHandler.GetTeamSubtree, auth.AdminOnly, auth.ScopeTeamsRead},
+		{http.MethodPut, "/api/v1/teams/{name}/parent", teamsHandler.SetParentByName, auth.AdminOnly, auth.ScopeTeamsWrite},
+		{http.MethodGet, "/api/v1/teams/{name}/leads", teamsHandler.GetTeamLeads, auth.Authenticated, auth.ScopeTeamsRead},
+		{http.MethodPut, "/api/v1/teams/{name}/leads/{user_id}", teamsHandler.AddTeamLead, auth.AdminOnly, auth.ScopeTeamsWrite},
+		{http.MethodDelete, "/api/v1/teams/{name}/leads/{user_id}", teamsHandler.RemoveTeamLead, auth.AdminOnly, auth.ScopeTeamsWrite},
+
+		{http.MethodPost, "/api/v1/pull-requests", pullRequestsHandler.AddPullRequest, auth.Authenticated, auth.ScopePullRequestsWrite},
+		{http.MethodPost, "/api/v1/pull-requests/{id}/merge", pullRequestsHandler.MergePullRequestById, auth.AdminOnly, auth.ScopePullRequestsWrite},
+		{http.MethodPost, "/api/v1/pull-requests/{id}/reassign", pullRequestsHandler.ReassignReviewerById, auth.Authenticated, auth.ScopePullRequestsWrite},
+
+		{http.MethodGet, "/api/v1/users/{id}", usersHandler.GetUserById, auth.AdminOnly, auth.ScopeUsersRead},
+		{http.MethodPatch, "/api/v1/users/{id}", usersHandler.UpdateUserById, auth.AdminOnly, auth.ScopeUsersWrite},
+		{http.MethodDelete, "/api/v1/users/{id}", usersHandler.DeleteUserById, auth.AdminOnly, auth.ScopeUsersWrite},
+		{http.MethodPut, "/api/v1/users/{id}/active", usersHandler.SetIsActiveByUserId, auth.Authenticated, auth.ScopeUsersWrite},
+		{http.MethodGet, "/api/v1/users/{id}/reviews", usersHandler.GetReviewByUserId, auth.Authenticated, auth.ScopeUsersRead},
+
+		{http.MethodGet, "/api/v1/stats", statsHandler.GetStats, auth.Authenticated, auth.ScopeStatsRead},
+
+		{http.MethodPost, "/api/v1/import", importHandler.Import, auth.AdminOnly, auth.ScopeAdmin},
+
+		{http.MethodGet, "/api/v1/admin/export", adminHandler.Export, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodPost, "/api/v1/admin/restore", adminHandler.Restore, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodPost, "/api/v1/admin/config/reload", adminHandler.ReloadConfig, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodPost, "/api/v1/admin/api-keys", apiKeysHandler.CreateKey, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodGet, "/api/v1/admin/api-keys", apiKeysHandler.ListKeys, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodDelete, "/api/v1/admin/api-keys/{id}", apiKeysHandler.RevokeKey, auth.AdminOnly, auth.ScopeAdmin},
+
+		{http.MethodGet, "/api/v1/openapi.json", openapi.ServeSpec, auth.Public, ""},
+
+		// путь без версии, как принято для Prometheus
+		{http.MethodGet, "/metrics", metricsHandler.GetMetrics, auth.AdminOnly, auth.ScopeMetricsRead},
+
+		// пробы оркестратора: жив ли процесс и готов ли он принимать трафик
+		{http.MethodGet, "/healthz", healthHandler.Liveness, auth.Public, ""},
+		{http.MethodGet, "/readyz", healthHandler.Readiness, auth.Public, ""},
+
+		// старые пути
+		{http.MethodPost, "/team/add", teamsHandler.AddTeam, auth.Authenticated, auth.ScopeTeamsWrite},
+		{http.MethodGet, "/team/get", teamsHandler.GetTeam, auth.Authenticated, auth.ScopeTeamsRead},
+		{http.MethodPost, "/team/setParent", teamsHandler.SetParent, auth.AdminOnly, auth.ScopeTeamsWrite},
+		{http.MethodGet, "/team/tree", teamsHandler.GetTeamTree, auth.AdminOnly, auth.ScopeTeamsRead},
+
+		{http.MethodPost, "/pullRequest/create", pullRequestsHandler.AddPullRequest, auth.Authenticated, auth.ScopePullRequestsWrite},
+		{http.MethodPost, "/pullRequest/merge", pullRequestsHandler.MergePullRequest, auth.AdminOnly, auth.ScopePullRequestsWrite},
+		{http.MethodPost, "/pullRequest/reassign", pullRequestsHandler.ReassignReviewer, auth.Authenticated, auth.ScopePullRequestsWrite},
+
+		{http.MethodGet, "/users/getReview", usersHandler.GetReview, auth.Authenticated, auth.ScopeUsersRead},
+		{http.MethodPost, "/users/setIsActive", usersHandler.SetIsActive, auth.Authenticated, auth.ScopeUsersWrite},
+		{http.MethodGet, "/users/get", usersHandler.GetUser, auth.AdminOnly, auth.ScopeUsersRead},
+		{http.MethodPost, "/users/update", usersHandler.UpdateUser, auth.AdminOnly, auth.ScopeUsersWrite},
+		{http.MethodPost, "/users/delete", usersHandler.DeleteUser, auth.AdminOnly, auth.ScopeUsersWrite},
+
+		{http.MethodGet, "/stats", statsHandler.GetStats, auth.Authenticated, auth.ScopeStatsRead},
+
+		{http.MethodPost, "/import", importHandler.Import, auth.AdminOnly, auth.ScopeAdmin},
+
+		{http.MethodGet, "/admin/export", adminHandler.Export, auth.AdminOnly, auth.ScopeAdmin},
+		{http.MethodPost, "/admin/restore", adminHandler.Restore, auth.AdminOnly, auth.ScopeAdmin},
+
+		{http.MethodGet, "/openapi.json", openapi.ServeSpec, auth.Public, ""},
+	}
+}
+
+// bulkRoutes принимают тела больше, чем читает openapi.ValidateRoute, и проверяют данные
+// сами: импорт сообщает об ошибках по строкам, восстановление - о нарушениях целостности снимка
+var bulkRoutes = map[string]bool{
+	"POST /api/v1/import":        true,
+	"POST /import":               true,
+	"POST /api/v1/admin/restore": true,
+	"POST /admin/restore":        true,
+}
+
+// NewRouter собирает маршруты с проверками. validateRequests включает проверку тел
+// запросов по схемам OpenAPI
+func NewRouter(authenticator auth.Authenticator,
+	limits *ratelimit.Limits,
+	timeouts *Timeouts,
+	validateRequests bool,
+	teamsHandler handlers.ITeamsHandlers,
+	usersHandler handlers.IUsersHandlers,
+	pullRequestsHandler handlers.IPullRequestsHandlers,
+	statsHandler handlers.IStatsHandlers,
+	importHandler handlers.IImportHandlers,
+	adminHandler handlers.IAdminHandlers,
+	apiKeysHandler handlers.IAPIKeysHandlers,
+	metricsHandler handlers.IMetricsHandlers,
+	healthHandler handlers.IHealthHandlers,
 ) *http.ServeMux {
 	router := http.NewServeMux()
 
-	router.HandleFunc("/team/add", teamsHandler.AddTeam)
-	router.HandleFunc("/team/get", teamsHandler.GetTeam)
+	routes := Routes(teamsHandler, usersHandler, pullRequestsHandler, statsHandler, importHandler, adminHandler, apiKeysHandler, metricsHandler, healthHandler)
+
+	// методы каждого пути в порядке объявления
+	paths := []string{}
+	allowed := map[string][]string{}
+	for _, route := range routes {
+		// тело проверяется последним: анонимные и отклоненные лимитом запросы его не читают
+		handler := route.Handler
+		if validateRequests && !bulkRoutes[route.Method+" "+route.Path] {
+			handler = openapi.ValidateRoute(route.Method, route.Path, handler)
+		}
 
-	router.HandleFunc("/pullRequest/create", pullRequestsHandler.AddPullRequest)
-	router.HandleFunc("/pullRequest/merge", pullRequestsHandler.MergePullRequest)
-	router.HandleFunc("/pullRequest/reassign", pullRequestsHandler.ReassignReviewer)
+		// лимит проверяется после аутентификации, чтобы считать запросы по клиенту,
+		// а неудачные аутентификации считаются до нее по IP адресу.
+		// Срок обработки распространяется и на проверку ключа API в БД
+		handler = limits.Middleware(route.Method, handler)
+		handler = auth.Middleware(authenticator, route.Access, route.Scope, handler)
+		if route.Access != auth.Public {
+			handler = limits.AuthMiddleware(handler)
+		}
+		router.HandleFunc(route.Method+" "+route.Path, timeouts.Middleware(route, handler))
 
-	router.HandleFunc("/users/getReview", usersHandler.GetReview)
-	router.HandleFunc("/users/setIsActive", usersHandler.SetIsActive)
+		if _, ok := allowed[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		allowed[route.Path] = append(allowed[route.Path], route.Method)
+	}
 
-	router.HandleFunc("/stats", statsHandler.GetStats)
+	// путь без метода совпадает с запросами, для метода которых нет обработчика.
+	// Шаблон GET также обслуживает HEAD, поэтому HEAD попадает в Allow
+	for _, path := range paths {
+		methods := allowed[path]
+		if slices.Contains(methods, http.MethodGet) {
+			methods = append(methods, http.MethodHead)
+		}
+		router.HandleFunc(path, handlers.MethodNotAllowed(methods))
+	}
 
 	return router
 }
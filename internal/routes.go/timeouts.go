@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// импорт и снимки обрабатывают всю БД, поэтому им по умолчанию дается больше времени
+var slowRoutes = map[string]time.Duration{
+	"POST /api/v1/import":        time.Minute,
+	"POST /import":               time.Minute,
+	"GET /api/v1/admin/export":   time.Minute,
+	"GET /admin/export":          time.Minute,
+	"POST /api/v1/admin/restore": time.Minute,
+	"POST /admin/restore":        time.Minute,
+}
+
+// Timeouts - сроки обработки запросов. По истечении срока контекст запроса отменяется
+// вместе с запросами к БД. Нулевой срок отключает ограничение
+type Timeouts struct {
+	Default time.Duration
+	// сроки отдельных маршрутов по ключу "METHOD /path", как в Route
+	Routes map[string]time.Duration
+}
+
+// NewTimeouts собирает общий срок и сроки маршрутов по ключу "METHOD /path" из
+// конфигурации. Сроки из конфигурации главнее сроков медленных маршрутов по умолчанию
+func NewTimeouts(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) *Timeouts {
+	timeouts := &Timeouts{
+		Default: defaultTimeout,
+		Routes:  maps.Clone(slowRoutes),
+	}
+	maps.Copy(timeouts.Routes, routeTimeouts)
+
+	return timeouts
+}
+
+// For возвращает срок обработки маршрута
+func (t *Timeouts) For(route Route) time.Duration {
+	if timeout, ok := t.Routes[route.Method+" "+route.Path]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// Middleware ограничивает время обработки запроса к маршруту. Отключение клиента
+// отменяет контекст запроса и без срока
+func (t *Timeouts) Middleware(route Route, next http.HandlerFunc) http.HandlerFunc {
+	if t == nil {
+		return next
+	}
+
+	timeout := t.For(route)
+	if timeout == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultAddr        = ":8080"
+	DefaultReadTimeout = 15 * time.Second
+	// запись ответа должна пережить самый долгий срок маршрута (импорт и снимки - минута)
+	DefaultWriteTimeout    = 90 * time.Second
+	DefaultIdleTimeout     = 2 * time.Minute
+	DefaultShutdownTimeout = 30 * time.Second
+	// срок обработки запроса маршрутом, если он не задан в конфигурации
+	DefaultRequestTimeout = 10 * time.Second
+
+	// заголовки читаются отдельно, чтобы медленный клиент не держал соединение
+	readHeaderTimeout = 5 * time.Second
+)
+
+// Options - адрес и сроки HTTP сервера
+type Options struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// DefaultOptions возвращает адрес и сроки по умолчанию
+func DefaultOptions() Options {
+	return Options{
+		Addr:            DefaultAddr,
+		ReadTimeout:     DefaultReadTimeout,
+		WriteTimeout:    DefaultWriteTimeout,
+		IdleTimeout:     DefaultIdleTimeout,
+		ShutdownTimeout: DefaultShutdownTimeout,
+	}
+}
+
+// New создает HTTP сервер с заданными сроками
+func New(opts *Options, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+	}
+}
+
+// Hook - фоновая задача, которую нужно остановить после того, как сервер
+// перестал принимать запросы
+type Hook struct {
+	Name string
+	Stop func(ctx context.Context) error
+}
+
+// Run слушает srv.Addr до отмены ctx, см. Serve
+func Run(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration, lgr *slog.Logger, hooks ...Hook) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, srv, listener, shutdownTimeout, lgr, hooks...)
+}
+
+// Serve обслуживает запросы до отмены ctx. После отмены сервер перестает принимать
+// соединения и ждет завершения начатых запросов не дольше shutdownTimeout, затем
+// закрывает оставшиеся соединения (контексты запросов отменяются, транзакции
+// откатываются) и останавливает hooks по порядку
+func Serve(ctx context.Context, srv *http.Server, listener net.Listener, shutdownTimeout time.Duration, lgr *slog.Logger, hooks ...Hook) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	lgr.With(
+		slog.String("addr", listener.Addr().String()),
+	).Info("Server is listening")
+
+	select {
+	case err := <-serveErr:
+		// сервер упал сам, фоновые задачи все равно останавливаем
+		stopHooks(shutdownTimeout, lgr, hooks)
+		return err
+	case <-ctx.Done():
+	}
+
+	lgr.Info("Shutting down, draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var errShutdown error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		lgr.With(
+			slog.String("error", err.Error()),
+		).Error("Failed to drain requests in time, closing connections")
+
+		errShutdown = err
+		srv.Close()
+	}
+
+	// Serve возвращает ErrServerClosed сразу после вызова Shutdown
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errShutdown = errors.Join(errShutdown, err)
+	}
+
+	stopHooks(shutdownTimeout, lgr, hooks)
+
+	lgr.Info("Server stopped")
+	return errShutdown
+}
+
+func stopHooks(timeout time.Duration, lgr *slog.Logger, hooks []Hook) {
+	for _, hook := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := hook.Stop(ctx)
+		cancel()
+
+		if err != nil {
+			lgr.With(
+				slog.String("hook", hook.Name),
+				slog.String("error", err.Error()),
+			).Error("Failed to stop background worker")
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/repository"
+)
+
+// restrictedPrincipal возвращает принципала, права которого нужно проверить.
+// nil - администратор или доверенный вызов без принципала в ctx (prctl, импорт, вызовы между сервисами)
+func restrictedPrincipal(ctx context.Context) *auth.Principal {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return nil
+	}
+
+	return principal
+}
+
+// isLeadOf проверяет, что пользователь - лид хотя бы одной из команд
+func isLeadOf(ctx context.Context, teamsRepository repository.ITeamsRepository, userId string, teamNames ...string) (bool, error) {
+	ledTeams, err := teamsRepository.GetLedTeams(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, teamName := range teamNames {
+		if slices.Contains(ledTeams, teamName) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// authorizeAdmin пропускает только доверенные вызовы и администратора
+func authorizeAdmin(ctx context.Context, lgr *slog.Logger) error {
+	principal := restrictedPrincipal(ctx)
+	if principal == nil {
+		return nil
+	}
+
+	lgr.With(
+		slog.String("user_id", principal.UserId),
+	).Warn("operation is allowed only for admins")
+	return ErrForbidden
+}
+
+// authorizeLead пропускает доверенные вызовы, администратора и лида одной из команд
+func authorizeLead(ctx context.Context, teamsRepository repository.ITeamsRepository, lgr *slog.Logger, teamNames ...string) error {
+	principal := restrictedPrincipal(ctx)
+	if principal == nil {
+		return nil
+	}
+
+	isLead, err := isLeadOf(ctx, teamsRepository, principal.UserId, teamNames...)
+	if err != nil {
+		lgr.With(
+			slog.String("user_id", principal.UserId),
+			slog.String("error", err.Error()),
+		).Error("failed to get teams led by user")
+		return err
+	}
+
+	if !isLead {
+		lgr.With(
+			slog.String("user_id", principal.UserId),
+		).Warn("user is not a lead of the team")
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/logging"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
+)
+
+const (
+	// по префиксу ключи API отличаются от JWT и статических токенов без обращения к БД
+	apiKeyTokenPrefix = "prs_"
+	// сколько символов ключа хранится открыто для опознания
+	apiKeyVisiblePrefix = len(apiKeyTokenPrefix) + 8
+	// last_used_at обновляется не чаще этого интервала
+	apiKeyTouchInterval = time.Minute
+)
+
+type IAPIKeysService interface {
+	CreateKey(ctx context.Context, requestDTO *dto.RequestCreateAPIKeyDTO) (*dto.ResponseCreateAPIKeyDTO, error)
+	ListKeys(ctx context.Context) (*dto.ResponseAPIKeysDTO, error)
+	RevokeKey(ctx context.Context, keyId int) (*dto.APIKeyDTO, error)
+}
+
+// APIKeysService управляет ключами API и проверяет их как auth.Authenticator
+type APIKeysService struct {
+	APIKeysRepository repository.IAPIKeysRepository
+	Lgr               *slog.Logger
+}
+
+func (as *APIKeysService) CreateKey(ctx context.Context, requestDTO *dto.RequestCreateAPIKeyDTO) (*dto.ResponseCreateAPIKeyDTO, error) {
+	ctx, span := tracing.Start(ctx, "APIKeysService.CreateKey")
+	defer span.End()
+
+	as.logger(ctx).With(
+		slog.String("name", requestDTO.Name),
+	).Info("creating api key")
+
+	if err := authorizeAdmin(ctx, as.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token := apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &models.APIKeyModel{
+		Name:      requestDTO.Name,
+		Prefix:    token[:apiKeyVisiblePrefix],
+		KeyHash:   hashAPIKey(token),
+		Scopes:    requestDTO.Scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: requestDTO.ExpiresAt,
+	}
+
+	keyId, err := as.APIKeysRepository.AddKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicatedKeyName) {
+			return nil, ErrAPIKeyExists
+		}
+		as.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to add api key")
+		return nil, err
+	}
+	key.KeyId = keyId
+
+	as.logger(ctx).With(
+		slog.Int("key_id", keyId),
+		slog.String("prefix", key.Prefix),
+	).Info("api key created")
+
+	return &dto.ResponseCreateAPIKeyDTO{
+		Key:   newAPIKey(key),
+		Token: token,
+	}, nil
+}
+
+func (as *APIKeysService) ListKeys(ctx context.Context) (*dto.ResponseAPIKeysDTO, error) {
+	ctx, span := tracing.Start(ctx, "APIKeysService.ListKeys")
+	defer span.End()
+
+	if err := authorizeAdmin(ctx, as.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	keys, err := as.APIKeysRepository.GetKeys(ctx)
+	if err != nil {
+		as.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get api keys")
+		return nil, err
+	}
+
+	responseDTO := &dto.ResponseAPIKeysDTO{
+		Keys: make([]*dto.APIKeyDTO, 0, len(keys)),
+	}
+	for _, key := range keys {
+		responseDTO.Keys = append(responseDTO.Keys, newAPIKey(key))
+	}
+
+	return responseDTO, nil
+}
+
+func (as *APIKeysService) RevokeKey(ctx context.Context, keyId int) (*dto.APIKeyDTO, error) {
+	ctx, span := tracing.Start(ctx, "APIKeysService.RevokeKey")
+	defer span.End()
+
+	as.logger(ctx).With(
+		slog.Int("key_id", keyId),
+	).Info("revoking api key")
+
+	if err := authorizeAdmin(ctx, as.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	key, err := as.APIKeysRepository.RevokeKey(ctx, keyId, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRecord) {
+			return nil, ErrNoResourse
+		}
+		as.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to revoke api key")
+		return nil, err
+	}
+
+	return newAPIKey(key), nil
+}
+
+// Authenticate проверяет ключ API. Ключ действует от имени администратора,
+// но только в пределах своих областей, которые проверяет auth.Middleware
+func (as *APIKeysService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "APIKeysService.Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(token, apiKeyTokenPrefix) {
+		return nil, auth.ErrInvalidToken
+	}
+
+	key, err := as.APIKeysRepository.GetKeyByHash(ctx, hashAPIKey(token))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNoRecord) {
+			as.Lgr.With(
+				slog.String("error", err.Error()),
+			).Error("failed to get api key")
+		}
+		return nil, auth.ErrInvalidToken
+	}
+
+	if key.RevokedAt != nil {
+		return nil, auth.ErrInvalidToken
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, auth.ErrTokenExpired
+	}
+
+	// ошибка записи last_used_at не должна мешать запросу
+	if err := as.APIKeysRepository.TouchKey(ctx, key.KeyId, now, apiKeyTouchInterval); err != nil {
+		as.Lgr.With(
+			slog.Int("key_id", key.KeyId),
+			slog.String("error", err.Error()),
+		).Error("failed to update api key last usage")
+	}
+
+	return &auth.Principal{
+		Role:    auth.RoleAdmin,
+		KeyName: key.Name,
+		Scopes:  key.Scopes,
+	}, nil
+}
+
+func hashAPIKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func newAPIKey(key *models.APIKeyModel) *dto.APIKeyDTO {
+	return &dto.APIKeyDTO{
+		KeyId:      key.KeyId,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func (as *APIKeysService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, as.Lgr)
+}
@@ -1,6 +1,9 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrNoReviewrs         = errors.New("pr doesn't have reviewers")
@@ -11,4 +14,33 @@ var (
 	ErrTeamExists         = errors.New("team with this id exists")
 	ErrPRExists           = errors.New("pr with this id exists")
 	ErrNoResourse         = errors.New("resourse doesn't exist")
+	ErrDuplicatedMember   = errors.New("member is listed more than once")
+	ErrHasOpenReviews     = errors.New("user has open reviews")
+	ErrTeamCycle          = errors.New("team hierarchy cycle")
+	ErrWrongImportFile    = errors.New("import file can't be parsed")
+	ErrDatabaseNotEmpty   = errors.New("database is not empty")
+	ErrInvalidSnapshot    = errors.New("snapshot is invalid")
+	ErrForbidden          = errors.New("not enough permissions")
+	ErrAPIKeyExists       = errors.New("active api key with this name exists")
+)
+
+// ошибки, которыми сервис откатывает транзакцию WithTx, наружу не возвращаются
+var (
+	// синхронизация команды в режиме dry-run
+	errDryRun = errors.New("dry run")
+	// импорт с ошибками строк в режиме atomic
+	errImportRolledBack = errors.New("import rolled back")
 )
+
+// SnapshotValidationError перечисляет все нарушения целостности в снимке данных
+type SnapshotValidationError struct {
+	Problems []string
+}
+
+func (e *SnapshotValidationError) Error() string {
+	return ErrInvalidSnapshot.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+func (e *SnapshotValidationError) Unwrap() error {
+	return ErrInvalidSnapshot
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/logging"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
+	"pr-service/internal/validators"
+)
+
+// форматы файла импорта
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// режимы импорта: все или ничего либо все корректные строки
+const (
+	ImportModeAtomic     = "atomic"
+	ImportModeBestEffort = "best_effort"
+)
+
+// savepoint для отката одной строки импорта
+const importRowSavepoint = "import_row"
+
+type IImportService interface {
+	Import(ctx context.Context, format, mode string, data io.Reader) (*dto.ResponseImportDTO, error)
+}
+
+type ImportService struct {
+	TeamsService    *TeamsService
+	TeamsRepository repository.ITeamsRepository
+	UsersRepository repository.IUsersRepository
+	Transactor      repository.ITransactor
+	Lgr             *slog.Logger
+}
+
+// Import загружает команды и пользователей из CSV или JSON в одной транзакции
+func (is *ImportService) Import(ctx context.Context, format, mode string, data io.Reader) (*dto.ResponseImportDTO, error) {
+	ctx, span := tracing.Start(ctx, "ImportService.Import")
+	defer span.End()
+
+	is.logger(ctx).With(
+		slog.String("format", format),
+		slog.String("mode", mode),
+	).Info("starting import")
+
+	if mode != ImportModeAtomic && mode != ImportModeBestEffort {
+		is.logger(ctx).With(
+			slog.String("mode", mode),
+		).Warn("unknown import mode")
+		return nil, ErrWrongImportFile
+	}
+
+	rows, rowErrors, err := parseImportRows(format, data)
+	if err != nil {
+		is.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Warn("failed to parse import file")
+		return nil, errors.Join(ErrWrongImportFile, err)
+	}
+
+	var report *dto.ResponseImportDTO
+	// строки с родительскими командами проверяются на циклы так же, как в SetParentTeam,
+	// поэтому импорт тоже выполняется в SERIALIZABLE
+	err = is.Transactor.WithTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context) error {
+		// при повторе транзакции отчет собирается заново
+		report = &dto.ResponseImportDTO{
+			Mode:      mode,
+			TotalRows: len(rows) + len(rowErrors),
+			Errors:    slices.Clone(rowErrors),
+		}
+
+		for _, row := range rows {
+			rowError, err := is.importRow(ctx, row, report)
+			if err != nil {
+				is.logger(ctx).With(
+					slog.Int("row", row.Row),
+					slog.String("error", err.Error()),
+				).Error("failed to import row")
+				return err
+			}
+
+			if rowError != nil {
+				is.logger(ctx).With(
+					slog.Int("row", row.Row),
+					slog.String("code", rowError.Code),
+				).Warn("import row was skipped")
+				report.Errors = append(report.Errors, rowError)
+				continue
+			}
+
+			report.ImportedRows++
+		}
+
+		// ошибки разбора и применения строк возвращаются по порядку строк
+		slices.SortStableFunc(report.Errors, func(a, b *dto.ImportRowErrorDTO) int {
+			return a.Row - b.Row
+		})
+
+		// в режиме "все или ничего" любая ошибка отменяет импорт
+		if mode == ImportModeAtomic && len(report.Errors) != 0 {
+			return errImportRolledBack
+		}
+
+		return nil
+	})
+	if errors.Is(err, errImportRolledBack) {
+		is.logger(ctx).With(
+			slog.Int("errors", len(report.Errors)),
+		).Warn("import was rolled back due to row errors")
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = true
+
+	is.logger(ctx).With(
+		slog.Int("imported_rows", report.ImportedRows),
+		slog.Int("errors", len(report.Errors)),
+	).Info("import completed successfully")
+
+	return report, nil
+}
+
+// importRow применяет одну строку внутри savepoint. Ошибки данных возвращаются
+// как ошибка строки, остальные ошибки прерывают весь импорт
+func (is *ImportService) importRow(ctx context.Context, row *dto.ImportRowDTO, report *dto.ResponseImportDTO) (*dto.ImportRowErrorDTO, error) {
+	validator := validators.NewValidator()
+	validator.ValidateTeamName(row.TeamName)
+	if row.ParentTeamName != "" {
+		validator.ValidateTeamName(row.ParentTeamName)
+	}
+	if row.UserId != "" {
+		validator.ValidateUserId(row.UserId)
+		validator.ValidateUsername(row.Username)
+	}
+	if !validator.GetIsValid() {
+		return newImportRowError(row.Row, "WRONG_DATA_INPUT", "wrong format of input data"), nil
+	}
+
+	if err := repository.Savepoint(ctx, importRowSavepoint); err != nil {
+		return nil, err
+	}
+
+	// счетчики применяются только после успешной строки
+	var teamsCreated, usersCreated, usersUpdated, membershipsAdded int
+	rowError, err := func() (*dto.ImportRowErrorDTO, error) {
+		created, err := is.TeamsRepository.AddTeamIfNotExists(ctx, row.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			teamsCreated++
+		}
+
+		// родительская команда создается, если ее еще нет
+		if row.ParentTeamName != "" {
+			created, err := is.TeamsRepository.AddTeamIfNotExists(ctx, row.ParentTeamName)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				teamsCreated++
+			}
+
+			if err := is.TeamsService.setParent(ctx, row.TeamName, row.ParentTeamName); err != nil {
+				if errors.Is(err, ErrTeamCycle) {
+					return newImportRowError(row.Row, "TEAM_CYCLE", "team can't be a descendant of itself"), nil
+				}
+				return nil, err
+			}
+		}
+
+		// строка без пользователя описывает только команду
+		if row.UserId == "" {
+			return nil, nil
+		}
+
+		existing, err := is.UsersRepository.GetUserById(ctx, row.UserId)
+		if err != nil && !errors.Is(err, repository.ErrNoRecord) {
+			return nil, err
+		}
+
+		// новый пользователь получает команду строки основной
+		if errors.Is(err, repository.ErrNoRecord) {
+			member := &dto.TeamMemberDTO{
+				UserId:   row.UserId,
+				Username: row.Username,
+				IsActive: row.IsActive,
+			}
+			if err := is.TeamsService.addMember(ctx, row.TeamName, member); err != nil {
+				if errors.Is(err, ErrUserExists) {
+					return newImportRowError(row.Row, "USER_EXISTS", "user_id already exists"), nil
+				}
+				return nil, err
+			}
+			usersCreated++
+			return nil, nil
+		}
+
+		// существующий пользователь добавляется в команду строки
+		added, err := is.UsersRepository.AddMembership(ctx, row.TeamName, row.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			membershipsAdded++
+		}
+
+		// имя и активность берутся из файла, как при синхронизации команды через /team/add,
+		// а поля профиля остаются прежними
+		user := &models.UserModel{
+			Id:       existing.Id,
+			Username: row.Username,
+			IsActive: row.IsActive,
+			TeamName: existing.TeamName,
+			Email:    existing.Email,
+			Handles:  existing.Handles,
+		}
+		if len(userChanges(existing, user)) == 0 {
+			return nil, nil
+		}
+
+		if err := is.UsersRepository.UpdateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		usersUpdated++
+
+		return nil, nil
+	}()
+
+	if err != nil || rowError != nil {
+		if errRollback := repository.RollbackToSavepoint(ctx, importRowSavepoint); errRollback != nil {
+			return nil, errors.Join(err, errRollback)
+		}
+		return rowError, err
+	}
+
+	if err := repository.ReleaseSavepoint(ctx, importRowSavepoint); err != nil {
+		return nil, err
+	}
+
+	report.TeamsCreated += teamsCreated
+	report.UsersCreated += usersCreated
+	report.UsersUpdated += usersUpdated
+	report.MembershipsAdded += membershipsAdded
+
+	return nil, nil
+}
+
+// parseImportRows разбирает файл импорта в строки. Строки, которые не удалось
+// разобрать, возвращаются отдельно как ошибки строк
+func parseImportRows(format string, data io.Reader) ([]*dto.ImportRowDTO, []*dto.ImportRowErrorDTO, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(data)
+	case ImportFormatJSON:
+		rows, err := parseImportJSON(data)
+		return rows, []*dto.ImportRowErrorDTO{}, err
+	default:
+		return nil, nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+// parseImportCSV ожидает заголовок с колонкой team_name и необязательными
+// parent_team_name, user_id, username, is_active. Номер строки совпадает с номером строки файла
+func parseImportCSV(data io.Reader) ([]*dto.ImportRowDTO, []*dto.ImportRowErrorDTO, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	if _, ok := columns["team_name"]; !ok {
+		return nil, nil, errors.New("csv header must contain team_name column")
+	}
+
+	// значение колонки или пустая строка, если колонки нет
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []*dto.ImportRowDTO{}
+	rowErrors := []*dto.ImportRowErrorDTO{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read csv line %d: %w", line, err)
+		}
+
+		row := &dto.ImportRowDTO{
+			Row:            line,
+			TeamName:       field(record, "team_name"),
+			ParentTeamName: field(record, "parent_team_name"),
+			UserId:         field(record, "user_id"),
+			Username:       field(record, "username"),
+			IsActive:       true,
+		}
+
+		// по умолчанию пользователь активен
+		if rawIsActive := field(record, "is_active"); rawIsActive != "" {
+			isActive, err := strconv.ParseBool(rawIsActive)
+			if err != nil {
+				rowErrors = append(rowErrors, newImportRowError(line, "WRONG_DATA_INPUT", "is_active must be a boolean"))
+				continue
+			}
+			row.IsActive = isActive
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// parseImportJSON превращает каждую команду и каждого ее участника в отдельную строку,
+// строки нумеруются с 1 в порядке следования в файле
+func parseImportJSON(data io.Reader) ([]*dto.ImportRowDTO, error) {
+	var requestDTO dto.RequestImportJSONDTO
+	if err := json.NewDecoder(data).Decode(&requestDTO); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	rows := []*dto.ImportRowDTO{}
+	for _, team := range requestDTO.Teams {
+		rows = append(rows, &dto.ImportRowDTO{
+			Row:            len(rows) + 1,
+			TeamName:       team.TeamName,
+			ParentTeamName: team.ParentTeamName,
+		})
+
+		for _, member := range team.Members {
+			// по умолчанию пользователь активен
+			isActive := true
+			if member.IsActive != nil {
+				isActive = *member.IsActive
+			}
+
+			rows = append(rows, &dto.ImportRowDTO{
+				Row:      len(rows) + 1,
+				TeamName: team.TeamName,
+				UserId:   member.UserId,
+				Username: member.Username,
+				IsActive: isActive,
+			})
+		}
+	}
+
+	return rows, nil
+}
+
+func newImportRowError(row int, code, message string) *dto.ImportRowErrorDTO {
+	return &dto.ImportRowErrorDTO{
+		Row:     row,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (is *ImportService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, is.Lgr)
+}
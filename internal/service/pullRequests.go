@@ -1,60 +1,122 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"math/rand/v2"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"pr-service/internal/dto"
 	"pr-service/internal/enums"
+	"pr-service/internal/logging"
+	"pr-service/internal/metrics"
 	"pr-service/internal/models"
 	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
 )
 
 type IPullRequestsService interface {
-	AddPullRequest(reqPullRequest *dto.RequestPullrequestDTO) (*dto.ResponsePullrequestDTO, error)
-	MergePullRequest(id string) (*dto.ResponseMergedPullRequestDTO, error)
-	ReassignReviewer(requestReassignDTO *dto.RequestReassignDTO) (*dto.ResponseReassignDTO, error)
+	AddPullRequest(ctx context.Context, reqPullRequest *dto.RequestPullrequestDTO) (*dto.ResponsePullrequestDTO, error)
+	MergePullRequest(ctx context.Context, id string) (*dto.ResponseMergedPullRequestDTO, error)
+	ReassignReviewer(ctx context.Context, requestReassignDTO *dto.RequestReassignDTO) (*dto.ResponseReassignDTO, error)
+}
+
+// AssignmentPolicy - правила назначения ревьюверов
+type AssignmentPolicy struct {
+	// сколько ревьюверов назначается на новый pr
+	Reviewers int
+	// добирать недостающих ревьюверов из родительских команд
+	EscalateToParent bool
+}
+
+// DefaultAssignmentPolicy - два ревьювера, при нехватке - из родительских команд
+var DefaultAssignmentPolicy = AssignmentPolicy{
+	Reviewers:        2,
+	EscalateToParent: true,
+}
+
+// AssignmentPolicies хранит действующую политику назначения, ее можно заменить без перезапуска
+type AssignmentPolicies struct {
+	current atomic.Pointer[AssignmentPolicy]
+}
+
+func NewAssignmentPolicies(policy AssignmentPolicy) *AssignmentPolicies {
+	policies := &AssignmentPolicies{}
+	policies.Set(policy)
+	return policies
+}
+
+// Get возвращает действующую политику, для nil - DefaultAssignmentPolicy
+func (ap *AssignmentPolicies) Get() AssignmentPolicy {
+	if ap == nil {
+		return DefaultAssignmentPolicy
+	}
+	return *ap.current.Load()
+}
+
+func (ap *AssignmentPolicies) Set(policy AssignmentPolicy) {
+	ap.current.Store(&policy)
 }
 
 type PullRequestsService struct {
 	PullRequestsRepository repository.IPullRequestsRepository
 	UsersRepository        repository.IUsersRepository
+	TeamsRepository        repository.ITeamsRepository
 	ReviewersRepository    repository.IReviewersRepository
-	Lgr                    *slog.Logger
+	EventsRepository       repository.IEventsRepository
+	Transactor             repository.ITransactor
+	// счетчики назначений для /metrics, nil отключает учет
+	Metrics *metrics.Reviews
+	// nil - DefaultAssignmentPolicy
+	Policy *AssignmentPolicies
+	Lgr    *slog.Logger
 }
 
-func (ps *PullRequestsService) AddPullRequest(reqPullRequest *dto.RequestPullrequestDTO) (*dto.ResponsePullrequestDTO, error) {
-	ps.Lgr.Info("starting pull request creation")
+func (ps *PullRequestsService) AddPullRequest(ctx context.Context, reqPullRequest *dto.RequestPullrequestDTO) (*dto.ResponsePullrequestDTO, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestsService.AddPullRequest")
+	defer span.End()
 
-	// начало транзакции, так как добавляем pr и его ревьюверов
-	tx, err := ps.PullRequestsRepository.GetDB().Begin()
+	ps.logger(ctx).Info("starting pull request creation")
+
+	// обычный пользователь создает pr только от своего имени
+	if principal := restrictedPrincipal(ctx); principal != nil && principal.UserId != reqPullRequest.AuthorID {
+		ps.logger(ctx).With(
+			slog.String("user_id", principal.UserId),
+		).Warn("user can create pull requests only as author")
+		return nil, ErrForbidden
+	}
+
+	// политика читается один раз: перезагрузка настроек посреди создания или при повторе
+	// транзакции не должна смешать число ревьюверов одной версии с эскалацией другой
+	policy := ps.policy()
+
+	// pr, его ревьюверы и события назначения добавляются одной транзакцией
+	var responseDTO *dto.ResponsePullrequestDTO
+	err := ps.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		responseDTO, err = ps.addPullRequest(ctx, policy, reqPullRequest)
+		return err
+	})
 	if err != nil {
-		ps.Lgr.With(
-			slog.String("error", err.Error()),
-		).Error("failed to begin transaction")
 		return nil, err
 	}
 
-	// если возникла ошибка в сервисной функции
-	defer func() {
-		if err != nil {
-			ps.Lgr.With(
-				slog.String("error", err.Error()),
-			).Error("rolling back transaction due to error")
-			if errRollback := tx.Rollback(); errRollback != nil {
-				ps.Lgr.With(
-					slog.String("error", errRollback.Error()),
-				).Error("rolling back was failed")
-			}
-		}
-	}()
+	// вызов может быть частью внешней транзакции, тогда метрики учитываются после ее коммита
+	repository.AfterCommit(ctx, func() { ps.Metrics.Assigned(len(responseDTO.PR.AssignedReviewers)) })
+
+	ps.logger(ctx).Info("pull request creation completed successfully")
+
+	return responseDTO, nil
+}
 
+func (ps *PullRequestsService) addPullRequest(ctx context.Context, policy AssignmentPolicy, reqPullRequest *dto.RequestPullrequestDTO) (*dto.ResponsePullrequestDTO, error) {
 	// проверяем наличие автора
-	author, err := ps.UsersRepository.GetUserById(tx, reqPullRequest.AuthorID)
+	author, err := ps.UsersRepository.GetUserById(ctx, reqPullRequest.AuthorID)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("author_id", reqPullRequest.AuthorID),
 			slog.String("error", err.Error()),
 		).Error("author not found")
@@ -64,24 +126,32 @@ func (ps *PullRequestsService) AddPullRequest(reqPullRequest *dto.RequestPullreq
 		return nil, err
 	}
 
-	// пользователи в команде автора
-	users, err := ps.UsersRepository.GetUsersByTeam(tx, author.TeamName)
-	if err != nil {
-		ps.Lgr.With(
-			slog.String("team", author.TeamName),
-			slog.String("error", err.Error()),
-		).Error("failed to get team users")
-		return nil, err
-	}
+	// ревьюверы выбираются из переданной команды или из основной команды автора
+	reviewTeam := author.TeamName
+	if reqPullRequest.TeamName != "" {
+		isExists, err := ps.TeamsRepository.IsExist(ctx, reqPullRequest.TeamName)
+		if err != nil {
+			ps.logger(ctx).With(
+				slog.String("team", reqPullRequest.TeamName),
+				slog.String("error", err.Error()),
+			).Error("failed to check team existence")
+			return nil, err
+		}
 
-	// удаляем автора из пользователей команды и всех неактивынх юзеров
-	availableReviewers := []*models.UserModel{}
-	for i := 0; i != len(users); i++ {
-		if users[i].Id == author.Id || !users[i].IsActive {
-			continue
+		if !isExists {
+			ps.logger(ctx).With(
+				slog.String("team", reqPullRequest.TeamName),
+			).Warn("team not found")
+			return nil, ErrNoResourse
 		}
 
-		availableReviewers = append(availableReviewers, users[i])
+		reviewTeam = reqPullRequest.TeamName
+	}
+
+	// выбираем ревьюверов по политике назначения, автор не может ревьювить свой PR
+	newReviewrIds, err := ps.pickReviewers(ctx, policy, reviewTeam, policy.Reviewers, map[string]bool{author.Id: true})
+	if err != nil {
+		return nil, err
 	}
 
 	// добавляем pr
@@ -89,11 +159,12 @@ func (ps *PullRequestsService) AddPullRequest(reqPullRequest *dto.RequestPullreq
 		PullRequestId:   reqPullRequest.PullRequestId,
 		PullRequestName: reqPullRequest.PullRequestName,
 		AuthorID:        reqPullRequest.AuthorID,
+		TeamName:        reviewTeam,
 		CreatedAt:       time.Now(),
 	}
 
-	if err := ps.PullRequestsRepository.AddPullRequest(tx, pullRequestModel); err != nil {
-		ps.Lgr.With(
+	if err := ps.PullRequestsRepository.AddPullRequest(ctx, pullRequestModel); err != nil {
+		ps.logger(ctx).With(
 			slog.String("PullRequestId", pullRequestModel.PullRequestId),
 			slog.String("error", err.Error()),
 		).Error("failed to add pull request")
@@ -103,24 +174,6 @@ func (ps *PullRequestsService) AddPullRequest(reqPullRequest *dto.RequestPullreq
 		return nil, err
 	}
 
-	// выбираем id reviewr
-	newReviewrIds := []string{}
-	if len(availableReviewers) >= 2 {
-		// Выбираем первого ревьювера
-		currentReviewerIndex := rand.IntN(len(availableReviewers))
-		newReviewrIds = append(newReviewrIds, availableReviewers[currentReviewerIndex].Id)
-
-		// убираем выбранного ревьювера
-		availableReviewers = append(availableReviewers[:currentReviewerIndex], availableReviewers[currentReviewerIndex+1:]...)
-
-		// Выбираем второго ревьювера
-		currentReviewerIndex = rand.IntN(len(availableReviewers))
-		newReviewrIds = append(newReviewrIds, availableReviewers[currentReviewerIndex].Id)
-	} else if len(availableReviewers) == 1 {
-		currentReviewerIndex := rand.IntN(len(availableReviewers))
-		newReviewrIds = append(newReviewrIds, availableReviewers[currentReviewerIndex].Id)
-	}
-
 	// добавляем reviwers
 	for _, id := range newReviewrIds {
 		reviwerModel := &models.ReviewerModel{
@@ -128,85 +181,110 @@ func (ps *PullRequestsService) AddPullRequest(reqPullRequest *dto.RequestPullreq
 			PullRequestId: reqPullRequest.PullRequestId,
 		}
 
-		if err := ps.ReviewersRepository.AddReviewer(tx, reviwerModel); err != nil {
-			ps.Lgr.With(
+		if err := ps.ReviewersRepository.AddReviewer(ctx, reviwerModel); err != nil {
+			ps.logger(ctx).With(
 				slog.String("userId", reviwerModel.UserId),
 				slog.String("error", err.Error()),
 			).Error("failed to add reviewers for pull request")
 			return nil, err
 		}
+
+		if err := ps.addEvent(ctx, reqPullRequest.PullRequestId, enums.EVENT_ASSIGNED, id, ""); err != nil {
+			return nil, err
+		}
 	}
 
 	responseDTO := &dto.ResponsePullrequestDTO{
 		PR: dto.NewPullRequestDTO(reqPullRequest.PullRequestId, reqPullRequest.PullRequestName, reqPullRequest.AuthorID, newReviewrIds...),
 	}
+	responseDTO.PR.TeamName = reviewTeam
+
+	return responseDTO, nil
+}
 
-	// завершаем транзакцию
-	err = tx.Commit()
+func (ps *PullRequestsService) MergePullRequest(ctx context.Context, id string) (*dto.ResponseMergedPullRequestDTO, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestsService.MergePullRequest")
+	defer span.End()
+
+	ps.logger(ctx).With().Info("starting merge a pull request")
+
+	// проверки, смена статуса и событие MERGED выполняются одной транзакцией
+	var responseDTO *dto.ResponseMergedPullRequestDTO
+	var merged bool
+	err := ps.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		responseDTO, merged, err = ps.mergePullRequest(ctx, id)
+		return err
+	})
 	if err != nil {
-		ps.Lgr.With(
-			slog.String("error", err.Error()),
-		).Error("failed to commit transaction")
 		return nil, err
 	}
 
-	ps.Lgr.Info("pull request creation completed successfully")
+	// повторный merge уже слитого pr в метриках не учитывается
+	if merged {
+		repository.AfterCommit(ctx, ps.Metrics.Merged)
+	}
+
+	ps.logger(ctx).Info("pull request merge operation completed")
 
 	return responseDTO, nil
 }
 
-func (ps *PullRequestsService) MergePullRequest(id string) (*dto.ResponseMergedPullRequestDTO, error) {
-	ps.Lgr.With().Info("starting merge a pull request")
-
-	// проверяем наличие pr
-	pullRequestModel, err := ps.PullRequestsRepository.GetPullRequestById(id)
+// mergePullRequest возвращает true вторым значением, если pr слит этим вызовом
+func (ps *PullRequestsService) mergePullRequest(ctx context.Context, id string) (*dto.ResponseMergedPullRequestDTO, bool, error) {
+	// проверяем наличие pr. Строка pr блокируется до конца транзакции, поэтому merge
+	// и переназначения одного pr выполняются по очереди и видят результат предыдущих
+	pullRequestModel, err := ps.PullRequestsRepository.GetPullRequestByIdForUpdate(ctx, id)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("pull request not found")
 		if errors.Is(err, repository.ErrNoRecord) {
-			return nil, ErrNoResourse
+			return nil, false, ErrNoResourse
 		}
-		return nil, err
+		return nil, false, err
 	}
 
 	// проверяем наличие ревьюверов у pr
-	reviewersIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(id)
+	reviewersIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(ctx, id)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to get reviewers list")
-		return nil, err
+		return nil, false, err
 	}
 
 	// если нет ревьювера, то не можем замержить
 	if len(reviewersIds) == 0 {
-		ps.Lgr.With().Warn("pr doesn't have reviewers")
-		return nil, ErrNoReviewrs
+		ps.logger(ctx).With().Warn("pr doesn't have reviewers")
+		return nil, false, ErrNoReviewrs
 	}
 
 	// проверяем статус Pr до обращения к репозиторию
-	if pullRequestModel.Status != enums.MERGED {
+	merged := pullRequestModel.Status != enums.MERGED
+	if merged {
 		mergedAt := time.Now()
-		if err := ps.PullRequestsRepository.MergePullRequest(mergedAt, id); err != nil {
-			ps.Lgr.With(
+		if err := ps.PullRequestsRepository.MergePullRequest(ctx, mergedAt, id); err != nil {
+			ps.logger(ctx).With(
 				slog.String("error", err.Error()),
 			).Error("failed to merge pr")
-			return nil, err
+			return nil, false, err
+		}
+
+		if err := ps.addEvent(ctx, id, enums.EVENT_MERGED, "", ""); err != nil {
+			return nil, false, err
 		}
 
 		// обновлдяем модель для merged_at
-		pullRequestModel, err = ps.PullRequestsRepository.GetPullRequestById(id)
+		pullRequestModel, err = ps.PullRequestsRepository.GetPullRequestById(ctx, id)
 		if err != nil {
-			ps.Lgr.With(
+			ps.logger(ctx).With(
 				slog.String("error", err.Error()),
 			).Error("failed to get merged pr")
-			return nil, err
+			return nil, false, err
 		}
 	}
 
-	ps.Lgr.Info("pull request merge operation completed")
-
 	return &dto.ResponseMergedPullRequestDTO{
 		PR: &dto.MergedPullRequestDTO{
 			PullRequestId:     pullRequestModel.PullRequestId,
@@ -216,50 +294,88 @@ func (ps *PullRequestsService) MergePullRequest(id string) (*dto.ResponseMergedP
 			AssignedReviewers: reviewersIds,
 			MergedAt:          *pullRequestModel.MergedAt,
 		},
-	}, nil
+	}, merged, nil
 }
 
-func (ps *PullRequestsService) ReassignReviewer(requestReassignDTO *dto.RequestReassignDTO) (*dto.ResponseReassignDTO, error) {
-	ps.Lgr.Info("starting reviewer reassignment")
+func (ps *PullRequestsService) ReassignReviewer(ctx context.Context, requestReassignDTO *dto.RequestReassignDTO) (*dto.ResponseReassignDTO, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestsService.ReassignReviewer")
+	defer span.End()
 
-	// проверяем наличие pr
-	pullRequestModel, err := ps.PullRequestsRepository.GetPullRequestById(requestReassignDTO.PullRequestId)
+	ps.logger(ctx).Info("starting reviewer reassignment")
+
+	// замена ревьювера и событие истории выполняются одной транзакцией
+	var responseDTO *dto.ResponseReassignDTO
+	var firstAssignment bool
+	err := ps.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		responseDTO, firstAssignment, err = ps.reassignReviewer(ctx, requestReassignDTO)
+		return err
+	})
 	if err != nil {
-		ps.Lgr.With(
+		if errors.Is(err, ErrNoReviewrsToAssign) {
+			ps.Metrics.NoCandidate()
+		}
+		return nil, err
+	}
+
+	if firstAssignment {
+		repository.AfterCommit(ctx, func() { ps.Metrics.Assigned(1) })
+	} else {
+		repository.AfterCommit(ctx, ps.Metrics.Reassigned)
+	}
+
+	ps.logger(ctx).Info("reviewer reassignment completed successfully")
+
+	return responseDTO, nil
+}
+
+// reassignReviewer возвращает true вторым значением, если у pr не было ревьюверов
+// и новый ревьювер назначен впервые
+func (ps *PullRequestsService) reassignReviewer(ctx context.Context, requestReassignDTO *dto.RequestReassignDTO) (*dto.ResponseReassignDTO, bool, error) {
+	// проверяем наличие pr и блокируем его: параллельное переназначение или merge
+	// этого pr дождется коммита и прочитает уже новый список ревьюверов
+	pullRequestModel, err := ps.PullRequestsRepository.GetPullRequestByIdForUpdate(ctx, requestReassignDTO.PullRequestId)
+	if err != nil {
+		ps.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("pull request not found")
 		if errors.Is(err, repository.ErrNoRecord) {
-			return nil, ErrNoResourse
+			return nil, false, ErrNoResourse
 		}
-		return nil, err
+		return nil, false, err
+	}
+
+	if err = ps.authorizeReassign(ctx, pullRequestModel); err != nil {
+		return nil, false, err
 	}
 
 	// проверяем наличие юзера
-	isExist, err := ps.UsersRepository.IsExist(nil, requestReassignDTO.OldUserId)
+	isExist, err := ps.UsersRepository.IsExist(ctx, requestReassignDTO.OldUserId)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("reviewer_id", requestReassignDTO.OldUserId),
 			slog.String("error", err.Error()),
 		).Error("failed to get an old reviewer")
+		return nil, false, err
 	}
 
 	if !isExist {
-		return nil, ErrNoResourse
+		return nil, false, ErrNoResourse
 	}
 
 	// проверяем статус pr
 	if pullRequestModel.Status == enums.MERGED {
-		ps.Lgr.Warn("cannot reassign reviewer on merged PR")
-		return nil, ErrPrMerged
+		ps.logger(ctx).Warn("cannot reassign reviewer on merged PR")
+		return nil, false, ErrPrMerged
 	}
 
 	// проверяем наличие ревьюверов у pr
-	oldReviewerIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(requestReassignDTO.PullRequestId)
+	oldReviewerIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(ctx, requestReassignDTO.PullRequestId)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to get reviewers")
-		return nil, err
+		return nil, false, err
 	}
 
 	// если у pr вообще не было ревьюверов, то пропускаем проверку старого ревьювера
@@ -274,10 +390,10 @@ func (ps *PullRequestsService) ReassignReviewer(requestReassignDTO *dto.RequestR
 			}
 		}
 		if !flag {
-			ps.Lgr.With(
+			ps.logger(ctx).With(
 				slog.String("reviewer_id", requestReassignDTO.OldUserId),
 			).Warn("reviewer is not assigned to this PR")
-			return nil, ErrNoSuchReviewer
+			return nil, false, ErrNoSuchReviewer
 		}
 
 		// у pr есть ревьюверы
@@ -285,68 +401,49 @@ func (ps *PullRequestsService) ReassignReviewer(requestReassignDTO *dto.RequestR
 	}
 
 	// берем автора
-	author, err := ps.UsersRepository.GetUserById(nil, pullRequestModel.AuthorID)
+	author, err := ps.UsersRepository.GetUserById(ctx, pullRequestModel.AuthorID)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("author_id", pullRequestModel.AuthorID),
 			slog.String("error", err.Error()),
 		).Error("failed to get author")
-		return nil, err
+		return nil, false, err
 	}
 
-	// пользователи в команде автора
-	temaUsers, err := ps.UsersRepository.GetUsersByTeam(nil, author.TeamName)
-	if err != nil {
-		ps.Lgr.With(
-			slog.String("team", author.TeamName),
-			slog.String("error", err.Error()),
-		).Error("failed to get team users")
-		return nil, err
+	// замена ищется в команде, из которой выбирались ревьюверы PR
+	reviewTeam := pullRequestModel.TeamName
+	if reviewTeam == "" {
+		reviewTeam = author.TeamName
 	}
 
-	newReviewersList := []*models.UserModel{}
-	for _, teamUser := range temaUsers {
-		// пропускаем автора и неактивных юзеров
-		if teamUser.Id == author.Id || !teamUser.IsActive {
-			continue
-		}
-
-		// пропускаем старого юзера, если у PR вообще нету ревьевров
-		if !prDoesntHaveReviewers && teamUser.Id == requestReassignDTO.OldUserId {
-			continue
-		}
-
-		// чтобы повторно не добавили второго ревьювера
-		isAssigned := false
-		for _, oldReviewerid := range oldReviewerIds {
-			if teamUser.Id == oldReviewerid && oldReviewerid != requestReassignDTO.OldUserId {
-				isAssigned = true
-				break
-			}
-		}
+	// пропускаем автора и всех текущих ревьюверов, включая заменяемого
+	exclude := map[string]bool{author.Id: true}
+	for _, oldReviewerId := range oldReviewerIds {
+		exclude[oldReviewerId] = true
+	}
 
-		if !isAssigned {
-			newReviewersList = append(newReviewersList, teamUser)
-		}
+	newReviewersList, err := ps.pickReviewers(ctx, ps.policy(), reviewTeam, 1, exclude)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// проверяем наличие ревьюверов
 	if len(newReviewersList) == 0 {
-		ps.Lgr.Warn("no available replacement candidates")
-		return nil, ErrNoReviewrsToAssign
+		ps.logger(ctx).Warn("no available replacement candidates")
+		return nil, false, ErrNoReviewrsToAssign
 	}
 
 	// выбираем новый id reviewer и меняем
-	newReviewerID := newReviewersList[rand.IntN(len(newReviewersList))].Id
+	newReviewerID := newReviewersList[0]
 
 	// меняем ревьювера, если до этого кто-то да был
 	if !prDoesntHaveReviewers {
-		if err := ps.ReviewersRepository.ChangeReviewer(pullRequestModel.PullRequestId, requestReassignDTO.OldUserId, newReviewerID); err != nil {
-			ps.Lgr.With(
+		if err := ps.ReviewersRepository.ChangeReviewer(ctx, pullRequestModel.PullRequestId, requestReassignDTO.OldUserId, newReviewerID); err != nil {
+			ps.logger(ctx).With(
 				slog.String("reviewer_id", newReviewerID),
 				slog.String("error", err.Error()),
 			).Error("failed to change reviewer")
-			return nil, err
+			return nil, false, err
 		}
 
 		// если ревьюверов не было
@@ -356,30 +453,159 @@ func (ps *PullRequestsService) ReassignReviewer(requestReassignDTO *dto.RequestR
 			PullRequestId: requestReassignDTO.PullRequestId,
 		}
 
-		if err := ps.ReviewersRepository.AddReviewer(nil, newReviewerModel); err != nil {
-			ps.Lgr.With(
+		if err := ps.ReviewersRepository.AddReviewer(ctx, newReviewerModel); err != nil {
+			ps.logger(ctx).With(
 				slog.String("reviewer_id", newReviewerID),
 				slog.String("error", err.Error()),
 			).Error("failed to add reviewer")
-			return nil, err
+			return nil, false, err
 		}
 	}
 
+	// первое назначение записывается как ASSIGNED, замена - как REASSIGNED
+	eventType, oldUserId := enums.EVENT_REASSIGNED, requestReassignDTO.OldUserId
+	if prDoesntHaveReviewers {
+		eventType, oldUserId = enums.EVENT_ASSIGNED, ""
+	}
+	if err := ps.addEvent(ctx, pullRequestModel.PullRequestId, eventType, newReviewerID, oldUserId); err != nil {
+		return nil, false, err
+	}
+
 	// получаем новый список ревьюверов
-	newReviewerIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(pullRequestModel.PullRequestId)
+	newReviewerIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(ctx, pullRequestModel.PullRequestId)
 	if err != nil {
-		ps.Lgr.With(
+		ps.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to get updated reviewers list")
-		return nil, err
+		return nil, false, err
 	}
 
-	ps.Lgr.Info("reviewer reassignment completed successfully")
-
 	responseDTO := &dto.ResponseReassignDTO{
 		PR:         dto.NewPullRequestDTO(pullRequestModel.PullRequestId, pullRequestModel.PullRequestName, pullRequestModel.AuthorID, newReviewerIds...),
 		ReplacedBy: newReviewerID,
 	}
+	responseDTO.PR.TeamName = reviewTeam
 
-	return responseDTO, nil
+	return responseDTO, prDoesntHaveReviewers, nil
+}
+
+// authorizeReassign пропускает администратора, автора и ревьюверов pr, а также лида команды pr
+func (ps *PullRequestsService) authorizeReassign(ctx context.Context, pullRequestModel *models.PullRequestModel) error {
+	principal := restrictedPrincipal(ctx)
+	if principal == nil || pullRequestModel.AuthorID == principal.UserId {
+		return nil
+	}
+
+	reviewersIds, err := ps.ReviewersRepository.GetReviewersIdByPullRequestId(ctx, pullRequestModel.PullRequestId)
+	if err != nil {
+		ps.logger(ctx).With(
+			slog.String("pull_request_id", pullRequestModel.PullRequestId),
+			slog.String("error", err.Error()),
+		).Error("failed to get reviewers list")
+		return err
+	}
+
+	if slices.Contains(reviewersIds, principal.UserId) {
+		return nil
+	}
+
+	return authorizeLead(ctx, ps.TeamsRepository, ps.logger(ctx), pullRequestModel.TeamName)
+}
+
+// pickReviewers случайно выбирает до need активных пользователей команды, не входящих в exclude.
+// Если в команде не хватает кандидатов и политика это разрешает, недостающие выбираются
+// из родительских команд
+func (ps *PullRequestsService) pickReviewers(ctx context.Context, policy AssignmentPolicy, teamName string, need int, exclude map[string]bool) ([]string, error) {
+	picked := []string{}
+
+	// у автора может не быть команды
+	if teamName == "" {
+		return picked, nil
+	}
+
+	chain := []string{teamName}
+	if policy.EscalateToParent {
+		ancestors, err := ps.TeamsRepository.GetAncestors(ctx, teamName)
+		if err != nil {
+			ps.logger(ctx).With(
+				slog.String("team", teamName),
+				slog.String("error", err.Error()),
+			).Error("failed to get parent teams")
+			return nil, err
+		}
+		chain = append(chain, ancestors...)
+	}
+	for i, team := range chain {
+		users, err := ps.UsersRepository.GetUsersByTeam(ctx, team)
+		if err != nil {
+			ps.logger(ctx).With(
+				slog.String("team", team),
+				slog.String("error", err.Error()),
+			).Error("failed to get team users")
+			return nil, err
+		}
+
+		// убираем неактивных и исключенных пользователей
+		candidates := []string{}
+		for _, user := range users {
+			if !user.IsActive || exclude[user.Id] {
+				continue
+			}
+			candidates = append(candidates, user.Id)
+		}
+
+		rand.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+
+		for _, candidate := range candidates {
+			if len(picked) == need {
+				break
+			}
+			picked = append(picked, candidate)
+			exclude[candidate] = true
+		}
+
+		if len(picked) == need || i == len(chain)-1 {
+			break
+		}
+
+		ps.logger(ctx).With(
+			slog.String("team", team),
+			slog.Int("picked", len(picked)),
+			slog.Int("need", need),
+		).Info("not enough reviewers in team, escalating to parent team")
+	}
+
+	return picked, nil
+}
+
+// addEvent записывает событие в историю pr
+func (ps *PullRequestsService) addEvent(ctx context.Context, pullRequestId, eventType, userId, oldUserId string) error {
+	event := &models.PullRequestEventModel{
+		PullRequestId: pullRequestId,
+		EventType:     eventType,
+		UserId:        userId,
+		OldUserId:     oldUserId,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := ps.EventsRepository.AddEvent(ctx, event); err != nil {
+		ps.logger(ctx).With(
+			slog.String("pull_request_id", pullRequestId),
+			slog.String("event_type", eventType),
+			slog.String("error", err.Error()),
+		).Error("failed to add pull request event")
+		return err
+	}
+
+	return nil
+}
+
+func (ps *PullRequestsService) policy() AssignmentPolicy {
+	return ps.Policy.Get()
+}
+
+func (ps *PullRequestsService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, ps.Lgr)
 }
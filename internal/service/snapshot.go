@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/enums"
+	"pr-service/internal/logging"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
+)
+
+type ISnapshotService interface {
+	Export(ctx context.Context) (*dto.SnapshotDTO, error)
+	Restore(ctx context.Context, snapshot *dto.SnapshotDTO) (*dto.ResponseRestoreDTO, error)
+}
+
+type SnapshotService struct {
+	SnapshotRepository  repository.ISnapshotRepository
+	TeamsRepository     repository.ITeamsRepository
+	ReviewersRepository repository.IReviewersRepository
+	EventsRepository    repository.IEventsRepository
+	Transactor          repository.ITransactor
+	Lgr                 *slog.Logger
+}
+
+// Export выгружает все данные сервиса одним согласованным снимком
+func (ss *SnapshotService) Export(ctx context.Context) (*dto.SnapshotDTO, error) {
+	ctx, span := tracing.Start(ctx, "SnapshotService.Export")
+	defer span.End()
+
+	ss.logger(ctx).Info("starting snapshot export")
+
+	// все таблицы читаются в одной транзакции, чтобы снимок был согласованным
+	var snapshot *dto.SnapshotDTO
+	err := ss.Transactor.WithTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	}, func(ctx context.Context) error {
+		var err error
+		snapshot, err = ss.readSnapshot(ctx)
+		return err
+	})
+	if err != nil {
+		ss.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to read snapshot")
+		return nil, err
+	}
+
+	ss.logger(ctx).With(
+		slog.Int("teams", len(snapshot.Teams)),
+		slog.Int("users", len(snapshot.Users)),
+		slog.Int("pull_requests", len(snapshot.PullRequests)),
+	).Info("snapshot export completed successfully")
+
+	return snapshot, nil
+}
+
+func (ss *SnapshotService) readSnapshot(ctx context.Context) (*dto.SnapshotDTO, error) {
+	snapshot := &dto.SnapshotDTO{
+		Version:    dto.SnapshotVersion,
+		ExportedAt: time.Now().UTC(),
+	}
+
+	teams, err := ss.TeamsRepository.GetAllTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Teams = make([]*dto.SnapshotTeamDTO, 0, len(teams))
+	for _, team := range teams {
+		snapshot.Teams = append(snapshot.Teams, &dto.SnapshotTeamDTO{
+			TeamName:       team.TeamName,
+			ParentTeamName: team.ParentTeamName,
+		})
+	}
+
+	users, err := ss.SnapshotRepository.GetAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Users = make([]*dto.SnapshotUserDTO, 0, len(users))
+	for _, user := range users {
+		snapshot.Users = append(snapshot.Users, &dto.SnapshotUserDTO{
+			UserId:    user.Id,
+			Username:  user.Username,
+			IsActive:  user.IsActive,
+			Email:     user.Email,
+			Handles:   user.Handles,
+			DeletedAt: user.DeletedAt,
+		})
+	}
+
+	members, err := ss.SnapshotRepository.GetAllMemberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Memberships = make([]*dto.SnapshotMembershipDTO, 0, len(members))
+	for _, member := range members {
+		snapshot.Memberships = append(snapshot.Memberships, &dto.SnapshotMembershipDTO{
+			TeamName:  member.TeamName,
+			UserId:    member.UserId,
+			IsPrimary: member.IsPrimary,
+		})
+	}
+
+	leads, err := ss.SnapshotRepository.GetAllTeamLeads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Leads = make([]*dto.SnapshotTeamLeadDTO, 0, len(leads))
+	for _, lead := range leads {
+		snapshot.Leads = append(snapshot.Leads, &dto.SnapshotTeamLeadDTO{
+			TeamName: lead.TeamName,
+			UserId:   lead.UserId,
+		})
+	}
+
+	pullRequests, err := ss.SnapshotRepository.GetAllPullRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.PullRequests = make([]*dto.SnapshotPullRequestDTO, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		snapshot.PullRequests = append(snapshot.PullRequests, &dto.SnapshotPullRequestDTO{
+			PullRequestId:   pullRequest.PullRequestId,
+			PullRequestName: pullRequest.PullRequestName,
+			AuthorID:        pullRequest.AuthorID,
+			Status:          pullRequest.Status,
+			TeamName:        pullRequest.TeamName,
+			CreatedAt:       pullRequest.CreatedAt,
+			MergedAt:        pullRequest.MergedAt,
+		})
+	}
+
+	reviewers, err := ss.SnapshotRepository.GetAllReviewers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Reviewers = make([]*dto.SnapshotReviewerDTO, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		snapshot.Reviewers = append(snapshot.Reviewers, &dto.SnapshotReviewerDTO{
+			PullRequestId: reviewer.PullRequestId,
+			UserId:        reviewer.UserId,
+		})
+	}
+
+	events, err := ss.SnapshotRepository.GetAllEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.History = make([]*dto.SnapshotEventDTO, 0, len(events))
+	for _, event := range events {
+		snapshot.History = append(snapshot.History, &dto.SnapshotEventDTO{
+			PullRequestId: event.PullRequestId,
+			EventType:     event.EventType,
+			UserId:        event.UserId,
+			OldUserId:     event.OldUserId,
+			CreatedAt:     event.CreatedAt,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// Restore загружает снимок в пустую БД. Снимок полностью проверяется до записи,
+// сама запись идет одной транзакцией
+func (ss *SnapshotService) Restore(ctx context.Context, snapshot *dto.SnapshotDTO) (*dto.ResponseRestoreDTO, error) {
+	ctx, span := tracing.Start(ctx, "SnapshotService.Restore")
+	defer span.End()
+
+	ss.logger(ctx).With(
+		slog.Int("version", snapshot.Version),
+	).Info("starting snapshot restore")
+
+	if problems := validateSnapshot(snapshot); len(problems) != 0 {
+		ss.logger(ctx).With(
+			slog.Int("problems", len(problems)),
+		).Warn("snapshot failed validation")
+		return nil, &SnapshotValidationError{Problems: problems}
+	}
+
+	// данные восстанавливаются целиком или не восстанавливаются совсем
+	err := ss.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		isEmpty, err := ss.SnapshotRepository.IsEmpty(ctx)
+		if err != nil {
+			ss.logger(ctx).With(
+				slog.String("error", err.Error()),
+			).Error("failed to check that database is empty")
+			return err
+		}
+
+		if !isEmpty {
+			ss.logger(ctx).Warn("restore is allowed only into an empty database")
+			return ErrDatabaseNotEmpty
+		}
+
+		if err := ss.writeSnapshot(ctx, snapshot); err != nil {
+			ss.logger(ctx).With(
+				slog.String("error", err.Error()),
+			).Error("failed to write snapshot")
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ss.logger(ctx).Info("snapshot restore completed successfully")
+
+	return &dto.ResponseRestoreDTO{
+		Version:      snapshot.Version,
+		Teams:        len(snapshot.Teams),
+		Users:        len(snapshot.Users),
+		Memberships:  len(snapshot.Memberships),
+		Leads:        len(snapshot.Leads),
+		PullRequests: len(snapshot.PullRequests),
+		Reviewers:    len(snapshot.Reviewers),
+		History:      len(snapshot.History),
+	}, nil
+}
+
+func (ss *SnapshotService) writeSnapshot(ctx context.Context, snapshot *dto.SnapshotDTO) error {
+	// сначала все команды, затем связи с родителями, так как порядок команд в снимке произвольный
+	for _, team := range snapshot.Teams {
+		if err := ss.TeamsRepository.AddTeam(ctx, team.TeamName); err != nil {
+			return fmt.Errorf("team %q: %w", team.TeamName, err)
+		}
+	}
+
+	for _, team := range snapshot.Teams {
+		if team.ParentTeamName == "" {
+			continue
+		}
+		if err := ss.TeamsRepository.SetParent(ctx, team.TeamName, team.ParentTeamName); err != nil {
+			return fmt.Errorf("team %q: %w", team.TeamName, err)
+		}
+	}
+
+	for _, user := range snapshot.Users {
+		userModel := &models.UserModel{
+			Id:        user.UserId,
+			Username:  user.Username,
+			IsActive:  user.IsActive,
+			Email:     user.Email,
+			Handles:   user.Handles,
+			DeletedAt: user.DeletedAt,
+		}
+		if err := ss.SnapshotRepository.RestoreUser(ctx, userModel); err != nil {
+			return fmt.Errorf("user %q: %w", user.UserId, err)
+		}
+	}
+
+	for _, member := range snapshot.Memberships {
+		memberModel := &models.TeamMemberModel{
+			TeamName:  member.TeamName,
+			UserId:    member.UserId,
+			IsPrimary: member.IsPrimary,
+		}
+		if err := ss.SnapshotRepository.RestoreMembership(ctx, memberModel); err != nil {
+			return fmt.Errorf("membership %q in %q: %w", member.UserId, member.TeamName, err)
+		}
+	}
+
+	for _, lead := range snapshot.Leads {
+		leadModel := &models.TeamLeadModel{
+			TeamName: lead.TeamName,
+			UserId:   lead.UserId,
+		}
+		if err := ss.SnapshotRepository.RestoreTeamLead(ctx, leadModel); err != nil {
+			return fmt.Errorf("lead %q of %q: %w", lead.UserId, lead.TeamName, err)
+		}
+	}
+
+	for _, pullRequest := range snapshot.PullRequests {
+		pullRequestModel := &models.PullRequestModel{
+			PullRequestId:   pullRequest.PullRequestId,
+			PullRequestName: pullRequest.PullRequestName,
+			AuthorID:        pullRequest.AuthorID,
+			Status:          pullRequest.Status,
+			TeamName:        pullRequest.TeamName,
+			CreatedAt:       pullRequest.CreatedAt,
+			MergedAt:        pullRequest.MergedAt,
+		}
+		if err := ss.SnapshotRepository.RestorePullRequest(ctx, pullRequestModel); err != nil {
+			return fmt.Errorf("pull request %q: %w", pullRequest.PullRequestId, err)
+		}
+	}
+
+	for _, reviewer := range snapshot.Reviewers {
+		reviewerModel := &models.ReviewerModel{
+			UserId:        reviewer.UserId,
+			PullRequestId: reviewer.PullRequestId,
+		}
+		if err := ss.ReviewersRepository.AddReviewer(ctx, reviewerModel); err != nil {
+			return fmt.Errorf("reviewer %q of %q: %w", reviewer.UserId, reviewer.PullRequestId, err)
+		}
+	}
+
+	for _, event := range snapshot.History {
+		eventModel := &models.PullRequestEventModel{
+			PullRequestId: event.PullRequestId,
+			EventType:     event.EventType,
+			UserId:        event.UserId,
+			OldUserId:     event.OldUserId,
+			CreatedAt:     event.CreatedAt,
+		}
+		if err := ss.EventsRepository.AddEvent(ctx, eventModel); err != nil {
+			return fmt.Errorf("event of %q: %w", event.PullRequestId, err)
+		}
+	}
+
+	return nil
+}
+
+// validateSnapshot проверяет версию и ссылочную целостность снимка и возвращает все найденные нарушения
+func validateSnapshot(snapshot *dto.SnapshotDTO) []string {
+	problems := []string{}
+	addProblem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if snapshot.Version != dto.SnapshotVersion {
+		addProblem("unsupported snapshot version %d, expected %d", snapshot.Version, dto.SnapshotVersion)
+		return problems
+	}
+
+	// команды: уникальные названия, существующие родители, без циклов
+	parents := make(map[string]string, len(snapshot.Teams))
+	for _, team := range snapshot.Teams {
+		if team.TeamName == "" {
+			addProblem("team with empty team_name")
+			continue
+		}
+		if _, ok := parents[team.TeamName]; ok {
+			addProblem("team %q is listed more than once", team.TeamName)
+			continue
+		}
+		parents[team.TeamName] = team.ParentTeamName
+	}
+
+	for _, team := range snapshot.Teams {
+		if team.ParentTeamName == "" {
+			continue
+		}
+		if _, ok := parents[team.ParentTeamName]; !ok {
+			addProblem("team %q references unknown parent team %q", team.TeamName, team.ParentTeamName)
+		}
+	}
+
+	for teamName := range parents {
+		seen := map[string]bool{teamName: true}
+		for parent := parents[teamName]; parent != ""; parent = parents[parent] {
+			if seen[parent] {
+				addProblem("team %q is part of a hierarchy cycle", teamName)
+				break
+			}
+			seen[parent] = true
+		}
+	}
+
+	// пользователи: уникальные id
+	users := make(map[string]bool, len(snapshot.Users))
+	for _, user := range snapshot.Users {
+		if user.UserId == "" {
+			addProblem("user with empty user_id")
+			continue
+		}
+		if users[user.UserId] {
+			addProblem("user %q is listed more than once", user.UserId)
+		}
+		users[user.UserId] = true
+	}
+
+	// членство: существующие команды и пользователи, не больше одной основной команды
+	members := make(map[[2]string]bool, len(snapshot.Memberships))
+	primaryTeams := make(map[string]string)
+	for _, member := range snapshot.Memberships {
+		if _, ok := parents[member.TeamName]; !ok {
+			addProblem("membership of %q references unknown team %q", member.UserId, member.TeamName)
+		}
+		if !users[member.UserId] {
+			addProblem("membership in %q references unknown user %q", member.TeamName, member.UserId)
+		}
+
+		key := [2]string{member.TeamName, member.UserId}
+		if members[key] {
+			addProblem("user %q is listed in team %q more than once", member.UserId, member.TeamName)
+		}
+		members[key] = true
+
+		if member.IsPrimary {
+			if primaryTeam, ok := primaryTeams[member.UserId]; ok {
+				addProblem("user %q has several primary teams: %q and %q", member.UserId, primaryTeam, member.TeamName)
+				continue
+			}
+			primaryTeams[member.UserId] = member.TeamName
+		}
+	}
+
+	// лиды: существующие команды и пользователи без повторов
+	leads := make(map[[2]string]bool, len(snapshot.Leads))
+	for _, lead := range snapshot.Leads {
+		if _, ok := parents[lead.TeamName]; !ok {
+			addProblem("lead %q references unknown team %q", lead.UserId, lead.TeamName)
+		}
+		if !users[lead.UserId] {
+			addProblem("lead of %q references unknown user %q", lead.TeamName, lead.UserId)
+		}
+
+		key := [2]string{lead.TeamName, lead.UserId}
+		if leads[key] {
+			addProblem("user %q is listed as lead of %q more than once", lead.UserId, lead.TeamName)
+		}
+		leads[key] = true
+	}
+
+	// pr: существующие авторы и команды, дата мержа только у MERGED
+	pullRequests := make(map[string]bool, len(snapshot.PullRequests))
+	for _, pullRequest := range snapshot.PullRequests {
+		if pullRequest.PullRequestId == "" {
+			addProblem("pull request with empty pull_request_id")
+			continue
+		}
+		if pullRequests[pullRequest.PullRequestId] {
+			addProblem("pull request %q is listed more than once", pullRequest.PullRequestId)
+		}
+		pullRequests[pullRequest.PullRequestId] = true
+
+		if !users[pullRequest.AuthorID] {
+			addProblem("pull request %q references unknown author %q", pullRequest.PullRequestId, pullRequest.AuthorID)
+		}
+		if pullRequest.TeamName != "" {
+			if _, ok := parents[pullRequest.TeamName]; !ok {
+				addProblem("pull request %q references unknown team %q", pullRequest.PullRequestId, pullRequest.TeamName)
+			}
+		}
+
+		switch pullRequest.Status {
+		case enums.OPEN:
+			if pullRequest.MergedAt != nil {
+				addProblem("open pull request %q has merged_at", pullRequest.PullRequestId)
+			}
+		case enums.MERGED:
+			if pullRequest.MergedAt == nil {
+				addProblem("merged pull request %q has no merged_at", pullRequest.PullRequestId)
+			}
+		default:
+			addProblem("pull request %q has unknown status %q", pullRequest.PullRequestId, pullRequest.Status)
+		}
+	}
+
+	// ревьюверы: существующие pr и пользователи без повторов
+	reviewers := make(map[[2]string]bool, len(snapshot.Reviewers))
+	for _, reviewer := range snapshot.Reviewers {
+		if !pullRequests[reviewer.PullRequestId] {
+			addProblem("reviewer %q references unknown pull request %q", reviewer.UserId, reviewer.PullRequestId)
+		}
+		if !users[reviewer.UserId] {
+			addProblem("pull request %q references unknown reviewer %q", reviewer.PullRequestId, reviewer.UserId)
+		}
+
+		key := [2]string{reviewer.PullRequestId, reviewer.UserId}
+		if reviewers[key] {
+			addProblem("reviewer %q is assigned to %q more than once", reviewer.UserId, reviewer.PullRequestId)
+		}
+		reviewers[key] = true
+	}
+
+	// история: существующие pr и пользователи, известные типы событий
+	for i, event := range snapshot.History {
+		if !pullRequests[event.PullRequestId] {
+			addProblem("history event %d references unknown pull request %q", i, event.PullRequestId)
+		}
+		switch event.EventType {
+		case enums.EVENT_ASSIGNED, enums.EVENT_REASSIGNED, enums.EVENT_MERGED:
+		default:
+			addProblem("history event %d has unknown type %q", i, event.EventType)
+		}
+		if event.UserId != "" && !users[event.UserId] {
+			addProblem("history event %d references unknown user %q", i, event.UserId)
+		}
+		if event.OldUserId != "" && !users[event.OldUserId] {
+			addProblem("history event %d references unknown user %q", i, event.OldUserId)
+		}
+	}
+
+	return problems
+}
+
+func (ss *SnapshotService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, ss.Lgr)
+}
@@ -1,54 +1,227 @@
 package service
 
 import (
+	"context"
 	"log/slog"
+	"maps"
+	"slices"
+
 	"pr-service/internal/dto"
+	"pr-service/internal/logging"
 	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
 )
 
 type IStatsService interface {
-	GetStats() (*dto.StatsResponseDTO, error)
+	GetStats(ctx context.Context) (*dto.StatsResponseDTO, error)
 }
 
 type StatsService struct {
-	PullRequestsRepository *repository.PullRequestsRepository
-	ReviewersRepository    *repository.ReviewersRepository
-	UsersRepository        *repository.UsersRepository
+	PullRequestsRepository repository.IPullRequestsRepository
+	ReviewersRepository    repository.IReviewersRepository
+	UsersRepository        repository.IUsersRepository
+	TeamsRepository        repository.ITeamsRepository
 	Lgr                    *slog.Logger
 }
 
-func (ss *StatsService) GetStats() (*dto.StatsResponseDTO, error) {
-	ss.Lgr.Info("Start to collect common stats")
+func (ss *StatsService) GetStats(ctx context.Context) (*dto.StatsResponseDTO, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetStats")
+	defer span.End()
+
+	ss.logger(ctx).Info("Start to collect common stats")
 
-	totalPRs, err := ss.PullRequestsRepository.CountAllPullRequests()
+	// лид видит только статистику своих команд, остальным пользователям она недоступна
+	var ledTeams []string
+	if principal := restrictedPrincipal(ctx); principal != nil {
+		var err error
+		ledTeams, err = ss.TeamsRepository.GetLedTeams(ctx, principal.UserId)
+		if err != nil {
+			ss.logger(ctx).With(
+				slog.String("error", err.Error()),
+			).Error("failed to get teams led by user")
+			return nil, err
+		}
+
+		if len(ledTeams) == 0 {
+			ss.logger(ctx).With(
+				slog.String("user_id", principal.UserId),
+			).Warn("stats are available only for admins and team leads")
+			return nil, ErrForbidden
+		}
+	}
+
+	totalPRs, err := ss.PullRequestsRepository.CountAllPullRequests(ctx)
 	if err != nil {
-		ss.Lgr.With(
+		ss.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to count all prs")
 		return nil, err
 	}
 
-	prsByStatus, err := ss.PullRequestsRepository.CountPullRequestsByStatus()
+	prsByStatus, err := ss.PullRequestsRepository.CountPullRequestsByStatus(ctx)
 	if err != nil {
-		ss.Lgr.With(
+		ss.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to count pr by status")
 		return nil, err
 	}
 
-	assignmentsByUser, err := ss.ReviewersRepository.CountAssignmentsByUser()
+	assignmentsByUser, err := ss.ReviewersRepository.CountAssignmentsByUser(ctx)
 	if err != nil {
-		ss.Lgr.With(
+		ss.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to count assignments by user")
 		return nil, err
 	}
 
-	ss.Lgr.Info("Collecting complete")
+	// статистика по дереву команд
+	teams, err := ss.TeamsRepository.GetAllTeams(ctx)
+	if err != nil {
+		ss.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get teams")
+		return nil, err
+	}
+
+	prsByTeam, err := ss.PullRequestsRepository.CountPullRequestsByTeamAndStatus(ctx)
+	if err != nil {
+		ss.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to count prs by team")
+		return nil, err
+	}
 
-	return &dto.StatsResponseDTO{
+	assignmentsByTeam, err := ss.ReviewersRepository.CountAssignmentsByTeam(ctx)
+	if err != nil {
+		ss.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to count assignments by team")
+		return nil, err
+	}
+
+	roots, _ := buildTeamTree(teams)
+	teamStats := make([]*dto.TeamStatsDTO, 0, len(roots))
+	for _, root := range roots {
+		teamStats = append(teamStats, rollUpTeamStats(root, prsByTeam, assignmentsByTeam))
+	}
+
+	responseDTO := &dto.StatsResponseDTO{
 		TotalPRs:          totalPRs,
 		PRsByStatus:       prsByStatus,
 		AssignmentsByUser: assignmentsByUser,
-	}, nil
+		Teams:             teamStats,
+	}
+
+	if ledTeams != nil {
+		responseDTO, err = ss.scopeStats(ctx, responseDTO, ledTeams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ss.logger(ctx).Info("Collecting complete")
+
+	return responseDTO, nil
+}
+
+// scopeStats оставляет в статистике только поддеревья команд лида: общие счетчики
+// считаются по этим поддеревьям, а назначения - только по их участникам
+func (ss *StatsService) scopeStats(ctx context.Context, stats *dto.StatsResponseDTO, ledTeams []string) (*dto.StatsResponseDTO, error) {
+	scoped := &dto.StatsResponseDTO{
+		PRsByStatus:       make(map[string]int),
+		AssignmentsByUser: make(map[string]int),
+		Teams:             []*dto.TeamStatsDTO{},
+	}
+
+	// вложенная команда лида уже входит в поддерево родительской
+	var collect func(nodes []*dto.TeamStatsDTO)
+	collect = func(nodes []*dto.TeamStatsDTO) {
+		for _, node := range nodes {
+			if slices.Contains(ledTeams, node.TeamName) {
+				scoped.Teams = append(scoped.Teams, node)
+				continue
+			}
+			collect(node.Children)
+		}
+	}
+	collect(stats.Teams)
+
+	teamNames := []string{}
+	var walk func(node *dto.TeamStatsDTO)
+	walk = func(node *dto.TeamStatsDTO) {
+		teamNames = append(teamNames, node.TeamName)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	for _, team := range scoped.Teams {
+		scoped.TotalPRs += team.Total.TotalPRs
+		for status, count := range team.Total.PRsByStatus {
+			scoped.PRsByStatus[status] += count
+		}
+		walk(team)
+	}
+
+	for _, teamName := range teamNames {
+		members, err := ss.UsersRepository.GetUsersByTeam(ctx, teamName)
+		if err != nil {
+			ss.logger(ctx).With(
+				slog.String("team", teamName),
+				slog.String("error", err.Error()),
+			).Error("failed to get team members")
+			return nil, err
+		}
+
+		for _, member := range members {
+			if count, ok := stats.AssignmentsByUser[member.Id]; ok {
+				scoped.AssignmentsByUser[member.Id] = count
+			}
+		}
+	}
+
+	return scoped, nil
+}
+
+// rollUpTeamStats считает статистику команды и суммирует ее с подкомандами
+func rollUpTeamStats(node *dto.TeamTreeDTO, prsByTeam map[string]map[string]int, assignmentsByTeam map[string]int) *dto.TeamStatsDTO {
+	own := &dto.TeamCountersDTO{
+		PRsByStatus: make(map[string]int),
+		Assignments: assignmentsByTeam[node.TeamName],
+	}
+	for status, count := range prsByTeam[node.TeamName] {
+		own.PRsByStatus[status] = count
+		own.TotalPRs += count
+	}
+
+	total := &dto.TeamCountersDTO{
+		TotalPRs:    own.TotalPRs,
+		PRsByStatus: maps.Clone(own.PRsByStatus),
+		Assignments: own.Assignments,
+	}
+
+	stats := &dto.TeamStatsDTO{
+		TeamName: node.TeamName,
+		Own:      own,
+		Total:    total,
+		Children: make([]*dto.TeamStatsDTO, 0, len(node.Children)),
+	}
+
+	for _, child := range node.Children {
+		childStats := rollUpTeamStats(child, prsByTeam, assignmentsByTeam)
+
+		total.TotalPRs += childStats.Total.TotalPRs
+		total.Assignments += childStats.Total.Assignments
+		for status, count := range childStats.Total.PRsByStatus {
+			total.PRsByStatus[status] += count
+		}
+
+		stats.Children = append(stats.Children, childStats)
+	}
+
+	return stats
+}
+
+func (ss *StatsService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, ss.Lgr)
 }
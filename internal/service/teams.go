@@ -1,102 +1,114 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"log/slog"
+	"slices"
+
 	"pr-service/internal/dto"
+	"pr-service/internal/logging"
 	"pr-service/internal/models"
 	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
 )
 
 type ITeamsService interface {
-	AddTeamWithMembers(team *dto.TeamDTO) (*dto.ResponseTeamDTO, error)
-	GetTeamWithMembers(teamName string) (*dto.TeamDTO, error)
+	AddTeamWithMembers(ctx context.Context, team *dto.TeamDTO) (*dto.ResponseTeamDTO, error)
+	GetTeamWithMembers(ctx context.Context, teamName string) (*dto.TeamDTO, error)
+	SyncTeamWithMembers(ctx context.Context, team *dto.TeamDTO, dryRun bool) (*dto.ResponseTeamSyncDTO, error)
+	SetParentTeam(ctx context.Context, requestDTO *dto.RequestSetParentDTO) (*dto.TeamTreeDTO, error)
+	GetTeamTree(ctx context.Context, teamName string) (*dto.ResponseTeamTreeDTO, error)
+	ListTeams(ctx context.Context) (*dto.ResponseTeamListDTO, error)
+	AddTeamLead(ctx context.Context, teamName, userId string) (*dto.TeamLeadsDTO, error)
+	RemoveTeamLead(ctx context.Context, teamName, userId string) (*dto.TeamLeadsDTO, error)
+	GetTeamLeads(ctx context.Context, teamName string) (*dto.TeamLeadsDTO, error)
 }
 
 type TeamsService struct {
 	TeamsRepository repository.ITeamsRepository
 	UsersRepository repository.IUsersRepository
+	Transactor      repository.ITransactor
 	Lgr             *slog.Logger
 }
 
-func (ts *TeamsService) AddTeamWithMembers(team *dto.TeamDTO) (*dto.ResponseTeamDTO, error) {
-	ts.Lgr.Info("starting team creation with members")
+func (ts *TeamsService) AddTeamWithMembers(ctx context.Context, team *dto.TeamDTO) (*dto.ResponseTeamDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.AddTeamWithMembers")
+	defer span.End()
 
-	// создаем транзакцию, так как добавялем команду и ее участников
-	tx, err := ts.TeamsRepository.GetDB().Begin()
-	if err != nil {
-		ts.Lgr.With(
-			slog.String("error", err.Error()),
-		).Error("failed to begin transaction")
+	ts.logger(ctx).Info("starting team creation with members")
+
+	// новые команды создает только администратор
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
 		return nil, err
 	}
 
-	// если возникла ошибка в сервисной функции
-	defer func() {
-		if err != nil {
-			ts.Lgr.With(
+	// команда и ее участники добавляются одной транзакцией
+	err := ts.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		if err := ts.TeamsRepository.AddTeam(ctx, team.TeamName); err != nil {
+			ts.logger(ctx).With(
 				slog.String("error", err.Error()),
-			).Error("rolling back transaction due to error")
-			if errRollback := tx.Rollback(); errRollback != nil {
-				ts.Lgr.With(
-					slog.String("error", errRollback.Error()),
-				).Error("rolling back was failed")
+			).Error("failed to add team")
+			if errors.Is(err, repository.ErrDuplicatedTeamName) {
+				return ErrTeamExists
 			}
+			return err
 		}
-	}()
 
-	// добавляем команду
-	if err := ts.TeamsRepository.AddTeam(tx, team.TeamName); err != nil {
-		ts.Lgr.With(
-			slog.String("error", err.Error()),
-		).Error("failed to add team")
-		if errors.Is(err, repository.ErrDuplicatedTeamName) {
-			return nil, ErrTeamExists
+		for _, member := range team.Members {
+			if err := ts.addMember(ctx, team.TeamName, member); err != nil {
+				return err
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// добавляем пользователей в команде
-	for _, member := range team.Members {
-		user := &models.UserModel{
-			Id:       member.UserId,
-			Username: member.Username,
-			IsActive: member.IsActive,
-			TeamName: team.TeamName,
-		}
+	ts.logger(ctx).Info("team creation completed successfully")
+	return &dto.ResponseTeamDTO{Team: team}, nil
+}
 
-		if err := ts.UsersRepository.AddUser(tx, user); err != nil {
-			ts.Lgr.With(
-				slog.String("error", err.Error()),
-				slog.String("user_id", user.Id),
-			).Error("failed to add team member")
-			if errors.Is(err, repository.ErrDuplicatedUserId) {
-				return nil, ErrUserExists
-			}
-			return nil, err
-		}
+// addMember создает нового пользователя с основной командой teamName
+func (ts *TeamsService) addMember(ctx context.Context, teamName string, member *dto.TeamMemberDTO) error {
+	user := &models.UserModel{
+		Id:       member.UserId,
+		Username: member.Username,
+		IsActive: member.IsActive,
+		TeamName: teamName,
 	}
 
-	// успешно завершаем транзакцию
-	err = tx.Commit()
-	if err != nil {
-		ts.Lgr.With(
+	if err := ts.UsersRepository.AddUser(ctx, user); err != nil {
+		ts.logger(ctx).With(
 			slog.String("error", err.Error()),
-		).Error("failed to commit transaction")
-		return nil, err
+			slog.String("user_id", user.Id),
+		).Error("failed to add team member")
+		if errors.Is(err, repository.ErrDuplicatedUserId) {
+			return ErrUserExists
+		}
+		return err
 	}
 
-	ts.Lgr.Info("team creation completed successfully")
-	return &dto.ResponseTeamDTO{Team: team}, nil
+	return nil
 }
 
-func (ts *TeamsService) GetTeamWithMembers(teamName string) (*dto.TeamDTO, error) {
-	ts.Lgr.Info("retrieving team with members")
+func (ts *TeamsService) GetTeamWithMembers(ctx context.Context, teamName string) (*dto.TeamDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.GetTeamWithMembers")
+	defer span.End()
+
+	ts.logger(ctx).Info("retrieving team with members")
+
+	if err := authorizeLead(ctx, ts.TeamsRepository, ts.logger(ctx), teamName); err != nil {
+		return nil, err
+	}
 
 	// проверяем существование команды
-	isExists, err := ts.TeamsRepository.IsExist(teamName)
+	isExists, err := ts.TeamsRepository.IsExist(ctx, teamName)
 	if err != nil {
-		ts.Lgr.With(
+		ts.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to check team existence")
 		return nil, err
@@ -104,14 +116,14 @@ func (ts *TeamsService) GetTeamWithMembers(teamName string) (*dto.TeamDTO, error
 
 	// если команды не существует
 	if !isExists {
-		ts.Lgr.Error("team not found")
+		ts.logger(ctx).Error("team not found")
 		return nil, ErrNoResourse
 	}
 
 	// ищем пользователей данной команды
-	userModels, err := ts.UsersRepository.GetUsersByTeam(nil, teamName)
+	userModels, err := ts.UsersRepository.GetUsersByTeam(ctx, teamName)
 	if err != nil {
-		ts.Lgr.With(
+		ts.logger(ctx).With(
 			slog.String("error", err.Error()),
 		).Error("failed to get team members")
 		return nil, err
@@ -132,7 +144,557 @@ func (ts *TeamsService) GetTeamWithMembers(teamName string) (*dto.TeamDTO, error
 		responseDTO.Members = append(responseDTO.Members, member)
 	}
 
-	ts.Lgr.Info("team retrieved successfully")
+	ts.logger(ctx).Info("team retrieved successfully")
 
 	return responseDTO, nil
 }
+
+// ListTeams возвращает все команды по алфавиту с количеством участников
+func (ts *TeamsService) ListTeams(ctx context.Context) (*dto.ResponseTeamListDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.ListTeams")
+	defer span.End()
+
+	ts.logger(ctx).Info("listing teams")
+
+	// список всех команд доступен только администратору
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	teamModels, err := ts.TeamsRepository.GetAllTeams(ctx)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get teams")
+		return nil, err
+	}
+
+	responseDTO := &dto.ResponseTeamListDTO{
+		Teams: make([]*dto.TeamSummaryDTO, 0, len(teamModels)),
+	}
+
+	for _, team := range teamModels {
+		userModels, err := ts.UsersRepository.GetUsersByTeam(ctx, team.TeamName)
+		if err != nil {
+			ts.logger(ctx).With(
+				slog.String("team", team.TeamName),
+				slog.String("error", err.Error()),
+			).Error("failed to get team members")
+			return nil, err
+		}
+
+		summary := &dto.TeamSummaryDTO{
+			TeamName:       team.TeamName,
+			ParentTeamName: team.ParentTeamName,
+			Members:        len(userModels),
+		}
+		for _, user := range userModels {
+			if user.IsActive {
+				summary.ActiveMembers++
+			}
+		}
+
+		responseDTO.Teams = append(responseDTO.Teams, summary)
+	}
+
+	ts.logger(ctx).Info("teams listed successfully")
+
+	return responseDTO, nil
+}
+
+// SyncTeamWithMembers приводит состав команды в точное соответствие с переданным списком.
+// При dryRun все изменения выполняются в транзакции, которая затем откатывается.
+func (ts *TeamsService) SyncTeamWithMembers(ctx context.Context, team *dto.TeamDTO, dryRun bool) (*dto.ResponseTeamSyncDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.SyncTeamWithMembers")
+	defer span.End()
+
+	ts.logger(ctx).With(
+		slog.String("team", team.TeamName),
+		slog.Bool("dry_run", dryRun),
+	).Info("starting team synchronization")
+
+	// лид синхронизирует только свою команду
+	if err := authorizeLead(ctx, ts.TeamsRepository, ts.logger(ctx), team.TeamName); err != nil {
+		return nil, err
+	}
+
+	// в одном списке пользователь может встречаться только один раз
+	listed := make(map[string]*dto.TeamMemberDTO, len(team.Members))
+	for _, member := range team.Members {
+		if _, ok := listed[member.UserId]; ok {
+			ts.logger(ctx).With(
+				slog.String("user_id", member.UserId),
+			).Warn("member is listed more than once")
+			return nil, ErrDuplicatedMember
+		}
+		listed[member.UserId] = member
+	}
+
+	var diff *dto.TeamSyncDiffDTO
+	err := ts.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		diff, err = ts.syncTeam(ctx, team, listed)
+		if err == nil && dryRun {
+			// в режиме dry-run изменения откатываются
+			return errDryRun
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	ts.logger(ctx).With(
+		slog.Int("created", len(diff.CreatedUsers)),
+		slog.Int("added", len(diff.AddedUsers)),
+		slog.Int("updated", len(diff.UpdatedUsers)),
+		slog.Int("detached", len(diff.DetachedUsers)),
+	).Info("team synchronization completed successfully")
+
+	return &dto.ResponseTeamSyncDTO{
+		Team:   team,
+		Diff:   diff,
+		DryRun: dryRun,
+	}, nil
+}
+
+// syncTeam применяет изменения состава команды и возвращает их список
+func (ts *TeamsService) syncTeam(ctx context.Context, team *dto.TeamDTO, listed map[string]*dto.TeamMemberDTO) (*dto.TeamSyncDiffDTO, error) {
+	diff := &dto.TeamSyncDiffDTO{
+		CreatedUsers:  []string{},
+		AddedUsers:    []string{},
+		UpdatedUsers:  []*dto.UserChangeDTO{},
+		DetachedUsers: []string{},
+	}
+
+	// создаем команду, если ее еще нет
+	var err error
+	diff.TeamCreated, err = ts.TeamsRepository.AddTeamIfNotExists(ctx, team.TeamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to add team")
+		return nil, err
+	}
+
+	// текущие участники команды
+	currentMembers, err := ts.UsersRepository.GetUsersByTeam(ctx, team.TeamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get team members")
+		return nil, err
+	}
+
+	// открепляем тех, кого нет в списке
+	isMember := make(map[string]bool, len(currentMembers))
+	for _, current := range currentMembers {
+		isMember[current.Id] = true
+		if _, ok := listed[current.Id]; ok {
+			continue
+		}
+
+		if err := ts.UsersRepository.RemoveMembership(ctx, team.TeamName, current.Id); err != nil {
+			ts.logger(ctx).With(
+				slog.String("user_id", current.Id),
+				slog.String("error", err.Error()),
+			).Error("failed to detach team member")
+			return nil, err
+		}
+		diff.DetachedUsers = append(diff.DetachedUsers, current.Id)
+	}
+
+	// создаем недостающих и обновляем существующих пользователей
+	for _, member := range team.Members {
+		user := &models.UserModel{
+			Id:       member.UserId,
+			Username: member.Username,
+			IsActive: member.IsActive,
+			TeamName: team.TeamName,
+		}
+
+		existing, err := ts.UsersRepository.GetUserById(ctx, member.UserId)
+		if err != nil && !errors.Is(err, repository.ErrNoRecord) {
+			ts.logger(ctx).With(
+				slog.String("user_id", member.UserId),
+				slog.String("error", err.Error()),
+			).Error("failed to get user")
+			return nil, err
+		}
+
+		if existing == nil {
+			if err := ts.UsersRepository.AddUser(ctx, user); err != nil {
+				ts.logger(ctx).With(
+					slog.String("user_id", user.Id),
+					slog.String("error", err.Error()),
+				).Error("failed to add team member")
+				// id занят удаленным пользователем
+				if errors.Is(err, repository.ErrDuplicatedUserId) {
+					return nil, ErrUserExists
+				}
+				return nil, err
+			}
+			diff.CreatedUsers = append(diff.CreatedUsers, user.Id)
+			continue
+		}
+
+		// лид не может забрать в свою команду и изменить чужого пользователя,
+		// это может сделать только администратор
+		if principal := restrictedPrincipal(ctx); principal != nil && !isMember[user.Id] {
+			ts.logger(ctx).With(
+				slog.String("lead_id", principal.UserId),
+				slog.String("user_id", user.Id),
+			).Warn("lead can't add existing users from other teams")
+			return nil, ErrForbidden
+		}
+
+		// существующий пользователь может состоять и в других командах
+		added, err := ts.UsersRepository.AddMembership(ctx, team.TeamName, user.Id)
+		if err != nil {
+			ts.logger(ctx).With(
+				slog.String("user_id", user.Id),
+				slog.String("error", err.Error()),
+			).Error("failed to add team membership")
+			return nil, err
+		}
+		if added {
+			diff.AddedUsers = append(diff.AddedUsers, user.Id)
+		}
+
+		changes := userChanges(existing, user)
+		if len(changes) == 0 {
+			continue
+		}
+
+		// синхронизация не затрагивает поля профиля
+		user.Email = existing.Email
+		user.Handles = existing.Handles
+
+		if err := ts.UsersRepository.UpdateUser(ctx, user); err != nil {
+			ts.logger(ctx).With(
+				slog.String("user_id", user.Id),
+				slog.String("error", err.Error()),
+			).Error("failed to update team member")
+			return nil, err
+		}
+		diff.UpdatedUsers = append(diff.UpdatedUsers, &dto.UserChangeDTO{
+			UserId:  user.Id,
+			Changes: changes,
+		})
+	}
+
+	return diff, nil
+}
+
+// userChanges собирает отличающиеся поля пользователя
+func userChanges(old, updated *models.UserModel) map[string]*dto.FieldChangeDTO {
+	changes := make(map[string]*dto.FieldChangeDTO)
+
+	if old.Username != updated.Username {
+		changes["username"] = &dto.FieldChangeDTO{Old: old.Username, New: updated.Username}
+	}
+
+	if old.IsActive != updated.IsActive {
+		changes["is_active"] = &dto.FieldChangeDTO{Old: old.IsActive, New: updated.IsActive}
+	}
+
+	return changes
+}
+
+// SetParentTeam переносит команду в иерархии, пустой родитель делает команду корневой
+func (ts *TeamsService) SetParentTeam(ctx context.Context, requestDTO *dto.RequestSetParentDTO) (*dto.TeamTreeDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.SetParentTeam")
+	defer span.End()
+
+	ts.logger(ctx).With(
+		slog.String("team", requestDTO.TeamName),
+		slog.String("parent_team", requestDTO.ParentTeamName),
+	).Info("starting team parent change")
+
+	// иерархию команд меняет только администратор
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	// проверяем существование команды
+	isExists, err := ts.TeamsRepository.IsExist(ctx, requestDTO.TeamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to check team existence")
+		return nil, err
+	}
+
+	if !isExists {
+		ts.logger(ctx).Error("team not found")
+		return nil, ErrNoResourse
+	}
+
+	if requestDTO.ParentTeamName != "" {
+		isExists, err := ts.TeamsRepository.IsExist(ctx, requestDTO.ParentTeamName)
+		if err != nil {
+			ts.logger(ctx).With(
+				slog.String("error", err.Error()),
+			).Error("failed to check parent team existence")
+			return nil, err
+		}
+
+		if !isExists {
+			ts.logger(ctx).Error("parent team not found")
+			return nil, ErrNoResourse
+		}
+	}
+
+	// проверка циклов и смена родителя выполняются одной транзакцией. В READ COMMITTED
+	// встречные переносы A -> B и B -> A оба прошли бы проверку и вместе образовали цикл,
+	// а в SERIALIZABLE один из них отменяется (40001) и WithTx повторяет его уже с проверкой
+	err = ts.Transactor.WithTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context) error {
+		return ts.setParent(ctx, requestDTO.TeamName, requestDTO.ParentTeamName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ts.logger(ctx).Info("team parent change completed successfully")
+
+	return ts.getTeamSubtree(ctx, requestDTO.TeamName)
+}
+
+// setParent меняет родителя существующей команды, не допуская циклов в иерархии
+func (ts *TeamsService) setParent(ctx context.Context, teamName, parentTeamName string) error {
+	if parentTeamName != "" {
+		// команда не может быть родителем самой себя
+		if parentTeamName == teamName {
+			ts.logger(ctx).Warn("team can't be its own parent")
+			return ErrTeamCycle
+		}
+
+		// команда не должна оказаться среди предков нового родителя
+		ancestors, err := ts.TeamsRepository.GetAncestors(ctx, parentTeamName)
+		if err != nil {
+			ts.logger(ctx).With(
+				slog.String("error", err.Error()),
+			).Error("failed to get parent team ancestors")
+			return err
+		}
+
+		if slices.Contains(ancestors, teamName) {
+			ts.logger(ctx).Warn("team hierarchy cycle")
+			return ErrTeamCycle
+		}
+	}
+
+	if err := ts.TeamsRepository.SetParent(ctx, teamName, parentTeamName); err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to set parent team")
+		return err
+	}
+
+	return nil
+}
+
+// GetTeamTree возвращает поддерево команды или весь лес команд, если название пустое
+func (ts *TeamsService) GetTeamTree(ctx context.Context, teamName string) (*dto.ResponseTeamTreeDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.GetTeamTree")
+	defer span.End()
+
+	ts.logger(ctx).Info("retrieving team tree")
+
+	if teamName != "" {
+		// лид видит поддерево своей команды
+		if err := authorizeLead(ctx, ts.TeamsRepository, ts.logger(ctx), teamName); err != nil {
+			return nil, err
+		}
+
+		subtree, err := ts.getTeamSubtree(ctx, teamName)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dto.ResponseTeamTreeDTO{Teams: []*dto.TeamTreeDTO{subtree}}, nil
+	}
+
+	// весь лес команд доступен только администратору
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	teams, err := ts.TeamsRepository.GetAllTeams(ctx)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get teams")
+		return nil, err
+	}
+
+	roots, _ := buildTeamTree(teams)
+
+	ts.logger(ctx).Info("team tree retrieved successfully")
+
+	return &dto.ResponseTeamTreeDTO{Teams: roots}, nil
+}
+
+func (ts *TeamsService) getTeamSubtree(ctx context.Context, teamName string) (*dto.TeamTreeDTO, error) {
+	teams, err := ts.TeamsRepository.GetAllTeams(ctx)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get teams")
+		return nil, err
+	}
+
+	_, nodes := buildTeamTree(teams)
+
+	node, ok := nodes[teamName]
+	if !ok {
+		ts.logger(ctx).Error("team not found")
+		return nil, ErrNoResourse
+	}
+
+	return node, nil
+}
+
+// buildTeamTree строит лес команд и возвращает корни и индекс узлов по названию
+func buildTeamTree(teams []*models.TeamModel) ([]*dto.TeamTreeDTO, map[string]*dto.TeamTreeDTO) {
+	nodes := make(map[string]*dto.TeamTreeDTO, len(teams))
+	for _, team := range teams {
+		nodes[team.TeamName] = &dto.TeamTreeDTO{
+			TeamName:       team.TeamName,
+			ParentTeamName: team.ParentTeamName,
+			Children:       []*dto.TeamTreeDTO{},
+		}
+	}
+
+	roots := []*dto.TeamTreeDTO{}
+	for _, team := range teams {
+		parent, ok := nodes[team.ParentTeamName]
+		if !ok {
+			roots = append(roots, nodes[team.TeamName])
+			continue
+		}
+		parent.Children = append(parent.Children, nodes[team.TeamName])
+	}
+
+	return roots, nodes
+}
+
+// AddTeamLead назначает пользователя лидом команды
+func (ts *TeamsService) AddTeamLead(ctx context.Context, teamName, userId string) (*dto.TeamLeadsDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.AddTeamLead")
+	defer span.End()
+
+	ts.logger(ctx).With(
+		slog.String("team", teamName),
+		slog.String("user_id", userId),
+	).Info("assigning team lead")
+
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := ts.checkTeamAndUser(ctx, teamName, userId); err != nil {
+		return nil, err
+	}
+
+	if err := ts.TeamsRepository.AddTeamLead(ctx, teamName, userId); err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to add team lead")
+		return nil, err
+	}
+
+	return ts.teamLeads(ctx, teamName)
+}
+
+// RemoveTeamLead снимает пользователя с роли лида команды
+func (ts *TeamsService) RemoveTeamLead(ctx context.Context, teamName, userId string) (*dto.TeamLeadsDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.RemoveTeamLead")
+	defer span.End()
+
+	ts.logger(ctx).With(
+		slog.String("team", teamName),
+		slog.String("user_id", userId),
+	).Info("removing team lead")
+
+	if err := authorizeAdmin(ctx, ts.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := ts.TeamsRepository.RemoveTeamLead(ctx, teamName, userId); err != nil {
+		if errors.Is(err, repository.ErrNoRecord) {
+			return nil, ErrNoResourse
+		}
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to remove team lead")
+		return nil, err
+	}
+
+	return ts.teamLeads(ctx, teamName)
+}
+
+func (ts *TeamsService) GetTeamLeads(ctx context.Context, teamName string) (*dto.TeamLeadsDTO, error) {
+	ctx, span := tracing.Start(ctx, "TeamsService.GetTeamLeads")
+	defer span.End()
+
+	if err := authorizeLead(ctx, ts.TeamsRepository, ts.logger(ctx), teamName); err != nil {
+		return nil, err
+	}
+
+	isExists, err := ts.TeamsRepository.IsExist(ctx, teamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to check team existence")
+		return nil, err
+	}
+	if !isExists {
+		return nil, ErrNoResourse
+	}
+
+	return ts.teamLeads(ctx, teamName)
+}
+
+func (ts *TeamsService) checkTeamAndUser(ctx context.Context, teamName, userId string) error {
+	isExists, err := ts.TeamsRepository.IsExist(ctx, teamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to check team existence")
+		return err
+	}
+	if !isExists {
+		return ErrNoResourse
+	}
+
+	isExists, err = ts.UsersRepository.IsExist(ctx, userId)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to check user existence")
+		return err
+	}
+	if !isExists {
+		return ErrNoResourse
+	}
+
+	return nil
+}
+
+func (ts *TeamsService) teamLeads(ctx context.Context, teamName string) (*dto.TeamLeadsDTO, error) {
+	leads, err := ts.TeamsRepository.GetTeamLeads(ctx, teamName)
+	if err != nil {
+		ts.logger(ctx).With(
+			slog.String("error", err.Error()),
+		).Error("failed to get team leads")
+		return nil, err
+	}
+
+	return &dto.TeamLeadsDTO{TeamName: teamName, Leads: leads}, nil
+}
+
+// logger возвращает логгер HTTP запроса с request_id, а вне запроса - общий Lgr
+func (ts *TeamsService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, ts.Lgr)
+}
@@ -1,31 +1,47 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"log/slog"
+
+	"pr-service/internal/auth"
 	"pr-service/internal/dto"
+	"pr-service/internal/logging"
+	"pr-service/internal/models"
 	"pr-service/internal/repository"
+	"pr-service/internal/tracing"
 )
 
 type IUsersService interface {
-	SetIsActiveById(isActiveUserDTO *dto.IsActiveUserDTO) (*dto.UserDTO, error)
-	GetPullRequestsByUserId(id string) (*dto.UserPullRequestsDTO, error)
+	SetIsActiveById(ctx context.Context, isActiveUserDTO *dto.IsActiveUserDTO) (*dto.UserDTO, error)
+	GetPullRequestsByUserId(ctx context.Context, id string) (*dto.UserPullRequestsDTO, error)
+	GetAllPullRequestsByUserId(ctx context.Context, id string) (*dto.UserPullRequestsDTO, error)
+	GetUser(ctx context.Context, id string) (*dto.UserDTO, error)
+	UpdateUser(ctx context.Context, requestDTO *dto.RequestUpdateUserDTO) (*dto.UserDTO, error)
+	DeleteUser(ctx context.Context, requestDTO *dto.RequestDeleteUserDTO) (*dto.ResponseDeleteUserDTO, error)
 }
 
 type UsersService struct {
 	UsersRepository        repository.IUsersRepository
+	TeamsRepository        repository.ITeamsRepository
 	ReviewersRepository    repository.IReviewersRepository
 	PullRequestsRepository repository.IPullRequestsRepository
+	Transactor             repository.ITransactor
+	PullRequestsService    IPullRequestsService
 	Lgr                    *slog.Logger
 }
 
-func (us *UsersService) SetIsActiveById(isActiveUserDTO *dto.IsActiveUserDTO) (*dto.UserDTO, error) {
-	us.Lgr.Info("starting user active status operation")
+func (us *UsersService) SetIsActiveById(ctx context.Context, isActiveUserDTO *dto.IsActiveUserDTO) (*dto.UserDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.SetIsActiveById")
+	defer span.End()
+
+	us.logger(ctx).Info("starting user active status operation")
 
 	// проверяем наличие пользователя в бд
-	user, err := us.UsersRepository.GetUserById(nil, isActiveUserDTO.Id)
+	user, err := us.UsersRepository.GetUserById(ctx, isActiveUserDTO.Id)
 	if err != nil {
-		us.Lgr.With(
+		us.logger(ctx).With(
 			slog.String("user_id", isActiveUserDTO.Id),
 			slog.String("error", err.Error()),
 		).Error("user not found")
@@ -35,19 +51,28 @@ func (us *UsersService) SetIsActiveById(isActiveUserDTO *dto.IsActiveUserDTO) (*
 		return nil, err
 	}
 
+	// лид меняет активность только участников своих команд
+	if err := authorizeLead(ctx, us.TeamsRepository, us.logger(ctx), user.Teams...); err != nil {
+		return nil, err
+	}
+
 	// проверяем значение до изменения
 	if user.IsActive != isActiveUserDTO.IsActive {
-		if err := us.UsersRepository.UpdateUserIsActive(isActiveUserDTO.Id, isActiveUserDTO.IsActive); err != nil {
-			us.Lgr.With(
+		if err := us.UsersRepository.UpdateUserIsActive(ctx, isActiveUserDTO.Id, isActiveUserDTO.IsActive); err != nil {
+			us.logger(ctx).With(
 				slog.String("user_id", isActiveUserDTO.Id),
 				slog.String("error", err.Error()),
 			).Error("failed to update user active status")
+			// пользователя удалили после проверки
+			if errors.Is(err, repository.ErrNoRecord) {
+				return nil, ErrNoResourse
+			}
 			return nil, err
 		}
 		user.IsActive = isActiveUserDTO.IsActive
 	}
 
-	us.Lgr.Info("user active status operation completed")
+	us.logger(ctx).Info("user active status operation completed")
 
 	return &dto.UserDTO{
 		User: &dto.User{
@@ -59,27 +84,54 @@ func (us *UsersService) SetIsActiveById(isActiveUserDTO *dto.IsActiveUserDTO) (*
 	}, nil
 }
 
-func (us *UsersService) GetPullRequestsByUserId(id string) (*dto.UserPullRequestsDTO, error) {
-	us.Lgr.Info("retrieving pull requests for user")
+func (us *UsersService) GetPullRequestsByUserId(ctx context.Context, id string) (*dto.UserPullRequestsDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.GetPullRequestsByUserId")
+	defer span.End()
+
+	us.logger(ctx).Info("retrieving pull requests for user")
+
+	return us.pullRequestsByUserId(ctx, id, us.ReviewersRepository.GetPullRequestIDsWithReviewersByUserId)
+}
+
+// GetAllPullRequestsByUserId возвращает все pr, где пользователь - ревьювер, вместе со слитыми
+func (us *UsersService) GetAllPullRequestsByUserId(ctx context.Context, id string) (*dto.UserPullRequestsDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.GetAllPullRequestsByUserId")
+	defer span.End()
+
+	us.logger(ctx).Info("retrieving all pull requests for user")
+
+	return us.pullRequestsByUserId(ctx, id, us.ReviewersRepository.GetAllPullRequestIDsByUserId)
+}
+
+// pullRequestsByUserId собирает pr пользователя, id которых возвращает pullRequestIds
+func (us *UsersService) pullRequestsByUserId(ctx context.Context, id string, pullRequestIds func(ctx context.Context, id string) ([]string, error)) (*dto.UserPullRequestsDTO, error) {
+	// обычный пользователь видит только свою очередь
+	if principal := restrictedPrincipal(ctx); principal != nil && principal.UserId != id {
+		us.logger(ctx).With(
+			slog.String("user_id", principal.UserId),
+		).Warn("user can read only own review queue")
+		return nil, ErrForbidden
+	}
 
 	// проверяем наличие пользователя в бд
-	isExists, err := us.UsersRepository.IsExist(nil, id)
+	isExists, err := us.UsersRepository.IsExist(ctx, id)
 	if err != nil {
-		us.Lgr.With(
+		us.logger(ctx).With(
 			slog.String("user_id", id),
 			slog.String("error", err.Error()),
-		).Error("user not found")
+		).Error("failed to check user existence")
+		return nil, err
 	}
 
 	if !isExists {
-		us.Lgr.Error("user not found")
+		us.logger(ctx).Error("user not found")
 		return nil, ErrNoResourse
 	}
 
 	// получаем PR, на которые назначен пользователь
-	pullRequestIds, err := us.ReviewersRepository.GetPullRequestIDsWithReviewersByUserId(id)
+	ids, err := pullRequestIds(ctx, id)
 	if err != nil {
-		us.Lgr.With(
+		us.logger(ctx).With(
 			slog.String("user_id", id),
 			slog.String("error", err.Error()),
 		).Error("failed to get user's pull requests")
@@ -89,12 +141,12 @@ func (us *UsersService) GetPullRequestsByUserId(id string) (*dto.UserPullRequest
 	// формируем DTO
 	responseDTO := &dto.UserPullRequestsDTO{
 		UserId:       id,
-		PullRequests: make([]*dto.UserPullRequestDTO, 0, len(pullRequestIds)),
+		PullRequests: make([]*dto.UserPullRequestDTO, 0, len(ids)),
 	}
-	for _, pullRequestId := range pullRequestIds {
-		pullRequestModel, err := us.PullRequestsRepository.GetPullRequestById(pullRequestId)
+	for _, pullRequestId := range ids {
+		pullRequestModel, err := us.PullRequestsRepository.GetPullRequestById(ctx, pullRequestId)
 		if err != nil {
-			us.Lgr.With(
+			us.logger(ctx).With(
 				slog.String("pull_request_id", pullRequestId),
 				slog.String("error", err.Error()),
 			).Error("failed to get pull request")
@@ -111,7 +163,255 @@ func (us *UsersService) GetPullRequestsByUserId(id string) (*dto.UserPullRequest
 		responseDTO.PullRequests = append(responseDTO.PullRequests, userPullRequestDTO)
 	}
 
-	us.Lgr.Info("user pull requests retrieved successfully")
+	us.logger(ctx).Info("user pull requests retrieved successfully")
+
+	return responseDTO, nil
+}
+
+func (us *UsersService) GetUser(ctx context.Context, id string) (*dto.UserDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.GetUser")
+	defer span.End()
+
+	us.logger(ctx).Info("retrieving user profile")
+
+	user, err := us.UsersRepository.GetUserById(ctx, id)
+	if err != nil {
+		us.logger(ctx).With(
+			slog.String("user_id", id),
+			slog.String("error", err.Error()),
+		).Error("user not found")
+		if errors.Is(err, repository.ErrNoRecord) {
+			return nil, ErrNoResourse
+		}
+		return nil, err
+	}
+
+	// лид видит профили только участников своих команд
+	if err := authorizeLead(ctx, us.TeamsRepository, us.logger(ctx), user.Teams...); err != nil {
+		return nil, err
+	}
+
+	us.logger(ctx).Info("user profile retrieved successfully")
+
+	return &dto.UserDTO{User: newUser(user)}, nil
+}
+
+func (us *UsersService) UpdateUser(ctx context.Context, requestDTO *dto.RequestUpdateUserDTO) (*dto.UserDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.UpdateUser")
+	defer span.End()
+
+	us.logger(ctx).Info("starting user profile update")
+
+	// профиль и основную команду меняет только администратор
+	if err := authorizeAdmin(ctx, us.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	// проверяем наличие пользователя в бд
+	user, err := us.UsersRepository.GetUserById(ctx, requestDTO.UserId)
+	if err != nil {
+		us.logger(ctx).With(
+			slog.String("user_id", requestDTO.UserId),
+			slog.String("error", err.Error()),
+		).Error("user not found")
+		if errors.Is(err, repository.ErrNoRecord) {
+			return nil, ErrNoResourse
+		}
+		return nil, err
+	}
+
+	if requestDTO.Username != nil {
+		user.Username = *requestDTO.Username
+	}
+
+	if requestDTO.Email != nil {
+		user.Email = *requestDTO.Email
+	}
+
+	if requestDTO.Handles != nil {
+		user.Handles = requestDTO.Handles
+	}
+
+	// пустое название команды открепляет пользователя
+	if requestDTO.TeamName != nil && *requestDTO.TeamName != "" {
+		isExists, err := us.TeamsRepository.IsExist(ctx, *requestDTO.TeamName)
+		if err != nil {
+			us.logger(ctx).With(
+				slog.String("team", *requestDTO.TeamName),
+				slog.String("error", err.Error()),
+			).Error("failed to check team existence")
+			return nil, err
+		}
+
+		if !isExists {
+			us.logger(ctx).With(
+				slog.String("team", *requestDTO.TeamName),
+			).Warn("team not found")
+			return nil, ErrNoResourse
+		}
+	}
+
+	// профиль и основная команда меняются одной транзакцией. user не меняется внутри,
+	// чтобы повтор транзакции начинался с тех же данных
+	var updated *models.UserModel
+	err = us.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		updated = user
+		if err := us.UsersRepository.UpdateUser(ctx, user); err != nil {
+			us.logger(ctx).With(
+				slog.String("user_id", user.Id),
+				slog.String("error", err.Error()),
+			).Error("failed to update user")
+			return err
+		}
+
+		// смена основной команды
+		if requestDTO.TeamName != nil && *requestDTO.TeamName != user.TeamName {
+			if err := us.UsersRepository.SetPrimaryTeam(ctx, user.Id, *requestDTO.TeamName); err != nil {
+				us.logger(ctx).With(
+					slog.String("user_id", user.Id),
+					slog.String("team", *requestDTO.TeamName),
+					slog.String("error", err.Error()),
+				).Error("failed to change user's primary team")
+				return err
+			}
+
+			// перечитываем пользователя, чтобы вернуть актуальный список команд
+			var err error
+			updated, err = us.UsersRepository.GetUserById(ctx, user.Id)
+			if err != nil {
+				us.logger(ctx).With(
+					slog.String("user_id", requestDTO.UserId),
+					slog.String("error", err.Error()),
+				).Error("failed to get updated user")
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	us.logger(ctx).Info("user profile update completed successfully")
+
+	return &dto.UserDTO{User: newUser(updated)}, nil
+}
+
+func (us *UsersService) DeleteUser(ctx context.Context, requestDTO *dto.RequestDeleteUserDTO) (*dto.ResponseDeleteUserDTO, error) {
+	ctx, span := tracing.Start(ctx, "UsersService.DeleteUser")
+	defer span.End()
+
+	us.logger(ctx).Info("starting user deletion")
+
+	// удаляет пользователей только администратор
+	if err := authorizeAdmin(ctx, us.logger(ctx)); err != nil {
+		return nil, err
+	}
+
+	// переназначения ревью и удаление выполняются одной транзакцией: если одно ревью
+	// переназначить не удалось, остальные переназначения откатываются и пользователь остается
+	var responseDTO *dto.ResponseDeleteUserDTO
+	err := us.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		responseDTO, err = us.deleteUser(ctx, requestDTO)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	us.logger(ctx).Info("user deletion completed successfully")
+
+	return responseDTO, nil
+}
+
+func (us *UsersService) deleteUser(ctx context.Context, requestDTO *dto.RequestDeleteUserDTO) (*dto.ResponseDeleteUserDTO, error) {
+	// проверяем наличие пользователя в бд
+	isExists, err := us.UsersRepository.IsExist(ctx, requestDTO.UserId)
+	if err != nil {
+		us.logger(ctx).With(
+			slog.String("user_id", requestDTO.UserId),
+			slog.String("error", err.Error()),
+		).Error("failed to check user existence")
+		return nil, err
+	}
+
+	if !isExists {
+		us.logger(ctx).Error("user not found")
+		return nil, ErrNoResourse
+	}
+
+	// открытые PR, на которые назначен пользователь
+	pullRequestIds, err := us.ReviewersRepository.GetPullRequestIDsWithReviewersByUserId(ctx, requestDTO.UserId)
+	if err != nil {
+		us.logger(ctx).With(
+			slog.String("user_id", requestDTO.UserId),
+			slog.String("error", err.Error()),
+		).Error("failed to get user's pull requests")
+		return nil, err
+	}
+
+	// без явного согласия открытые ревью не переназначаем
+	if len(pullRequestIds) != 0 && !requestDTO.ReassignReviews {
+		us.logger(ctx).With(
+			slog.String("user_id", requestDTO.UserId),
+			slog.Int("open_reviews", len(pullRequestIds)),
+		).Warn("user has open reviews")
+		return nil, ErrHasOpenReviews
+	}
+
+	responseDTO := &dto.ResponseDeleteUserDTO{
+		UserId:     requestDTO.UserId,
+		Reassigned: make([]*dto.ReassignedReviewDTO, 0, len(pullRequestIds)),
+	}
+
+	// переназначаем открытые ревью. Вызов между сервисами доверенный, поэтому ctx без принципала.
+	// Транзакция остается в ctx, и ReassignReviewer выполняется в ней же
+	trustedCtx := auth.WithoutPrincipal(ctx)
+	for _, pullRequestId := range pullRequestIds {
+		reassignDTO, err := us.PullRequestsService.ReassignReviewer(trustedCtx, &dto.RequestReassignDTO{
+			PullRequestId: pullRequestId,
+			OldUserId:     requestDTO.UserId,
+		})
+		if err != nil {
+			us.logger(ctx).With(
+				slog.String("pull_request_id", pullRequestId),
+				slog.String("error", err.Error()),
+			).Error("failed to reassign review")
+			return nil, err
+		}
+
+		responseDTO.Reassigned = append(responseDTO.Reassigned, &dto.ReassignedReviewDTO{
+			PullRequestId: pullRequestId,
+			ReplacedBy:    reassignDTO.ReplacedBy,
+		})
+	}
+
+	if err := us.UsersRepository.DeleteUser(ctx, requestDTO.UserId); err != nil {
+		us.logger(ctx).With(
+			slog.String("user_id", requestDTO.UserId),
+			slog.String("error", err.Error()),
+		).Error("failed to delete user")
+		return nil, err
+	}
 
 	return responseDTO, nil
 }
+
+// newUser формирует DTO пользователя из модели
+func newUser(user *models.UserModel) *dto.User {
+	return &dto.User{
+		UserId:   user.Id,
+		Username: user.Username,
+		TeamName: user.TeamName,
+		IsActive: user.IsActive,
+		Teams:    user.Teams,
+		Email:    user.Email,
+		Handles:  user.Handles,
+	}
+}
+
+func (us *UsersService) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, us.Lgr)
+}
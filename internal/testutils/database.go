@@ -2,27 +2,29 @@ package testutils
 
 import (
 	"database/sql"
+	"errors"
+	"io/fs"
 	"os"
 	"testing"
 
-	"pr-service/internal/config"
 	"pr-service/internal/database"
 
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 func NewTestDB(t *testing.T) *sql.DB {
-	// загрузка .env
-	cfg, err := config.Load("../.env")
-	if err != nil {
+	// тестовая БД настраивается только через TEST_DB_* из окружения или ../.env,
+	// настройки сервиса для нее не используются
+	if err := godotenv.Load("../.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		t.Fatal(err)
-		return nil
 	}
 
-	dbAddr := database.GetDbAddres(cfg.TestDBHost, cfg.TestDBPort, cfg.TestDBName, cfg.TestDBUser, cfg.TestDBPassword)
+	dbAddr := database.GetDbAddres(os.Getenv("TEST_DB_HOST"), os.Getenv("TEST_DB_PORT"), os.Getenv("TEST_DB_NAME"),
+		os.Getenv("TEST_DB_USER"), os.Getenv("TEST_DB_PASSWORD"))
 
 	// подключаемся к базе
-	db, err := database.ConnectDB(dbAddr)
+	db, err := database.ConnectDB(dbAddr, database.DefaultPoolOptions)
 	if err != nil {
 		t.Fatal(err)
 	}
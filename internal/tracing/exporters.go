@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultOTLPEndpoint - адрес OTLP/HTTP приемника локального коллектора
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// NewExporter создает экспортер по имени: "stdout" или "otlp"
+func NewExporter(name, otlpEndpoint, serviceName string) (Exporter, error) {
+	switch name {
+	case "stdout":
+		return &StdoutExporter{Writer: os.Stdout}, nil
+	case "otlp":
+		if otlpEndpoint == "" {
+			otlpEndpoint = DefaultOTLPEndpoint
+		}
+		return &OTLPExporter{
+			Endpoint:    otlpEndpoint,
+			ServiceName: serviceName,
+			Client:      &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown tracing exporter %q, expected stdout or otlp", name)
+}
+
+// StdoutExporter пишет каждый спан одной строкой JSON
+type StdoutExporter struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+type stdoutSpan struct {
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMs   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Error        string         `json:"error,omitempty"`
+}
+
+var kindNames = map[int]string{
+	SpanKindInternal: "internal",
+	SpanKindServer:   "server",
+	SpanKindClient:   "client",
+}
+
+func (se *StdoutExporter) Export(ctx context.Context, spans []*SpanData) error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	encoder := json.NewEncoder(se.Writer)
+	for _, span := range spans {
+		line := stdoutSpan{
+			TraceID:    span.TraceID.String(),
+			SpanID:     span.SpanID.String(),
+			Name:       span.Name,
+			Kind:       kindNames[span.Kind],
+			Start:      span.Start,
+			End:        span.End,
+			DurationMs: float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+			Error:      span.Error,
+		}
+		if span.ParentSpanID.IsValid() {
+			line.ParentSpanID = span.ParentSpanID.String()
+		}
+		if len(span.Attributes) > 0 {
+			line.Attributes = make(map[string]any, len(span.Attributes))
+			for _, attribute := range span.Attributes {
+				line.Attributes[attribute.Key] = attribute.Value
+			}
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (se *StdoutExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// OTLPExporter отправляет спаны в коллектор по OTLP/HTTP в JSON кодировке
+type OTLPExporter struct {
+	Endpoint    string
+	ServiceName string
+	Client      *http.Client
+}
+
+// структуры OTLP JSON: идентификаторы в hex, время - строка с наносекундами
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	// 2 - STATUS_CODE_ERROR
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func otlpValue(value any) map[string]any {
+	switch v := value.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(v)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	}
+	return map[string]any{"stringValue": fmt.Sprint(value)}
+}
+
+func (oe *OTLPExporter) Export(ctx context.Context, spans []*SpanData) error {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		item := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		}
+		if span.ParentSpanID.IsValid() {
+			item.ParentSpanID = span.ParentSpanID.String()
+		}
+		for _, attribute := range span.Attributes {
+			item.Attributes = append(item.Attributes, otlpKeyValue{Key: attribute.Key, Value: otlpValue(attribute.Value)})
+		}
+		if span.Error != "" {
+			item.Status = &otlpStatus{Code: 2, Message: span.Error}
+		}
+		otlpSpans = append(otlpSpans, item)
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: otlpValue(oe.ServiceName)},
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "pr-service/internal/tracing"},
+			Spans: otlpSpans,
+		}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, oe.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := oe.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("OTLP collector answered %s", response.Status)
+	}
+
+	return nil
+}
+
+func (oe *OTLPExporter) Shutdown(ctx context.Context) error {
+	oe.Client.CloseIdleConnections()
+	return nil
+}
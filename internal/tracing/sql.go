@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+)
+
+// длинные запросы (массовые вставки) обрезаются в атрибуте спана
+const maxStatementLength = 1000
+
+// WrapConnector добавляет спан на каждый SQL запрос, выполненный в трассируемом запросе.
+// Репозитории не меняются: спан берется из контекста QueryContext и ExecContext
+func WrapConnector(connector driver.Connector) driver.Connector {
+	return &tracedConnector{connector}
+}
+
+type tracedConnector struct {
+	driver.Connector
+}
+
+func (tc *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := tc.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{conn}, nil
+}
+
+// tracedConn пробрасывает необязательные интерфейсы драйвера, которые реализует lib/pq
+type tracedConn struct {
+	driver.Conn
+}
+
+func (tc *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := tc.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+	span.RecordError(err)
+	return rows, err
+}
+
+func (tc *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := tc.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	result, err := execer.ExecContext(ctx, query, args)
+	span.RecordError(err)
+	return result, err
+}
+
+func (tc *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := tc.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return tc.Conn.Prepare(query)
+}
+
+func (tc *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := tc.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return tc.Conn.Begin()
+}
+
+func (tc *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := tc.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (tc *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := tc.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (tc *tracedConn) IsValid() bool {
+	if validator, ok := tc.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// startStatement называет спан по команде SQL (SELECT, INSERT, SAVEPOINT, ...)
+func startStatement(ctx context.Context, query string) (context.Context, *Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength] + "..."
+	}
+
+	return start(ctx, strings.ToUpper(operation), SpanKindClient,
+		String("db.system", "postgresql"),
+		String("db.statement", statement),
+	)
+}
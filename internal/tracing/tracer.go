@@ -0,0 +1,239 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pr-service/internal/health"
+	"pr-service/internal/httpx"
+	"pr-service/internal/logging"
+)
+
+const (
+	// сколько спанов ждут отправки, лишние отбрасываются, чтобы не копить память
+	queueSize = 4096
+	batchSize = 256
+	// как часто отправлять неполный пакет
+	flushInterval = 2 * time.Second
+	// сколько ждать отправки одного пакета
+	exportTimeout = 5 * flushInterval
+	// горутина отправки отмечается раз в flushInterval, но может ждать отправки пакета
+	heartbeatTimeout = exportTimeout + 2*flushInterval
+)
+
+// Exporter отправляет завершенные спаны. Export вызывается из одной горутины
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer начинает трейсы HTTP запросов и пакетами отправляет спаны в Exporter
+// из фоновой горутины. Нулевой Tracer выключает трассировку
+type Tracer struct {
+	Exporter Exporter
+	// доля корневых трейсов, которые записываются; для запросов с traceparent
+	// решение о записи принимает вызывающий сервис
+	SampleRatio float64
+	Lgr         *slog.Logger
+
+	queue     chan *SpanData
+	stop      chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+	dropped   atomic.Int64
+	heartbeat *health.Heartbeat
+}
+
+// NewTracer запускает отправку спанов. Остановить ее нужно через Shutdown
+func NewTracer(exporter Exporter, sampleRatio float64, lgr *slog.Logger) *Tracer {
+	t := &Tracer{
+		Exporter:    exporter,
+		SampleRatio: sampleRatio,
+		Lgr:         lgr,
+		queue:       make(chan *SpanData, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		heartbeat:   health.NewHeartbeat("tracing", heartbeatTimeout),
+	}
+	go t.run()
+
+	return t
+}
+
+func (t *Tracer) enqueue(span *SpanData) {
+	select {
+	case t.queue <- span:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	defer t.heartbeat.Stop()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, batchSize)
+	flush := func() {
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			t.Lgr.With(
+				slog.Int64("dropped", dropped),
+			).Warn("tracing queue is full, spans were dropped")
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := t.Exporter.Export(ctx, batch); err != nil {
+			t.Lgr.With(
+				slog.Int("spans", len(batch)),
+				slog.String("error", err.Error()),
+			).Error("failed to export spans")
+		}
+		batch = make([]*SpanData, 0, batchSize)
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			t.heartbeat.Beat()
+		case <-t.stop:
+			// забираем то, что успело попасть в очередь
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+					if len(batch) == batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown отправляет накопленные спаны и закрывает Exporter. Вызывается после
+// остановки HTTP сервера, когда новые спаны уже не появятся
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return t.Exporter.Shutdown(ctx)
+}
+
+// Heartbeat - отметки горутины отправки спанов для проверки готовности
+func (t *Tracer) Heartbeat() *health.Heartbeat {
+	return t.heartbeat
+}
+
+// Middleware начинает спан запроса. Если клиент передал traceparent, спан
+// становится дочерним в его трейсе, иначе начинается новый трейс
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceId, parentId, sampled, ok := ParseTraceParent(r.Header.Get(TraceParentHeader))
+		if !ok {
+			traceId, parentId = newTraceID(), SpanID{}
+			sampled = rand.Float64() < t.SampleRatio
+		}
+		if !sampled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		span := &Span{
+			TraceID:      traceId,
+			SpanID:       newSpanID(),
+			ParentSpanID: parentId,
+			Kind:         SpanKindServer,
+			Start:        time.Now(),
+			tracer:       t,
+			name:         r.Method,
+		}
+		defer span.End()
+
+		// строки лога запроса получают trace_id, а спан - request_id, чтобы переходить между ними
+		ctx := context.WithValue(r.Context(), spanKey{}, span)
+		if lgr := logging.FromContext(ctx, nil); lgr != nil {
+			ctx = logging.WithLogger(ctx, lgr.With(slog.String("trace_id", traceId.String())))
+		}
+		traced := r.WithContext(ctx)
+
+		recorder := httpx.NewRecorder(w)
+		next.ServeHTTP(recorder, traced)
+
+		// ServeMux заполняет шаблон в переданной ему копии запроса, возвращаем его
+		// внешним middleware (access log, метрики). Шаблон маршрута - имя спана
+		route := traced.Pattern
+		r.Pattern = route
+		if route != "" {
+			span.SetName(route)
+		}
+		if requestId := logging.RequestID(ctx); requestId != "" {
+			span.SetAttributes(String("request.id", requestId))
+		}
+		span.SetAttributes(
+			String("http.request.method", r.Method),
+			String("http.route", route),
+			String("url.path", r.URL.Path),
+			Int("http.response.status_code", recorder.Status()),
+		)
+		if recorder.Status() >= http.StatusInternalServerError {
+			span.RecordError(&statusError{status: recorder.Status()})
+		}
+	})
+}
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return http.StatusText(e.status)
+}
+
+// ParseSampleRatio разбирает долю записываемых трейсов от 0 до 1, пустая строка - 1
+func ParseSampleRatio(spec string) (float64, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 1, nil
+	}
+
+	ratio, err := strconv.ParseFloat(spec, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("invalid tracing sample ratio %q, expected a number from 0 to 1", spec)
+	}
+	return ratio, nil
+}
@@ -0,0 +1,214 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceParentHeader - заголовок W3C Trace Context
+const TraceParentHeader = "traceparent"
+
+// виды спанов, значения совпадают с OTLP
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+	SpanKindClient   = 3
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// Attribute - атрибут спана, значение - string, int, int64, float64 или bool
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Span - операция внутри трейса. Методы нулевого Span ничего не делают, поэтому
+// код сервисов и репозиториев не проверяет, включена ли трассировка
+type Span struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Kind         int
+	Start        time.Time
+
+	tracer *Tracer
+
+	mu         sync.Mutex
+	name       string
+	end        time.Time
+	attributes []Attribute
+	errMessage string
+	ended      bool
+}
+
+// SpanData - завершенный спан, который получает Exporter
+type SpanData struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Kind         int
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	// пустая строка - спан завершился без ошибки
+	Error string
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// RecordError помечает спан как завершенный с ошибкой
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = err.Error()
+}
+
+// End завершает спан и передает его трейсеру. Повторные вызовы игнорируются
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := &SpanData{
+		TraceID:      s.TraceID,
+		SpanID:       s.SpanID,
+		ParentSpanID: s.ParentSpanID,
+		Name:         s.name,
+		Kind:         s.Kind,
+		Start:        s.Start,
+		End:          s.end,
+		Attributes:   s.attributes,
+		Error:        s.errMessage,
+	}
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+type spanKey struct{}
+
+// SpanFromContext возвращает текущий спан или nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start начинает дочерний спан текущего. Вне трассируемого запроса (трассировка
+// выключена, запрос не попал в выборку, вызов из prctl) возвращает nil
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindInternal, attributes...)
+}
+
+func start(ctx context.Context, name string, kind int, attributes ...Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		TraceID:      parent.TraceID,
+		SpanID:       newSpanID(),
+		ParentSpanID: parent.SpanID,
+		Kind:         kind,
+		Start:        time.Now(),
+		tracer:       parent.tracer,
+		name:         name,
+		attributes:   attributes,
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
+
+// ParseTraceParent разбирает заголовок traceparent вида
+// 00-<trace-id 32 hex>-<parent-id 16 hex>-<flags 2 hex>
+func ParseTraceParent(header string) (traceId TraceID, parentId SpanID, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceId, parentId, false, false
+	}
+
+	// версия ff запрещена, у версии 00 ровно 4 поля, будущие версии могут добавить поля
+	version := parts[0]
+	if version == "ff" || (version == "00" && len(parts) != 4) {
+		return traceId, parentId, false, false
+	}
+
+	for _, part := range parts[:4] {
+		if strings.ToLower(part) != part {
+			return traceId, parentId, false, false
+		}
+	}
+
+	var versionByte, flags [1]byte
+	if _, err := hex.Decode(versionByte[:], []byte(version)); err != nil {
+		return traceId, parentId, false, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return traceId, parentId, false, false
+	}
+	if _, err := hex.Decode(traceId[:], []byte(parts[1])); err != nil || !traceId.IsValid() {
+		return TraceID{}, SpanID{}, false, false
+	}
+	if _, err := hex.Decode(parentId[:], []byte(parts[2])); err != nil || !parentId.IsValid() {
+		return TraceID{}, SpanID{}, false, false
+	}
+
+	return traceId, parentId, flags[0]&1 == 1, true
+}
@@ -2,6 +2,7 @@ package validators
 
 import (
 	"regexp"
+	"slices"
 	"unicode/utf8"
 )
 
@@ -97,3 +98,67 @@ func (v *Validator) ValidateTeamName(teamName string) {
 		return
 	}
 }
+
+func (v *Validator) ValidateEmail(email string) {
+	// пустая строка удаляет email
+	if email == "" {
+		return
+	}
+
+	matched, _ := regexp.MatchString(`^[^@\s]+@[^@\s]+\.[^@\s]+$`, email)
+	if !matched {
+		v.IsValid = false
+		return
+	}
+
+	// длина не больше 255 символов из-за БД
+	if utf8.RuneCountInString(email) > 255 {
+		v.IsValid = false
+		return
+	}
+}
+
+func (v *Validator) ValidateHandles(handles map[string]string) {
+	for service, handle := range handles {
+		// название сервиса (github, slack, ...) и сам логин обязательны
+		if service == "" || handle == "" {
+			v.IsValid = false
+			return
+		}
+
+		if utf8.RuneCountInString(service) > 64 || utf8.RuneCountInString(handle) > 255 {
+			v.IsValid = false
+			return
+		}
+	}
+}
+
+func (v *Validator) ValidateAPIKeyName(name string) {
+	if name == "" {
+		v.IsValid = false
+		return
+	}
+
+	// длина не больше 255 символов из-за БД
+	if utf8.RuneCountInString(name) > 255 {
+		v.IsValid = false
+		return
+	}
+}
+
+// ValidateScopes - хотя бы одна область, все из списка known и без повторов
+func (v *Validator) ValidateScopes(scopes, known []string) {
+	if len(scopes) == 0 {
+		v.IsValid = false
+		return
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if seen[scope] || !slices.Contains(known, scope) {
+			v.IsValid = false
+			return
+		}
+		seen[scope] = true
+	}
+}
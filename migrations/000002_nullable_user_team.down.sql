@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
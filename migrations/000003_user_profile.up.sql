@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(255) NULL;
+ALTER TABLE users ADD COLUMN handles JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN team_name VARCHAR(255) NULL REFERENCES teams(team_name);
+
+UPDATE users SET team_name = team_members.team_name
+FROM team_members WHERE team_members.user_id = users.user_id AND team_members.is_primary;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS team_name;
+
+DROP TABLE IF EXISTS team_members;
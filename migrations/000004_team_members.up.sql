@@ -0,0 +1,22 @@
+CREATE TABLE team_members (
+	team_name VARCHAR(255) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	is_primary BOOLEAN NOT NULL DEFAULT false,
+	PRIMARY KEY(team_name, user_id),
+	FOREIGN KEY(team_name) REFERENCES teams(team_name),
+	FOREIGN KEY(user_id) REFERENCES users(user_id)
+);
+
+-- у пользователя может быть только одна основная команда
+CREATE UNIQUE INDEX team_members_primary_idx ON team_members(user_id) WHERE is_primary;
+
+INSERT INTO team_members(team_name, user_id, is_primary)
+SELECT team_name, user_id, true FROM users WHERE team_name IS NOT NULL;
+
+-- команда, из которой выбирались ревьюверы PR
+ALTER TABLE pull_requests ADD COLUMN team_name VARCHAR(255) NULL REFERENCES teams(team_name);
+
+UPDATE pull_requests SET team_name = users.team_name
+FROM users WHERE users.user_id = pull_requests.author_id;
+
+ALTER TABLE users DROP COLUMN team_name;
@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN parent_team_name VARCHAR(255) NULL REFERENCES teams(team_name);
@@ -0,0 +1,13 @@
+CREATE TABLE pull_request_events (
+	event_id SERIAL PRIMARY KEY,
+	pull_request_id VARCHAR(255) NOT NULL,
+	event_type VARCHAR(32) NOT NULL,
+	user_id VARCHAR(255) NULL,
+	old_user_id VARCHAR(255) NULL,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY(pull_request_id) REFERENCES pull_requests(pull_request_id),
+	FOREIGN KEY(user_id) REFERENCES users(user_id),
+	FOREIGN KEY(old_user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX pull_request_events_pr_idx ON pull_request_events(pull_request_id);
@@ -0,0 +1,10 @@
+-- лиды команд: управляют составом и активностью своей команды
+CREATE TABLE team_leads (
+	team_name VARCHAR(255) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	PRIMARY KEY(team_name, user_id),
+	FOREIGN KEY(team_name) REFERENCES teams(team_name),
+	FOREIGN KEY(user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX team_leads_user_idx ON team_leads(user_id);
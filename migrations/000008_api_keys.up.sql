@@ -0,0 +1,15 @@
+-- ключи доступа для ботов и интеграций, хранится только sha256 ключа
+CREATE TABLE api_keys (
+	key_id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	key_hash CHAR(64) NOT NULL UNIQUE,
+	scopes TEXT[] NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NULL,
+	last_used_at TIMESTAMP NULL,
+	revoked_at TIMESTAMP NULL
+);
+
+-- имя уникально среди действующих ключей, отозванное имя можно выдать заново
+CREATE UNIQUE INDEX api_keys_active_name_idx ON api_keys(name) WHERE revoked_at IS NULL;
@@ -2,6 +2,7 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -28,14 +29,20 @@ func TestAddPullRequestHandler(t *testing.T) {
 	usersRepository := &repository.UsersRepository{Db: db}
 	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
 	reviewersRepository := &repository.ReviewersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	eventsRepository := &repository.EventsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
 
 	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 
 	// создаем сервис
 	pullRequestService := &service.PullRequestsService{
 		UsersRepository:        usersRepository,
+		TeamsRepository:        teamsRepository,
 		PullRequestsRepository: pullRequestsRepository,
 		ReviewersRepository:    reviewersRepository,
+		EventsRepository:       eventsRepository,
+		Transactor:             transactor,
 		Lgr:                    lgr,
 	}
 
@@ -91,7 +98,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		// проверяем записи в БД
 
 		// проверяем что PR создался в БД
-		pr, err := pullRequestsRepository.GetPullRequestById(requestDTO.PullRequestId)
+		pr, err := pullRequestsRepository.GetPullRequestById(context.Background(), requestDTO.PullRequestId)
 		if err != nil {
 			t.Fatalf("Failed to get PR from database: %v", err)
 		}
@@ -109,7 +116,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, pr.Status, enums.OPEN)
 
 		// проверяем что ревьюверы назначились
-		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(requestDTO.PullRequestId)
+		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(context.Background(), requestDTO.PullRequestId)
 		if err != nil {
 			t.Fatalf("Failed to get reviewers from database: %v", err)
 		}
@@ -147,7 +154,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
 
 		// проверяем что PR создался в БД
-		_, err = pullRequestsRepository.GetPullRequestById(requestDTO.PullRequestId)
+		_, err = pullRequestsRepository.GetPullRequestById(context.Background(), requestDTO.PullRequestId)
 		if err != nil {
 			t.Fatalf("Failed to get PR from database: %v", err)
 		}
@@ -206,7 +213,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.Error.Code, "NOT_FOUND")
 
 		// проверяем что PR не создался в БД
-		_, err = pullRequestsRepository.GetPullRequestById(requestDTO.PullRequestId)
+		_, err = pullRequestsRepository.GetPullRequestById(context.Background(), requestDTO.PullRequestId)
 		testhelpers.Equal(t, errors.Is(err, repository.ErrNoRecord), true)
 	})
 
@@ -237,7 +244,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
 
 		// проверяем что PR создался в БД
-		pr, err := pullRequestsRepository.GetPullRequestById(requestDTO.PullRequestId)
+		pr, err := pullRequestsRepository.GetPullRequestById(context.Background(), requestDTO.PullRequestId)
 		if err != nil {
 			t.Fatalf("Failed to get PR from database: %v", err)
 		}
@@ -246,7 +253,7 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, pr.PullRequestId, requestDTO.PullRequestId)
 
 		// проверяем что ревьюверы не назначены
-		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(requestDTO.PullRequestId)
+		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(context.Background(), requestDTO.PullRequestId)
 		if err != nil {
 			t.Fatalf("Failed to get reviewers from database: %v", err)
 		}
@@ -255,23 +262,44 @@ func TestAddPullRequestHandler(t *testing.T) {
 		testhelpers.Equal(t, len(reviewerIds), 0)
 	})
 
-	// 	t.Run("invalid HTTP method", func(t *testing.T) {
-	// 		request := httptest.NewRequest("GET", "/pullRequest/create", nil)
-	// 		responseWriter := httptest.NewRecorder()
+	t.Run("reviewers from parent team", func(t *testing.T) {
+		// squad входит в department, в squad только один активный кандидат
+		testutils.RunQuery(t, db, "./testdata/insertTeamHierarchy.sql")
 
-	// 		pullRequestHandler.AddPullRequest(responseWriter, request)
-	// 		responseResult := responseWriter.Result()
+		requestDTO := dto.RequestPullrequestDTO{
+			PullRequestId:   "pr-1200",
+			PullRequestName: "Squad PR",
+			AuthorID:        "u10",
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	// 		// код ответа должен совпадать
-	// 		testhelpers.Equal(t, responseResult.StatusCode, http.StatusMethodNotAllowed)
+		request := httptest.NewRequest("POST", "/pullRequest/create", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
 
-	// 		// десереализируем ответ
-	// 		var responseDTO dto.ErrorResponseDTO
-	// 		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
-	// 			t.Fatalf("Failed to parse response: %v", err)
-	// 		}
+		// делаем запрос
+		pullRequestHandler.AddPullRequest(responseWriter, request)
+		responseResult := responseWriter.Result()
 
-	//		// код ошибки должен совпадать
-	//		testhelpers.Equal(t, responseDTO.Error.Code, "WRONG_METHOD")
-	//	})
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+		// проверяем ревьюверов в БД
+		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(context.Background(), requestDTO.PullRequestId)
+		if err != nil {
+			t.Fatalf("Failed to get reviewers from database: %v", err)
+		}
+
+		// второй ревьювер должен прийти из родительской команды
+		testhelpers.Equal(t, len(reviewerIds), 2)
+		reviewers := map[string]bool{}
+		for _, reviewerId := range reviewerIds {
+			reviewers[reviewerId] = true
+		}
+		testhelpers.Equal(t, reviewers["u11"], true)
+		testhelpers.Equal(t, reviewers["u12"], true)
+	})
 }
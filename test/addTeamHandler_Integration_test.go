@@ -2,6 +2,7 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -26,6 +27,7 @@ func TestAddTeamHandler(t *testing.T) {
 	// создаем репозитории
 	usersRepository := &repository.UsersRepository{Db: db}
 	teamsRepository := &repository.TeamsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
 
 	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 
@@ -33,6 +35,7 @@ func TestAddTeamHandler(t *testing.T) {
 	teamService := &service.TeamsService{
 		UsersRepository: usersRepository,
 		TeamsRepository: teamsRepository,
+		Transactor:      transactor,
 		Lgr:             lgr,
 	}
 
@@ -79,7 +82,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, len(responseDTO.Team.Members), len(requestDTO.Members))
 
 		// проверяем записи в БД
-		exists, err := teamsRepository.IsExist(requestDTO.TeamName)
+		exists, err := teamsRepository.IsExist(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to check team existence: %v", err)
 		}
@@ -88,7 +91,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, exists, true)
 
 		// проверяем, что пользователи создались в БД
-		userModels, err := usersRepository.GetUsersByTeam(nil, requestDTO.TeamName)
+		userModels, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to get team userModels: %v", err)
 		}
@@ -151,7 +154,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
 
 		// проверяем записи в БД после первого создания
-		exists, err := teamsRepository.IsExist(requestDTO.TeamName)
+		exists, err := teamsRepository.IsExist(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to check team existence: %v", err)
 		}
@@ -160,7 +163,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, exists, true)
 
 		// проверяем, что пользователи создались в БД
-		userModels, err := usersRepository.GetUsersByTeam(nil, requestDTO.TeamName)
+		userModels, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to get team userModels: %v", err)
 		}
@@ -189,7 +192,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.Error.Code, "TEAM_EXISTS")
 
 		// проверяем что данные не дублировались в БД
-		userModelsAfterSecondAttempt, err := usersRepository.GetUsersByTeam(nil, requestDTO.TeamName)
+		userModelsAfterSecondAttempt, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to get team userModels after duplicate attempt: %v", err)
 		}
@@ -230,7 +233,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.Error.Code, "USER_EXISTS")
 
 		// проверяем что ничего не создалось в БД (транзакция откатилась)
-		exists, err := teamsRepository.IsExist(requestDTO.TeamName)
+		exists, err := teamsRepository.IsExist(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to check team existence: %v", err)
 		}
@@ -239,7 +242,7 @@ func TestAddTeamHandler(t *testing.T) {
 		testhelpers.Equal(t, exists, false)
 
 		// проверяем, что пользователи не создались в БД
-		userModels, err := usersRepository.GetUsersByTeam(nil, requestDTO.TeamName)
+		userModels, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
 		if err != nil {
 			t.Fatalf("Failed to get team userModels: %v", err)
 		}
@@ -247,15 +250,4 @@ func TestAddTeamHandler(t *testing.T) {
 		// кол-во участников должно быть 0
 		testhelpers.Equal(t, len(userModels), 0)
 	})
-
-	t.Run("invalid HTTP method", func(t *testing.T) {
-		request := httptest.NewRequest("GET", "/team/add", nil)
-		responseWriter := httptest.NewRecorder()
-
-		teamHandler.AddTeam(responseWriter, request)
-		responseResult := responseWriter.Result()
-
-		// код ответа должен совпадать
-		testhelpers.Equal(t, responseResult.StatusCode, http.StatusMethodNotAllowed)
-	})
 }
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestAPIKeys(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	apiKeysService := &service.APIKeysService{
+		APIKeysRepository: &repository.APIKeysRepository{Db: db},
+		Lgr:               slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+	}
+
+	// ключи проверяются тем же роутером, что и в main
+	router := routes.NewRouter(auth.Chain{&auth.StaticTokens{}, apiKeysService}, nil, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{},
+		&handlers.PullRequestsHandlers{}, &handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{},
+		&handlers.APIKeysHandlers{APIKeysService: apiKeysService}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	createKey := func(requestDTO dto.RequestCreateAPIKeyDTO) *http.Response {
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		handler := &handlers.APIKeysHandlers{APIKeysService: apiKeysService}
+		handler.CreateKey(responseWriter, withPrincipal(request, "", auth.RoleAdmin))
+		return responseWriter.Result()
+	}
+
+	callRouter := func(method, path, token string) *http.Response {
+		request := httptest.NewRequest(method, path, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		return responseWriter.Result()
+	}
+
+	// создаем ключ с областью admin
+	responseResult := createKey(dto.RequestCreateAPIKeyDTO{Name: "ci-bot", Scopes: []string{auth.ScopeAdmin}})
+	testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+	var created dto.ResponseCreateAPIKeyDTO
+	if err := json.NewDecoder(responseResult.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	testhelpers.Equal(t, strings.HasPrefix(created.Token, created.Key.Prefix), true)
+
+	t.Run("only hash is stored", func(t *testing.T) {
+		var stored int
+		if err := db.QueryRow("SELECT COUNT(*) FROM api_keys WHERE key_hash = $1", created.Token).Scan(&stored); err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, stored, 0)
+	})
+
+	t.Run("duplicated name", func(t *testing.T) {
+		responseResult := createKey(dto.RequestCreateAPIKeyDTO{Name: "ci-bot", Scopes: []string{auth.ScopeStatsRead}})
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusConflict)
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		responseResult := createKey(dto.RequestCreateAPIKeyDTO{Name: "other", Scopes: []string{"teams:delete"}})
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("key works through router and records usage", func(t *testing.T) {
+		responseResult := callRouter(http.MethodGet, "/api/v1/admin/api-keys", created.Token)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var listed dto.ResponseAPIKeysDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&listed); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, len(listed.Keys), 1)
+		testhelpers.Equal(t, listed.Keys[0].LastUsedAt != nil, true)
+	})
+
+	t.Run("key without scope is forbidden", func(t *testing.T) {
+		responseResult := createKey(dto.RequestCreateAPIKeyDTO{Name: "stats-bot", Scopes: []string{auth.ScopeStatsRead}})
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+		var statsKey dto.ResponseCreateAPIKeyDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&statsKey); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		responseResult = callRouter(http.MethodGet, "/api/v1/admin/api-keys", statsKey.Token)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		responseResult := createKey(dto.RequestCreateAPIKeyDTO{Name: "short", Scopes: []string{auth.ScopeAdmin}, ExpiresAt: &expiresAt})
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+		var shortKey dto.ResponseCreateAPIKeyDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&shortKey); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// срок в прошлом через API задать нельзя, поэтому сдвигаем его в БД
+		if _, err := db.Exec("UPDATE api_keys SET expires_at = NOW() - INTERVAL '1 minute' WHERE key_id = $1", shortKey.Key.KeyId); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := apiKeysService.Authenticate(context.Background(), shortKey.Token); !errors.Is(err, auth.ErrTokenExpired) {
+			t.Errorf("expired key must be rejected with ErrTokenExpired, got %v", err)
+		}
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/api-keys/"+strconv.Itoa(created.Key.KeyId), nil)
+		request.SetPathValue("id", strconv.Itoa(created.Key.KeyId))
+		responseWriter := httptest.NewRecorder()
+
+		handler := &handlers.APIKeysHandlers{APIKeysService: apiKeysService}
+		handler.RevokeKey(responseWriter, withPrincipal(request, "", auth.RoleAdmin))
+		testhelpers.Equal(t, responseWriter.Result().StatusCode, http.StatusOK)
+
+		responseResult := callRouter(http.MethodGet, "/api/v1/admin/api-keys", created.Token)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusUnauthorized)
+
+		// после отзыва имя снова свободно
+		responseResult = createKey(dto.RequestCreateAPIKeyDTO{Name: "ci-bot", Scopes: []string{auth.ScopeAdmin}})
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+	})
+}
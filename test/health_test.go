@@ -0,0 +1,159 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/database"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/health"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/tracing"
+)
+
+func TestHealthProbes(t *testing.T) {
+	databaseErr := errors.New("connection refused")
+	checker := &health.Checker{
+		Timeout: 50 * time.Millisecond,
+		Checks: []health.Check{
+			{Name: "database", Check: func(ctx context.Context) error { return databaseErr }},
+			{Name: "migrations", Check: func(ctx context.Context) error { return nil }},
+			// зависшая проверка не должна держать пробу дольше своего срока
+			{Name: "tracing", Check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+	}
+
+	// пробы доступны без токена
+	router := routes.NewRouter(&auth.StaticTokens{}, nil, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{Checker: checker})
+
+	call := func(path string) (int, *dto.ResponseHealthDTO) {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+
+		var responseDTO dto.ResponseHealthDTO
+		if err := json.NewDecoder(responseWriter.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse %s response: %v", path, err)
+		}
+		return responseWriter.Code, &responseDTO
+	}
+
+	t.Run("liveness does not depend on checks", func(t *testing.T) {
+		status, responseDTO := call("/healthz")
+		testhelpers.Equal(t, status, http.StatusOK)
+		testhelpers.Equal(t, responseDTO.Status, health.StatusOK)
+		testhelpers.Equal(t, len(responseDTO.Checks), 0)
+	})
+
+	t.Run("readiness reports every check", func(t *testing.T) {
+		status, responseDTO := call("/readyz")
+		testhelpers.Equal(t, status, http.StatusServiceUnavailable)
+		testhelpers.Equal(t, responseDTO.Status, health.StatusFail)
+		testhelpers.Equal(t, len(responseDTO.Checks), 3)
+
+		testhelpers.Equal(t, responseDTO.Checks["database"].Status, health.StatusFail)
+		testhelpers.Equal(t, responseDTO.Checks["database"].Error, databaseErr.Error())
+		testhelpers.Equal(t, responseDTO.Checks["migrations"].Status, health.StatusOK)
+		testhelpers.Equal(t, responseDTO.Checks["tracing"].Error, context.DeadlineExceeded.Error())
+	})
+
+	t.Run("ready when all checks pass", func(t *testing.T) {
+		checker.Checks = checker.Checks[1:2]
+		status, responseDTO := call("/readyz")
+		testhelpers.Equal(t, status, http.StatusOK)
+		testhelpers.Equal(t, responseDTO.Status, health.StatusOK)
+	})
+}
+
+func TestHealthEmbeddedMigrations(t *testing.T) {
+	// последняя миграция в migrations/ - версия, которую ждет бинарник
+	version, err := database.LatestMigrationVersion()
+	if err != nil {
+		t.Fatalf("Failed to read embedded migrations: %v", err)
+	}
+	testhelpers.Equal(t, version, uint(8))
+}
+
+func TestHealthHeartbeat(t *testing.T) {
+	heartbeat := health.NewHeartbeat("worker", 20*time.Millisecond)
+
+	// до первого шага горутины проверка проходит
+	testhelpers.Equal(t, heartbeat.Alive(t.Context()), nil)
+
+	// горутина перестала отмечаться
+	time.Sleep(40 * time.Millisecond)
+	if err := heartbeat.Alive(t.Context()); err == nil {
+		t.Errorf("stale heartbeat must fail the check")
+	}
+
+	heartbeat.Beat()
+	testhelpers.Equal(t, heartbeat.Alive(t.Context()), nil)
+
+	// горутина завершилась
+	heartbeat.Stop()
+	testhelpers.Equal(t, errors.Is(heartbeat.Alive(t.Context()), health.ErrWorkerStopped), true)
+
+	// имя горутины - имя проверки в ответе /readyz
+	responseDTO := (&health.Checker{Checks: []health.Check{heartbeat.Check()}}).Run(t.Context())
+	testhelpers.Equal(t, responseDTO.Status, health.StatusFail)
+	testhelpers.Equal(t, responseDTO.Checks["worker"].Error, health.ErrWorkerStopped.Error())
+}
+
+func TestHealthWorkerHeartbeats(t *testing.T) {
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	limits, err := ratelimit.NewLimits("10,20", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]string{"kty": "oct", "kid": "hs-1", "k": b64.EncodeToString([]byte("secret"))})
+	keySet, err := auth.NewKeySet(path, time.Minute, lgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := tracing.NewTracer(&memoryExporter{}, 1, lgr)
+
+	// пока горутины работают, проверки проходят
+	ctx, cancel := context.WithCancel(t.Context())
+	stopped := make(chan struct{}, 2)
+	go func() { limits.RunSweeper(ctx); stopped <- struct{}{} }()
+	go func() { keySet.Run(ctx); stopped <- struct{}{} }()
+
+	for _, heartbeat := range []*health.Heartbeat{limits.Heartbeat(), keySet.Heartbeat(), tracer.Heartbeat()} {
+		testhelpers.Equal(t, heartbeat.Alive(t.Context()), nil)
+	}
+
+	// после остановки горутин готовность не проходит
+	cancel()
+	<-stopped
+	<-stopped
+	shutdownCtx, shutdownCancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer shutdownCancel()
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, heartbeat := range []*health.Heartbeat{limits.Heartbeat(), keySet.Heartbeat(), tracer.Heartbeat()} {
+		testhelpers.Equal(t, errors.Is(heartbeat.Alive(t.Context()), health.ErrWorkerStopped), true)
+	}
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestImportHandler(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервисы
+	teamService := &service.TeamsService{
+		UsersRepository: usersRepository,
+		TeamsRepository: teamsRepository,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	importService := &service.ImportService{
+		TeamsService:    teamService,
+		TeamsRepository: teamsRepository,
+		UsersRepository: usersRepository,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	// создаем сам хендлер
+	importHandler := handlers.ImportHandlers{
+		ImportService: importService,
+	}
+
+	// третья строка содержит некорректный user_id
+	csvFile := `team_name,parent_team_name,user_id,username,is_active
+platform,,u20,Olga,true
+platform,,bad-id,Pavel,true
+payments,platform,u21,Rita,false
+`
+
+	t.Run("atomic import is rolled back on row error", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/import?format=csv&mode=atomic", strings.NewReader(csvFile))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		importHandler.Import(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseImportDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// импорт не должен быть зафиксирован
+		testhelpers.Equal(t, responseDTO.Committed, false)
+
+		// ошибка должна указывать на третью строку файла
+		testhelpers.Equal(t, len(responseDTO.Errors), 1)
+		testhelpers.Equal(t, responseDTO.Errors[0].Row, 3)
+		testhelpers.Equal(t, responseDTO.Errors[0].Code, "WRONG_DATA_INPUT")
+
+		// команда не должна появиться в БД
+		exists, err := teamsRepository.IsExist(context.Background(), "platform")
+		if err != nil {
+			t.Fatalf("Failed to check team existence: %v", err)
+		}
+		testhelpers.Equal(t, exists, false)
+	})
+
+	t.Run("best effort import skips bad rows", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/import?format=csv&mode=best_effort", strings.NewReader(csvFile))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		importHandler.Import(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseImportDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// импорт должен быть зафиксирован без плохой строки
+		testhelpers.Equal(t, responseDTO.Committed, true)
+		testhelpers.Equal(t, responseDTO.TotalRows, 3)
+		testhelpers.Equal(t, responseDTO.ImportedRows, 2)
+		testhelpers.Equal(t, responseDTO.TeamsCreated, 2)
+		testhelpers.Equal(t, responseDTO.UsersCreated, 2)
+
+		// payments должна стать подкомандой platform
+		ancestors, err := teamsRepository.GetAncestors(context.Background(), "payments")
+		if err != nil {
+			t.Fatalf("Failed to get team ancestors: %v", err)
+		}
+		testhelpers.Equal(t, len(ancestors), 1)
+		testhelpers.Equal(t, ancestors[0], "platform")
+
+		// неактивный пользователь должен сохранить флаг
+		user, err := usersRepository.GetUserById(context.Background(), "u21")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.IsActive, false)
+		testhelpers.Equal(t, user.TeamName, "payments")
+	})
+
+	t.Run("existing user is updated from file", func(t *testing.T) {
+		// u21 уже есть в payments: становится активным, меняет имя и вступает в platform
+		request := httptest.NewRequest("POST", "/import?format=csv&mode=atomic", strings.NewReader(`team_name,user_id,username,is_active
+platform,u21,Rita Smirnova,true
+platform,u20,Olga,true
+`))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		importHandler.Import(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseImportDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// u20 не изменился и в users_updated не попадает
+		testhelpers.Equal(t, responseDTO.Committed, true)
+		testhelpers.Equal(t, responseDTO.UsersCreated, 0)
+		testhelpers.Equal(t, responseDTO.UsersUpdated, 1)
+		testhelpers.Equal(t, responseDTO.MembershipsAdded, 1)
+
+		user, err := usersRepository.GetUserById(context.Background(), "u21")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.Username, "Rita Smirnova")
+		testhelpers.Equal(t, user.IsActive, true)
+
+		// основная команда остается прежней
+		testhelpers.Equal(t, user.TeamName, "payments")
+		testhelpers.Equal(t, len(user.Teams), 2)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/import?format=xml", strings.NewReader("<teams/>"))
+		responseWriter := httptest.NewRecorder()
+
+		importHandler.Import(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,219 @@
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/testhelpers"
+)
+
+var b64 = base64.RawURLEncoding
+
+// signJWT собирает токен: для HS256 key - []byte секрет, для RS256 - *rsa.PrivateKey
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+
+	var signature []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case "RS256":
+		hash := sha256.Sum256([]byte(signingInput))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return signingInput + "." + b64.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "alg": "RS256",
+		"n": b64.EncodeToString(key.N.Bytes()),
+		"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("hs256-shared-secret")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", rsaKey), map[string]string{"kty": "oct", "kid": "hs-1", "k": b64.EncodeToString(secret)})
+
+	keySet, err := auth.NewKeySet(path, 10*time.Millisecond, lgr)
+	if err != nil {
+		t.Fatalf("Failed to load JWKS: %v", err)
+	}
+	go keySet.Run(t.Context())
+
+	now := time.Unix(1_700_000_000, 0)
+	authenticator := &auth.JWTAuthenticator{
+		Keys:     keySet,
+		Audience: "pr-service",
+		Now:      func() time.Time { return now },
+	}
+	// waitReload ждет, пока фоновое перечитывание JWKS не даст ответ want на токен
+	waitReload := func(t *testing.T, token string, want error) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			_, err := authenticator.Authenticate(context.Background(), token)
+			if errors.Is(err, want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("JWKS was not reloaded: got %v, want %v", err, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	claims := func(extra map[string]any) map[string]any {
+		base := map[string]any{"sub": "u1", "role": "user", "aud": "pr-service", "exp": now.Add(time.Hour).Unix()}
+		for k, v := range extra {
+			base[k] = v
+		}
+		return base
+	}
+
+	t.Run("RS256 and HS256 map claims to principal", func(t *testing.T) {
+		principal, err := authenticator.Authenticate(context.Background(), signJWT(t, "RS256", "rsa-1", rsaKey, claims(nil)))
+		if err != nil {
+			t.Fatalf("Failed to authenticate: %v", err)
+		}
+		testhelpers.Equal(t, principal.UserId, "u1")
+		testhelpers.Equal(t, principal.Role, auth.RoleUser)
+
+		// user_id имеет приоритет над sub
+		principal, err = authenticator.Authenticate(context.Background(), signJWT(t, "HS256", "hs-1", secret, claims(map[string]any{"user_id": "u7", "role": "admin"})))
+		if err != nil {
+			t.Fatalf("Failed to authenticate: %v", err)
+		}
+		testhelpers.Equal(t, principal.UserId, "u7")
+		testhelpers.Equal(t, principal.Role, auth.RoleAdmin)
+	})
+
+	t.Run("invalid tokens are rejected", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens := map[string]string{
+			"foreign key":     signJWT(t, "RS256", "rsa-1", otherKey, claims(nil)),
+			"wrong aud":       signJWT(t, "HS256", "hs-1", secret, claims(map[string]any{"aud": "other"})),
+			"unknown role":    signJWT(t, "HS256", "hs-1", secret, claims(map[string]any{"role": "root"})),
+			"user without id": signJWT(t, "HS256", "hs-1", secret, claims(map[string]any{"sub": ""})),
+			// открытый RSA ключ нельзя использовать как HMAC секрет
+			"alg confusion": signJWT(t, "HS256", "rsa-1", rsaKey.N.Bytes(), claims(nil)),
+			"not a jwt":     "static-token",
+		}
+		for name, token := range tokens {
+			if _, err := authenticator.Authenticate(context.Background(), token); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("%s: got %v, want ErrInvalidToken", name, err)
+			}
+		}
+	})
+
+	t.Run("token lifetime", func(t *testing.T) {
+		_, err := authenticator.Authenticate(context.Background(), signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})))
+		testhelpers.Equal(t, errors.Is(err, auth.ErrTokenExpired), true)
+
+		_, err = authenticator.Authenticate(context.Background(), signJWT(t, "RS256", "rsa-1", rsaKey, claims(map[string]any{"nbf": now.Add(time.Minute).Unix()})))
+		testhelpers.Equal(t, errors.Is(err, auth.ErrTokenNotYetValid), true)
+	})
+
+	t.Run("rotated JWKS is reloaded", func(t *testing.T) {
+		newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := signJWT(t, "RS256", "rsa-2", newKey, claims(nil))
+
+		if _, err := authenticator.Authenticate(context.Background(), token); err == nil {
+			t.Fatalf("token signed with unknown key must be rejected")
+		}
+
+		writeJWKS(t, path, rsaJWK("rsa-2", newKey))
+		// время изменения файла может совпасть до секунды, поэтому сдвигаем его явно
+		if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		// токен с новым ключом проходит после перечитывания файла
+		waitReload(t, token, nil)
+	})
+
+	t.Run("expired token answers TOKEN_EXPIRED", func(t *testing.T) {
+		handler := auth.Middleware(auth.Chain{&auth.StaticTokens{}, authenticator}, auth.Authenticated, "", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeJWKS(t, path, rsaJWK("rsa-3", newKey))
+		if err := os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		token := signJWT(t, "RS256", "rsa-3", newKey, claims(map[string]any{"exp": now.Unix()}))
+		waitReload(t, token, auth.ErrTokenExpired)
+
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		responseWriter := httptest.NewRecorder()
+
+		handler(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusUnauthorized)
+
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, responseDTO.Error.Code, "TOKEN_EXPIRED")
+	})
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pr-service/internal/logging"
+	"pr-service/internal/metrics"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/tracing"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	lgr := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	// хендлер пишет в лог как сервисный слой: через логгер из контекста
+	router := http.NewServeMux()
+	router.HandleFunc("GET /api/v1/teams/{name}", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context(), nil).Info("service line")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})
+	handler := logging.Middleware(lgr, router)
+
+	call := func(requestId string) (*http.Response, []map[string]any) {
+		logs.Reset()
+
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/teams/backend", nil)
+		if requestId != "" {
+			request.Header.Set(logging.RequestIDHeader, requestId)
+		}
+		responseWriter := httptest.NewRecorder()
+
+		handler.ServeHTTP(responseWriter, request)
+
+		// строки лога в формате JSON
+		lines := []map[string]any{}
+		scanner := bufio.NewScanner(&logs)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Failed to parse log line: %v", err)
+			}
+			lines = append(lines, line)
+		}
+		return responseWriter.Result(), lines
+	}
+
+	t.Run("request id is propagated to response and every log line", func(t *testing.T) {
+		responseResult, lines := call("req-42")
+		testhelpers.Equal(t, responseResult.Header.Get(logging.RequestIDHeader), "req-42")
+
+		testhelpers.Equal(t, len(lines), 2)
+		for _, line := range lines {
+			testhelpers.Equal(t, line["request_id"], any("req-42"))
+		}
+
+		// строка access log
+		accessLine := lines[1]
+		testhelpers.Equal(t, accessLine["method"], any("GET"))
+		testhelpers.Equal(t, accessLine["route"], any("GET /api/v1/teams/{name}"))
+		testhelpers.Equal(t, accessLine["status"], any(float64(http.StatusTeapot)))
+		testhelpers.Equal(t, accessLine["bytes"], any(float64(5)))
+		if _, ok := accessLine["latency_ms"]; !ok {
+			t.Errorf("access log must contain latency_ms")
+		}
+	})
+
+	t.Run("request id is generated when missing or invalid", func(t *testing.T) {
+		for _, requestId := range []string{"", "bad id", strings.Repeat("x", 200)} {
+			responseResult, lines := call(requestId)
+
+			generated := responseResult.Header.Get(logging.RequestIDHeader)
+			if generated == "" || generated == requestId {
+				t.Errorf("request id %q must be replaced, got %q", requestId, generated)
+			}
+			testhelpers.Equal(t, lines[0]["request_id"], any(generated))
+		}
+	})
+
+	t.Run("middleware chain shares one recorder", func(t *testing.T) {
+		registry := metrics.NewRegistry(lgr)
+		tracer := tracing.NewTracer(&memoryExporter{}, 1, lgr)
+		defer tracer.Shutdown(context.Background())
+
+		// хендлер получает recorder внешнего middleware, а под ним - исходный writer
+		var wrapped int
+		inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for writer := w; ; wrapped++ {
+				unwrapper, ok := writer.(interface{ Unwrap() http.ResponseWriter })
+				if !ok {
+					break
+				}
+				writer = unwrapper.Unwrap()
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+		chain := logging.Middleware(lgr, metrics.Middleware(registry, tracer.Middleware(inner)))
+
+		responseWriter := httptest.NewRecorder()
+		chain.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		testhelpers.Equal(t, responseWriter.Code, http.StatusNoContent)
+		testhelpers.Equal(t, wrapped, 1)
+	})
+
+	t.Run("logger falls back outside of requests", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		testhelpers.Equal(t, logging.FromContext(request.Context(), lgr), lgr)
+		testhelpers.Equal(t, logging.RequestID(request.Context()), "")
+	})
+}
@@ -0,0 +1,529 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"pr-service/internal/app"
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/enums"
+	"pr-service/internal/handlers"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+	"pr-service/internal/repository/memory"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+)
+
+func TestMemoryStoreTransactions(t *testing.T) {
+	ctx := t.Context()
+	store := memory.NewStore()
+	teamsRepository := &memory.TeamsRepository{Store: store}
+	transactor := &repository.Transactor{Db: store.DB()}
+	errRollback := errors.New("rollback")
+
+	teamExists := func(teamName string) bool {
+		t.Helper()
+		isExist, err := teamsRepository.IsExist(ctx, teamName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return isExist
+	}
+
+	t.Run("rollback discards changes", func(t *testing.T) {
+		var txCtx context.Context
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			txCtx = ctx
+			if err := teamsRepository.AddTeam(ctx, "rolled-back"); err != nil {
+				return err
+			}
+
+			// до коммита изменения видны только транзакции
+			testhelpers.Equal(t, teamExists("rolled-back"), false)
+			return errRollback
+		})
+		testhelpers.Equal(t, errors.Is(err, errRollback), true)
+		testhelpers.Equal(t, teamExists("rolled-back"), false)
+
+		// завершенную транзакцию использовать нельзя
+		if err := teamsRepository.AddTeam(txCtx, "late"); err == nil {
+			t.Errorf("write in a finished transaction must fail")
+		}
+	})
+
+	t.Run("commit publishes changes", func(t *testing.T) {
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			return teamsRepository.AddTeam(ctx, "committed")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, teamExists("committed"), true)
+
+		err = teamsRepository.AddTeam(ctx, "committed")
+		testhelpers.Equal(t, errors.Is(err, repository.ErrDuplicatedTeamName), true)
+	})
+
+	t.Run("savepoints", func(t *testing.T) {
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			if err := teamsRepository.AddTeam(ctx, "kept"); err != nil {
+				return err
+			}
+			if err := repository.Savepoint(ctx, "row"); err != nil {
+				return err
+			}
+			if err := teamsRepository.AddTeam(ctx, "undone"); err != nil {
+				return err
+			}
+			if err := repository.RollbackToSavepoint(ctx, "row"); err != nil {
+				return err
+			}
+			return repository.ReleaseSavepoint(ctx, "row")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testhelpers.Equal(t, teamExists("kept"), true)
+		testhelpers.Equal(t, teamExists("undone"), false)
+
+		// вне транзакции точки сохранения не создаются
+		if err := repository.Savepoint(ctx, "row"); err == nil {
+			t.Errorf("savepoint outside of a transaction must fail")
+		}
+	})
+
+	t.Run("write outside a transaction survives its rollback", func(t *testing.T) {
+		err := transactor.WithTx(ctx, nil, func(txCtx context.Context) error {
+			// ctx теста не несет транзакцию, запись в нем выполняется сразу
+			if err := teamsRepository.AddTeam(ctx, "autocommit"); err != nil {
+				return err
+			}
+
+			// транзакция видит запись, сделанную без нее
+			teams, err := teamsRepository.GetAllTeams(txCtx)
+			if err != nil {
+				return err
+			}
+			testhelpers.Equal(t, slices.ContainsFunc(teams, func(team *models.TeamModel) bool { return team.TeamName == "autocommit" }), true)
+			return errRollback
+		})
+		testhelpers.Equal(t, errors.Is(err, errRollback), true)
+		testhelpers.Equal(t, teamExists("autocommit"), true)
+	})
+
+	t.Run("write outside a transaction conflicting with it fails", func(t *testing.T) {
+		err := transactor.WithTx(ctx, nil, func(txCtx context.Context) error {
+			if err := teamsRepository.AddTeam(txCtx, "contested"); err != nil {
+				return err
+			}
+
+			// в подтвержденных данных команды нет, но в транзакции она уже создана
+			err := teamsRepository.AddTeam(ctx, "contested")
+			testhelpers.Equal(t, errors.Is(err, repository.ErrDuplicatedTeamName), true)
+			testhelpers.Equal(t, teamExists("contested"), false)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, teamExists("contested"), true)
+	})
+
+	t.Run("second transaction without the outer one waits for the slot", func(t *testing.T) {
+		err := transactor.WithTx(ctx, nil, func(txCtx context.Context) error {
+			// контекст без транзакции не присоединяется к внешней, а ждет ее окончания
+			waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			err := transactor.WithTx(waitCtx, nil, func(ctx context.Context) error {
+				return nil
+			})
+			testhelpers.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("foreign keys", func(t *testing.T) {
+		usersRepository := &memory.UsersRepository{Store: store}
+		err := usersRepository.AddUser(ctx, &models.UserModel{Id: "u1", Username: "Alice", TeamName: "missing", IsActive: true})
+		if err == nil {
+			t.Errorf("user in an unknown team must be rejected")
+		}
+
+		isExist, err := usersRepository.IsExist(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, isExist, false)
+	})
+}
+
+func TestMemoryBackend(t *testing.T) {
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	services := app.NewServices(app.MemoryRepositories(memory.NewStore()), lgr, nil, nil)
+
+	restored, err := app.LoadFixture(t.Context(), services.Snapshot, "../fixtures/demo.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	testhelpers.Equal(t, restored.Teams, 3)
+	testhelpers.Equal(t, restored.PullRequests, 2)
+
+	authenticator, err := auth.NewStaticTokens("admin-token=admin")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+	router := routes.NewRouter(authenticator, nil, nil, true, &handlers.TeamsHandlers{TeamService: services.Teams},
+		&handlers.UsersHandlers{UserService: services.Users}, &handlers.PullRequestsHandlers{PullRequestService: services.PullRequests},
+		&handlers.StatsHandlers{StatsService: services.Stats}, &handlers.ImportHandlers{ImportService: services.Import},
+		&handlers.AdminHandlers{SnapshotService: services.Snapshot}, &handlers.APIKeysHandlers{APIKeysService: services.APIKeys},
+		&handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	call := func(method, path string, body any, response any) int {
+		t.Helper()
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// как и в net/http, контекст запроса отменяется после ответа: database/sql
+		// тогда откатывает транзакцию, которую сервис не завершил
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		request := httptest.NewRequestWithContext(ctx, method, path, bytes.NewReader(b))
+		request.Header.Set("Authorization", "Bearer admin-token")
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		if response != nil {
+			if err := json.NewDecoder(responseWriter.Body).Decode(response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+		}
+		return responseWriter.Code
+	}
+
+	// ревьюверы - активные участники backend, кроме автора
+	var created dto.ResponsePullrequestDTO
+	code := call(http.MethodPost, "/api/v1/pull-requests", dto.RequestPullrequestDTO{
+		PullRequestId:   "pr-3",
+		PullRequestName: "Cache team tree",
+		AuthorID:        "u2",
+	}, &created)
+	testhelpers.Equal(t, code, http.StatusCreated)
+	slices.Sort(created.PR.AssignedReviewers)
+	testhelpers.Equal(t, slices.Equal(created.PR.AssignedReviewers, []string{"u1", "u3"}), true)
+
+	code = call(http.MethodPost, "/api/v1/pull-requests", dto.RequestPullrequestDTO{
+		PullRequestId:   "pr-1",
+		PullRequestName: "Duplicate",
+		AuthorID:        "u2",
+	}, nil)
+	testhelpers.Equal(t, code, http.StatusConflict)
+
+	var merged dto.ResponsePullrequestDTO
+	code = call(http.MethodPost, "/api/v1/pull-requests/pr-3/merge", nil, &merged)
+	testhelpers.Equal(t, code, http.StatusOK)
+	testhelpers.Equal(t, merged.PR.Status, enums.MERGED)
+
+	var stats dto.StatsResponseDTO
+	code = call(http.MethodGet, "/api/v1/stats", nil, &stats)
+	testhelpers.Equal(t, code, http.StatusOK)
+	testhelpers.Equal(t, stats.TotalPRs, 3)
+	testhelpers.Equal(t, stats.PRsByStatus[enums.MERGED], 2)
+
+	// снимок из памяти совпадает по формату с фикстурой
+	snapshot, err := services.Snapshot.Export(t.Context())
+	if err != nil {
+		t.Fatalf("Failed to export snapshot: %v", err)
+	}
+	testhelpers.Equal(t, len(snapshot.PullRequests), 3)
+	testhelpers.Equal(t, len(snapshot.History), 5+3)
+
+	t.Run("fixture needs an empty storage", func(t *testing.T) {
+		_, err := app.LoadFixture(t.Context(), services.Snapshot, "../fixtures/demo.json")
+		testhelpers.Equal(t, errors.Is(err, service.ErrDatabaseNotEmpty), true)
+	})
+}
+
+func TestMemoryConcurrentReassignAndMerge(t *testing.T) {
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hammerPullRequest(t, app.NewServices(app.MemoryRepositories(memory.NewStore()), lgr, nil, nil))
+}
+
+func TestMemoryDeleteUserIsAtomic(t *testing.T) {
+	ctx := t.Context()
+	repositories := app.MemoryRepositories(memory.NewStore())
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	services := app.NewServices(repositories, lgr, nil, nil)
+
+	// x ревьюит pr-a в wide, где есть замена, и pr-b в narrow, где замены нет
+	for teamName, members := range map[string][]string{"wide": {"a", "x", "y", "z"}, "narrow": {"b", "n"}} {
+		team := &dto.TeamDTO{TeamName: teamName}
+		for _, userId := range members {
+			team.Members = append(team.Members, &dto.TeamMemberDTO{UserId: userId, Username: userId, IsActive: true})
+		}
+		if _, err := services.Teams.AddTeamWithMembers(ctx, team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repositories.Users.AddMembership(ctx, "narrow", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	// на время создания pr-a в wide активен только x
+	for _, userId := range []string{"y", "z"} {
+		if err := repositories.Users.UpdateUserIsActive(ctx, userId, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pullRequest := range []*dto.RequestPullrequestDTO{
+		{PullRequestId: "pr-a", PullRequestName: "A", AuthorID: "a"},
+		{PullRequestId: "pr-b", PullRequestName: "B", AuthorID: "b"},
+	} {
+		if _, err := services.PullRequests.AddPullRequest(ctx, pullRequest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, userId := range []string{"y", "z"} {
+		if err := repositories.Users.UpdateUserIsActive(ctx, userId, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// pr-a переназначается первым, pr-b - уже без кандидатов
+	_, err := services.Users.DeleteUser(ctx, &dto.RequestDeleteUserDTO{UserId: "x", ReassignReviews: true})
+	testhelpers.Equal(t, errors.Is(err, service.ErrNoReviewrsToAssign), true)
+
+	// переназначение pr-a откатилось вместе с удалением
+	isExist, err := repositories.Users.IsExist(ctx, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, isExist, true)
+
+	pullRequestIds, err := repositories.Reviewers.GetPullRequestIDsWithReviewersByUserId(ctx, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, slices.Equal(pullRequestIds, []string{"pr-a", "pr-b"}), true)
+}
+
+func TestMemoryImportJSONDefaultsToActive(t *testing.T) {
+	ctx := t.Context()
+	repositories := app.MemoryRepositories(memory.NewStore())
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	services := app.NewServices(repositories, lgr, nil, nil)
+
+	// u2 уже есть и активен, в файле у него нет is_active, как и у нового u1
+	if _, err := services.Teams.AddTeamWithMembers(ctx, &dto.TeamDTO{
+		TeamName: "payments",
+		Members:  []*dto.TeamMemberDTO{{UserId: "u2", Username: "Bob", IsActive: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := services.Import.Import(ctx, "json", service.ImportModeAtomic, bytes.NewReader([]byte(`{"teams": [
+		{"team_name": "platform", "members": [
+			{"user_id": "u1", "username": "Alice"},
+			{"user_id": "u2", "username": "Bob"},
+			{"user_id": "u3", "username": "Carol", "is_active": false}
+		]}
+	]}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, report.Committed, true)
+
+	// без is_active пользователь активен, как в CSV, и существующий не деактивируется
+	for userId, isActive := range map[string]bool{"u1": true, "u2": true, "u3": false} {
+		user, err := repositories.Users.GetUserById(ctx, userId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, user.IsActive, isActive)
+	}
+	testhelpers.Equal(t, report.UsersUpdated, 0)
+}
+
+func TestMemoryServiceAuthorization(t *testing.T) {
+	ctx := t.Context()
+	repositories := app.MemoryRepositories(memory.NewStore())
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	services := app.NewServices(repositories, lgr, nil, nil)
+
+	// u1 - лид backend, u3 состоит только в platform
+	for teamName, members := range map[string][]string{"backend": {"u1", "u2"}, "platform": {"u3"}} {
+		team := &dto.TeamDTO{TeamName: teamName}
+		for _, userId := range members {
+			team.Members = append(team.Members, &dto.TeamMemberDTO{UserId: userId, Username: userId, IsActive: true})
+		}
+		if _, err := services.Teams.AddTeamWithMembers(ctx, team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repositories.Teams.AddTeamLead(ctx, "backend", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	lead := auth.WithPrincipal(ctx, &auth.Principal{UserId: "u1", Role: auth.RoleUser})
+	username := "Bob"
+
+	for _, test := range []struct {
+		name    string
+		call    func(ctx context.Context) error
+		allowed bool
+	}{
+		{
+			name: "lead gets member of own team",
+			call: func(ctx context.Context) error {
+				_, err := services.Users.GetUser(ctx, "u2")
+				return err
+			},
+			allowed: true,
+		},
+		{
+			name: "lead gets user of other team",
+			call: func(ctx context.Context) error {
+				_, err := services.Users.GetUser(ctx, "u3")
+				return err
+			},
+		},
+		{
+			name: "lead updates user",
+			call: func(ctx context.Context) error {
+				_, err := services.Users.UpdateUser(ctx, &dto.RequestUpdateUserDTO{UserId: "u2", Username: &username})
+				return err
+			},
+		},
+		{
+			name: "lead deletes user",
+			call: func(ctx context.Context) error {
+				_, err := services.Users.DeleteUser(ctx, &dto.RequestDeleteUserDTO{UserId: "u2"})
+				return err
+			},
+		},
+		{
+			name: "lead gets own team subtree",
+			call: func(ctx context.Context) error {
+				_, err := services.Teams.GetTeamTree(ctx, "backend")
+				return err
+			},
+			allowed: true,
+		},
+		{
+			name: "lead gets other team subtree",
+			call: func(ctx context.Context) error {
+				_, err := services.Teams.GetTeamTree(ctx, "platform")
+				return err
+			},
+		},
+		{
+			name: "lead gets whole tree",
+			call: func(ctx context.Context) error {
+				_, err := services.Teams.GetTeamTree(ctx, "")
+				return err
+			},
+		},
+		{
+			name: "lead sets parent of own team",
+			call: func(ctx context.Context) error {
+				_, err := services.Teams.SetParentTeam(ctx, &dto.RequestSetParentDTO{TeamName: "backend", ParentTeamName: "platform"})
+				return err
+			},
+		},
+		{
+			name: "lead lists teams",
+			call: func(ctx context.Context) error {
+				_, err := services.Teams.ListTeams(ctx)
+				return err
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.call(lead)
+			if test.allowed {
+				testhelpers.Equal(t, err, nil)
+				return
+			}
+			testhelpers.Equal(t, errors.Is(err, service.ErrForbidden), true)
+		})
+	}
+
+	// отказ ничего не изменил
+	user, err := repositories.Users.GetUserById(ctx, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, user.Username, "u2")
+
+	// администратору доступно то же самое
+	admin := auth.WithPrincipal(ctx, &auth.Principal{UserId: "admin", Role: auth.RoleAdmin})
+	if _, err := services.Users.UpdateUser(admin, &dto.RequestUpdateUserDTO{UserId: "u2", Username: &username}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.Teams.SetParentTeam(admin, &dto.RequestSetParentDTO{TeamName: "backend", ParentTeamName: "platform"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryDeleteUserRemovesMembershipAndLeads(t *testing.T) {
+	ctx := t.Context()
+	repositories := app.MemoryRepositories(memory.NewStore())
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	services := app.NewServices(repositories, lgr, nil, nil)
+
+	if _, err := services.Teams.AddTeamWithMembers(ctx, &dto.TeamDTO{
+		TeamName: "backend",
+		Members: []*dto.TeamMemberDTO{
+			{UserId: "u1", Username: "Alice", IsActive: true},
+			{UserId: "u2", Username: "Bob", IsActive: true},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repositories.Teams.AddTeamLead(ctx, "backend", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := services.Users.DeleteUser(ctx, &dto.RequestDeleteUserDTO{UserId: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// права лида не переживают удаление
+	ledTeams, err := repositories.Teams.GetLedTeams(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, len(ledTeams), 0)
+
+	members, err := repositories.Users.GetUsersByTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.Equal(t, len(members), 1)
+
+	// удаленного пользователя нельзя снова активировать
+	err = repositories.Users.UpdateUserIsActive(ctx, "u1", true)
+	testhelpers.Equal(t, errors.Is(err, repository.ErrNoRecord), true)
+
+	_, err = services.Users.SetIsActiveById(ctx, &dto.IsActiveUserDTO{Id: "u1", IsActive: true})
+	testhelpers.Equal(t, errors.Is(err, service.ErrNoResourse), true)
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/handlers"
+	"pr-service/internal/metrics"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/testhelpers"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := metrics.NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	counter := registry.NewCounter("test_events_total", "Events.", "kind")
+	counter.Inc("b")
+	counter.Add(2, `a"quoted"`)
+
+	histogram := registry.NewHistogram("test_duration_seconds", "Duration.", []float64{0.1, 1})
+	histogram.Observe(0.1)
+	histogram.Observe(0.5)
+	histogram.Observe(3)
+
+	registry.NewGaugeFunc("test_open", "Open items.", []string{"team"}, func(ctx context.Context) ([]metrics.Sample, error) {
+		return []metrics.Sample{{LabelValues: []string{"backend"}, Value: 4}}, nil
+	})
+	// недоступный источник не должен ломать остальные метрики
+	registry.NewGaugeFunc("test_broken", "Broken source.", nil, func(ctx context.Context) ([]metrics.Sample, error) {
+		return nil, errors.New("db is down")
+	})
+
+	var out bytes.Buffer
+	if err := registry.Write(context.Background(), &out); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+
+	expected := `# HELP test_events_total Events.
+# TYPE test_events_total counter
+test_events_total{kind="a\"quoted\""} 2
+test_events_total{kind="b"} 1
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 3.6
+test_duration_seconds_count 3
+# HELP test_open Open items.
+# TYPE test_open gauge
+test_open{team="backend"} 4
+`
+	testhelpers.Equal(t, out.String(), expected)
+
+	t.Run("nil registry disables metrics", func(t *testing.T) {
+		var registry *metrics.Registry
+		registry.NewCounter("test_total", "Test.").Inc()
+		metrics.NewReviews(registry).Assigned(2)
+		if err := registry.Write(context.Background(), io.Discard); err != nil {
+			t.Errorf("nil registry must write nothing, got %v", err)
+		}
+	})
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	registry := metrics.NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	reviews := metrics.NewReviews(registry)
+	reviews.Assigned(2)
+	reviews.NoCandidate()
+
+	authenticator, err := auth.NewStaticTokens("admin-token=admin,user-token=user:u1")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+
+	router := routes.NewRouter(authenticator, nil, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{Registry: registry}, &handlers.HealthHandlers{})
+	handler := metrics.Middleware(registry, router)
+
+	call := func(path, token string) (*http.Response, string) {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		responseWriter := httptest.NewRecorder()
+
+		handler.ServeHTTP(responseWriter, request)
+		return responseWriter.Result(), responseWriter.Body.String()
+	}
+
+	// обычный пользователь метрики не видит
+	responseResult, _ := call("/metrics", "user-token")
+	testhelpers.Equal(t, responseResult.StatusCode, http.StatusForbidden)
+	call("/no-such-path", "")
+
+	responseResult, body := call("/metrics", "admin-token")
+	testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+	testhelpers.Equal(t, responseResult.Header.Get("Content-Type"), metrics.ContentType)
+
+	for _, line := range []string{
+		"pr_service_reviewer_assignments_total 2",
+		"pr_service_no_candidate_total 1",
+		"pr_service_reviewer_reassignments_total 0",
+		`pr_service_http_requests_total{route="GET /metrics",status="403"} 1`,
+		`pr_service_http_requests_total{route="unmatched",status="404"} 1`,
+		`pr_service_http_request_duration_seconds_count{route="GET /metrics",status="403"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics must contain %q, got:\n%s", line, body)
+		}
+	}
+}
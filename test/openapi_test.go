@@ -0,0 +1,172 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/openapi"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/testhelpers"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Security      *[]any `json:"security"`
+			RequiredRole  string `json:"x-required-role"`
+			RequiredScope string `json:"x-required-scope"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Enum          []string            `json:"enum"`
+				ServiceErrors map[string][]string `json:"x-service-errors"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &spec); err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+
+	t.Run("every registered route is documented", func(t *testing.T) {
+		registered := routes.Routes(&handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+			&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+		for _, route := range registered {
+			operation, ok := spec.Paths[route.Path][strings.ToLower(route.Method)]
+			if !ok {
+				t.Errorf("route %s %s is missing in openapi.json", route.Method, route.Path)
+				continue
+			}
+
+			// уровень доступа в спецификации должен совпадать с маршрутом
+			requiredRole := map[auth.Access]string{auth.Authenticated: auth.RoleUser, auth.AdminOnly: auth.RoleAdmin}[route.Access]
+			isPublic := operation.Security != nil && len(*operation.Security) == 0
+			if operation.RequiredRole != requiredRole || isPublic != (route.Access == auth.Public) {
+				t.Errorf("route %s %s has wrong access in openapi.json", route.Method, route.Path)
+			}
+
+			// область ключа API тоже
+			if operation.RequiredScope != route.Scope {
+				t.Errorf("route %s %s has wrong scope in openapi.json", route.Method, route.Path)
+			}
+		}
+	})
+
+	t.Run("every error code is documented", func(t *testing.T) {
+		files, err := filepath.Glob("../internal/handlers/*.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		authFiles, err := filepath.Glob("../internal/auth/*.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, authFiles...)
+		files = append(files, "../internal/ratelimit/ratelimit.go")
+
+		codes := spec.Components.Schemas["ErrorCode"].Enum
+		codeRe := regexp.MustCompile(`WriteErrorReponse\(w, http\.\w+, "([A-Z_]+)"`)
+		for _, file := range files {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, match := range codeRe.FindAllStringSubmatch(string(source), -1) {
+				if !slices.Contains(codes, match[1]) {
+					t.Errorf("error code %s from %s is missing in openapi.json", match[1], filepath.Base(file))
+				}
+			}
+		}
+	})
+
+	t.Run("every service error is mapped to a code", func(t *testing.T) {
+		source, err := os.ReadFile("../internal/service/errors.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		documented := []string{}
+		for _, serviceErrors := range spec.Components.Schemas["ErrorCode"].ServiceErrors {
+			documented = append(documented, serviceErrors...)
+		}
+
+		for _, match := range regexp.MustCompile(`(?m)^\s+(Err\w+)\s+=`).FindAllStringSubmatch(string(source), -1) {
+			if !slices.Contains(documented, match[1]) {
+				t.Errorf("service error %s is missing in openapi.json", match[1])
+			}
+		}
+	})
+}
+
+func TestValidateRequests(t *testing.T) {
+	// хендлер-заглушка отвечает 200, если запрос прошел проверку
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	t.Run("valid body passes", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/pullRequest/merge", strings.NewReader(`{"pull_request_id": "pr-1001"}`))
+		responseWriter := httptest.NewRecorder()
+
+		openapi.ValidateRoute("POST", "/pullRequest/merge", ok)(responseWriter, request)
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseWriter.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("misspelled field is rejected", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/users/setIsActive", strings.NewReader(`{"user_id": "u1", "isActive": false}`))
+		responseWriter := httptest.NewRecorder()
+
+		openapi.ValidateRoute("POST", "/users/setIsActive", ok)(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+
+		// десереализируем ответ
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// в сообщении должны быть и лишнее, и пропущенное поле
+		testhelpers.Equal(t, responseDTO.Error.Code, "WRONG_DATA_INPUT")
+		testhelpers.Equal(t, responseDTO.Error.Message, "body.is_active: is required; body.isActive: unknown field")
+	})
+
+	t.Run("body over 1 MiB is rejected", func(t *testing.T) {
+		body := `{"pull_request_id": "` + strings.Repeat("x", 1<<20) + `"}`
+		request := httptest.NewRequest("POST", "/pullRequest/merge", strings.NewReader(body))
+		responseWriter := httptest.NewRecorder()
+
+		openapi.ValidateRoute("POST", "/pullRequest/merge", ok)(responseWriter, request)
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseWriter.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("anonymous requests are not validated", func(t *testing.T) {
+		router := routes.NewRouter(&auth.StaticTokens{}, nil, nil, true, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+			&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+		request := httptest.NewRequest("POST", "/users/setIsActive", strings.NewReader(`{"isActive": false}`))
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+
+		// без токена ответ 401, а не подробности схемы
+		testhelpers.Equal(t, responseWriter.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"pr-service/internal/app"
+	"pr-service/internal/dto"
+	"pr-service/internal/enums"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestConcurrentReassignAndMerge(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	hammerPullRequest(t, app.NewServices(app.PostgresRepositories(db), lgr, nil, nil))
+}
+
+// hammerPullRequest переназначает ревьюверов одного pr из многих горутин, одна из них
+// посередине сливает pr. После этого история pr должна сходиться с итоговыми ревьюверами
+func hammerPullRequest(t *testing.T, services *app.Services) {
+	const (
+		workers = 16
+		rounds  = 10
+		author  = "race-u1"
+	)
+	ctx := t.Context()
+
+	members := []*dto.TeamMemberDTO{}
+	for i := 1; i <= 8; i++ {
+		members = append(members, &dto.TeamMemberDTO{UserId: fmt.Sprintf("race-u%d", i), Username: fmt.Sprintf("Racer %d", i), IsActive: true})
+	}
+	if _, err := services.Teams.AddTeamWithMembers(ctx, &dto.TeamDTO{TeamName: "race-team", Members: members}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.PullRequests.AddPullRequest(ctx, &dto.RequestPullrequestDTO{
+		PullRequestId:   "pr-race",
+		PullRequestName: "Race",
+		AuthorID:        author,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var reassigned atomic.Int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for worker := range workers {
+		wg.Go(func() {
+			<-start
+			for round := range rounds {
+				// первая горутина сливает pr, пока остальные еще переназначают
+				if worker == 0 && round == rounds/2 {
+					if _, err := services.PullRequests.MergePullRequest(ctx, "pr-race"); err != nil {
+						t.Errorf("merge: %v", err)
+					}
+					continue
+				}
+
+				// старый ревьювер угадывается, неверная догадка - ожидаемая ошибка
+				_, err := services.PullRequests.ReassignReviewer(ctx, &dto.RequestReassignDTO{
+					PullRequestId: "pr-race",
+					OldUserId:     members[1+rand.IntN(len(members)-1)].UserId,
+				})
+				switch {
+				case err == nil:
+					reassigned.Add(1)
+				case errors.Is(err, service.ErrNoSuchReviewer), errors.Is(err, service.ErrPrMerged):
+				default:
+					t.Errorf("reassign: %v", err)
+				}
+			}
+		})
+	}
+	close(start)
+	wg.Wait()
+
+	snapshot, err := services.Snapshot.Export(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pullRequest := range snapshot.PullRequests {
+		if pullRequest.PullRequestId == "pr-race" {
+			testhelpers.Equal(t, pullRequest.Status, enums.MERGED)
+		}
+	}
+
+	// итоговые ревьюверы: двое разных, не автор
+	final := []string{}
+	for _, reviewer := range snapshot.Reviewers {
+		if reviewer.PullRequestId == "pr-race" {
+			final = append(final, reviewer.UserId)
+		}
+	}
+	slices.Sort(final)
+	testhelpers.Equal(t, len(final), 2)
+	testhelpers.Equal(t, len(slices.Compact(slices.Clone(final))), 2)
+	testhelpers.Equal(t, slices.Contains(final, author), false)
+
+	// история воспроизводится шаг за шагом: заменяется только текущий ревьювер,
+	// новый ревьювер еще не назначен, после MERGED событий нет
+	current := map[string]bool{}
+	reassignedEvents, merged := 0, false
+	for _, event := range snapshot.History {
+		if event.PullRequestId != "pr-race" {
+			continue
+		}
+		if merged {
+			t.Errorf("event %s after merge", event.EventType)
+		}
+
+		switch event.EventType {
+		case enums.EVENT_ASSIGNED:
+			current[event.UserId] = true
+		case enums.EVENT_REASSIGNED:
+			if !current[event.OldUserId] || current[event.UserId] || event.UserId == author {
+				t.Errorf("inconsistent reassignment %s -> %s, reviewers %v", event.OldUserId, event.UserId, current)
+			}
+			delete(current, event.OldUserId)
+			current[event.UserId] = true
+			reassignedEvents++
+		case enums.EVENT_MERGED:
+			merged = true
+		}
+	}
+	testhelpers.Equal(t, merged, true)
+	testhelpers.Equal(t, int64(reassignedEvents), reassigned.Load())
+
+	replayed := []string{}
+	for userId := range current {
+		replayed = append(replayed, userId)
+	}
+	slices.Sort(replayed)
+	testhelpers.Equal(t, slices.Equal(replayed, final), true)
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/testhelpers"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewLimiter(ratelimit.Budget{Rate: 2, Burst: 3})
+	limiter.Now = func() time.Time { return now }
+
+	// запас расходуется подряд, дальше нужно ждать пополнения
+	for i := 0; i < 3; i++ {
+		ok, _ := limiter.Allow("user:u1")
+		testhelpers.Equal(t, ok, true)
+	}
+	ok, wait := limiter.Allow("user:u1")
+	testhelpers.Equal(t, ok, false)
+	testhelpers.Equal(t, wait, 500*time.Millisecond)
+
+	// у другого клиента своя корзина
+	ok, _ = limiter.Allow("user:u2")
+	testhelpers.Equal(t, ok, true)
+
+	// за полсекунды появляется один токен
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.Allow("user:u1")
+	testhelpers.Equal(t, ok, true)
+	ok, _ = limiter.Allow("user:u1")
+	testhelpers.Equal(t, ok, false)
+
+	t.Run("budget parsing", func(t *testing.T) {
+		budget, err := ratelimit.ParseBudget("0.5, 10")
+		if err != nil {
+			t.Fatalf("Failed to parse budget: %v", err)
+		}
+		testhelpers.Equal(t, *budget, ratelimit.Budget{Rate: 0.5, Burst: 10})
+
+		budget, err = ratelimit.ParseBudget("")
+		testhelpers.Equal(t, budget == nil && err == nil, true)
+
+		for _, spec := range []string{"10", "0,1", "1,0", "x,1", "1,x"} {
+			if _, err := ratelimit.ParseBudget(spec); err == nil {
+				t.Errorf("spec %q must be rejected", spec)
+			}
+		}
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	authenticator, err := auth.NewStaticTokens("u1-token=user:u1,u2-token=user:u2")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+
+	limits := &ratelimit.Limits{
+		Read:  ratelimit.NewLimiter(ratelimit.Budget{Rate: 1, Burst: 1}),
+		Write: ratelimit.NewLimiter(ratelimit.Budget{Rate: 0.1, Burst: 1}),
+	}
+
+	// до сервисов запросы не доходят, поэтому хендлерам они не нужны
+	router := routes.NewRouter(authenticator, limits, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	call := func(method, path, token string) *http.Response {
+		request := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		return responseWriter.Result()
+	}
+
+	// пользователь u1 тратит бюджет записи, хендлер отвечает на пустое тело
+	testhelpers.Equal(t, call(http.MethodPost, "/pullRequest/reassign", "u1-token").StatusCode, http.StatusBadRequest)
+
+	responseResult := call(http.MethodPost, "/pullRequest/reassign", "u1-token")
+	testhelpers.Equal(t, responseResult.StatusCode, http.StatusTooManyRequests)
+	testhelpers.Equal(t, responseResult.Header.Get("Retry-After"), "10")
+
+	// тело ответа в формате ErrorResponseDTO
+	var responseDTO dto.ErrorResponseDTO
+	if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	testhelpers.Equal(t, responseDTO.Error.Code, "TOO_MANY_REQUESTS")
+
+	// бюджет чтения и бюджет другого пользователя не тронуты
+	testhelpers.Equal(t, call(http.MethodGet, "/users/getReview", "u1-token").StatusCode, http.StatusBadRequest)
+	testhelpers.Equal(t, call(http.MethodPost, "/pullRequest/reassign", "u2-token").StatusCode, http.StatusBadRequest)
+
+	// без токена клиент определяется по IP
+	testhelpers.Equal(t, call(http.MethodGet, "/openapi.json", "").StatusCode, http.StatusOK)
+	testhelpers.Equal(t, call(http.MethodGet, "/api/v1/openapi.json", "").StatusCode, http.StatusTooManyRequests)
+}
+
+// countingAuthenticator считает проверки токенов, которые дошли до аутентификатора
+type countingAuthenticator struct {
+	auth.Authenticator
+	calls int
+}
+
+func (ca *countingAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	ca.calls++
+	return ca.Authenticator.Authenticate(ctx, token)
+}
+
+func TestAuthFailuresLimit(t *testing.T) {
+	tokens, err := auth.NewStaticTokens("u1-token=user:u1")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+	authenticator := &countingAuthenticator{Authenticator: tokens}
+
+	limits := &ratelimit.Limits{
+		AuthFailures: ratelimit.NewLimiter(ratelimit.Budget{Rate: 0.1, Burst: 2}),
+	}
+
+	router := routes.NewRouter(authenticator, limits, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	call := func(path, token, remoteAddr string) *http.Response {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.RemoteAddr = remoteAddr
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		return responseWriter.Result()
+	}
+
+	// успешные запросы бюджет не тратят
+	for range 3 {
+		testhelpers.Equal(t, call("/users/getReview", "u1-token", "192.0.2.1:1234").StatusCode, http.StatusBadRequest)
+	}
+
+	// неверные токены и запросы без токена тратят бюджет IP адреса
+	testhelpers.Equal(t, call("/users/getReview", "guess-1", "192.0.2.1:1234").StatusCode, http.StatusUnauthorized)
+	testhelpers.Equal(t, call("/users/getReview", "", "192.0.2.1:1234").StatusCode, http.StatusUnauthorized)
+
+	// дальше запросы с этого адреса не доходят до проверки токена
+	calls := authenticator.calls
+	responseResult := call("/users/getReview", "guess-2", "192.0.2.1:1234")
+	testhelpers.Equal(t, responseResult.StatusCode, http.StatusTooManyRequests)
+	testhelpers.Equal(t, responseResult.Header.Get("Retry-After"), "10")
+	testhelpers.Equal(t, call("/users/getReview", "u1-token", "192.0.2.1:1234").StatusCode, http.StatusTooManyRequests)
+	testhelpers.Equal(t, authenticator.calls, calls)
+
+	// у другого адреса свой бюджет, публичные маршруты не ограничены
+	testhelpers.Equal(t, call("/users/getReview", "u1-token", "198.51.100.7:1234").StatusCode, http.StatusBadRequest)
+	testhelpers.Equal(t, call("/openapi.json", "", "192.0.2.1:1234").StatusCode, http.StatusOK)
+}
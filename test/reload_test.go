@@ -0,0 +1,188 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/config"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+)
+
+func TestConfigReload(t *testing.T) {
+	dir := configEnv(t)
+	t.Setenv("DB_NAME", "reviews")
+	t.Setenv("DB_USER", "service")
+
+	configFile := filepath.Join(dir, "config.yaml")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("log:\n  level: info\nrate_limit:\n  read: \"1,1\"\n")
+
+	flags := &config.Flags{ConfigFile: configFile}
+	cfg, err := config.Load(flags)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var logs bytes.Buffer
+	lgr := slog.New(slog.NewTextHandler(&logs, nil))
+
+	// применение настроек - как в main
+	logLevel := &slog.LevelVar{}
+	limits, err := ratelimit.NewLimits(cfg.RateLimitRead, cfg.RateLimitWrite, cfg.RateLimitAuthFailures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := service.NewAssignmentPolicies(cfg.Assignment)
+	applied := 0
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		return config.Load(flags)
+	}, func(next *config.Config) error {
+		applied++
+		if err := limits.Update(next.RateLimitRead, next.RateLimitWrite, next.RateLimitAuthFailures); err != nil {
+			return err
+		}
+		logLevel.Set(next.LogLevel)
+		policies.Set(next.Assignment)
+		return nil
+	}, lgr)
+
+	authenticator, err := auth.NewStaticTokens("admin-token=admin")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+	router := routes.NewRouter(authenticator, limits, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{ConfigReloader: reloader}, &handlers.APIKeysHandlers{},
+		&handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	call := func(method, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		request.Header.Set("Authorization", "Bearer admin-token")
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		return responseWriter
+	}
+
+	writeConfig(`log:
+  level: debug
+rate_limit:
+  read: "100,100"
+assignment:
+  reviewers: 3
+  escalate_to_parent: false
+server:
+  addr: ":9000"
+db:
+  password: secret
+`)
+
+	responseWriter := call(http.MethodPost, "/api/v1/admin/config/reload")
+	testhelpers.Equal(t, responseWriter.Code, http.StatusOK)
+
+	var responseDTO dto.ResponseConfigReloadDTO
+	if err := json.NewDecoder(responseWriter.Body).Decode(&responseDTO); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	keys := func(changes []*dto.ConfigChangeDTO) string {
+		result := []string{}
+		for _, change := range changes {
+			result = append(result, change.Key)
+		}
+		return strings.Join(result, ",")
+	}
+	testhelpers.Equal(t, keys(responseDTO.Applied), "log.level,assignment.reviewers,assignment.escalate_to_parent,rate_limit.read")
+	testhelpers.Equal(t, keys(responseDTO.RestartRequired), "server.addr,db.password")
+	testhelpers.Equal(t, *responseDTO.RestartRequired[1], dto.ConfigChangeDTO{Key: "db.password", Old: "***", New: "***"})
+
+	// перезагружаемые настройки действуют сразу, остальные ждут перезапуска
+	testhelpers.Equal(t, logLevel.Level(), slog.LevelDebug)
+	testhelpers.Equal(t, policies.Get(), service.AssignmentPolicy{Reviewers: 3, EscalateToParent: false})
+	testhelpers.Equal(t, limits.Read.Budget, ratelimit.Budget{Rate: 100, Burst: 100})
+	testhelpers.Equal(t, reloader.Current().Server.Addr, ":8080")
+	testhelpers.Equal(t, reloader.Current().Assignment.Reviewers, 3)
+
+	if !strings.Contains(logs.String(), "setting=assignment.reviewers old=2 new=3") {
+		t.Errorf("reload must log the diff, got:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("secrets must not be logged, got:\n%s", logs.String())
+	}
+
+	t.Run("invalid config keeps the current one", func(t *testing.T) {
+		writeConfig("assignment:\n  reviewers: 0\nrate_limit:\n  read: fast\n")
+
+		responseWriter := call(http.MethodPost, "/api/v1/admin/config/reload")
+		testhelpers.Equal(t, responseWriter.Code, http.StatusUnprocessableEntity)
+
+		var errorDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseWriter.Body).Decode(&errorDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, errorDTO.Error.Code, "INVALID_CONFIG")
+		if !strings.Contains(errorDTO.Error.Message, "rate_limit.read") || !strings.Contains(errorDTO.Error.Message, "assignment.reviewers") {
+			t.Errorf("message must list invalid settings, got %q", errorDTO.Error.Message)
+		}
+
+		testhelpers.Equal(t, applied, 1)
+		testhelpers.Equal(t, policies.Get().Reviewers, 3)
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		writeConfig("log:\n  level: debug\nrate_limit:\n  read: \"100,100\"\nassignment:\n  reviewers: 3\n  escalate_to_parent: false\n")
+
+		result, err := reloader.Reload(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to reload: %v", err)
+		}
+		testhelpers.Equal(t, len(result.Applied), 0)
+		testhelpers.Equal(t, applied, 1)
+	})
+}
+
+func TestRateLimitUpdate(t *testing.T) {
+	limits, err := ratelimit.NewLimits("1,1", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits.Read.Allow("ip:1")
+	if ok, _ := limits.Read.Allow("ip:1"); ok {
+		t.Fatal("budget must be spent")
+	}
+
+	// неверный бюджет не меняет действующие
+	if err := limits.Update("1,1", "often", ""); err == nil {
+		t.Errorf("invalid budget must be rejected")
+	}
+	testhelpers.Equal(t, limits.Write == nil, true)
+
+	if err := limits.Update("10,5", "1,1", ""); err != nil {
+		t.Fatalf("Failed to update limits: %v", err)
+	}
+	testhelpers.Equal(t, limits.Read.Budget, ratelimit.Budget{Rate: 10, Burst: 5})
+	testhelpers.Equal(t, limits.Write.Budget, ratelimit.Budget{Rate: 1, Burst: 1})
+
+	if err := limits.Update("", "1,1", ""); err != nil {
+		t.Fatalf("Failed to update limits: %v", err)
+	}
+	testhelpers.Equal(t, limits.Read == nil, true)
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/testhelpers"
+)
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	// до хендлеров запросы не доходят, поэтому сервисы не нужны
+	router := routes.NewRouter(&auth.StaticTokens{}, nil, nil, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		allow  string
+	}{
+		{"versioned path", http.MethodPost, "/api/v1/users/u1", "GET, PATCH, DELETE, HEAD"},
+		{"versioned action", http.MethodGet, "/api/v1/pull-requests/pr-1001/merge", "POST"},
+		{"legacy path", http.MethodGet, "/team/add", "POST"},
+		{"legacy users path", http.MethodGet, "/users/setIsActive", "POST"},
+		{"legacy pull request path", http.MethodGet, "/pullRequest/create", "POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			responseWriter := httptest.NewRecorder()
+
+			router.ServeHTTP(responseWriter, request)
+			responseResult := responseWriter.Result()
+
+			// код ответа и заголовок Allow должны совпадать
+			testhelpers.Equal(t, responseResult.StatusCode, http.StatusMethodNotAllowed)
+			testhelpers.Equal(t, responseResult.Header.Get("Allow"), tt.allow)
+
+			// тело ответа в формате ErrorResponseDTO
+			var responseDTO dto.ErrorResponseDTO
+			if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			testhelpers.Equal(t, responseDTO.Error.Code, "WRONG_METHOD")
+		})
+	}
+}
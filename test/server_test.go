@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"pr-service/internal/server"
+	"pr-service/internal/testhelpers"
+)
+
+func TestServerOptions(t *testing.T) {
+	opts := server.DefaultOptions()
+	opts.WriteTimeout = 2 * time.Minute
+
+	srv := server.New(&opts, http.NotFoundHandler())
+	testhelpers.Equal(t, srv.Addr, server.DefaultAddr)
+	testhelpers.Equal(t, srv.ReadTimeout, server.DefaultReadTimeout)
+	testhelpers.Equal(t, srv.WriteTimeout, 2*time.Minute)
+	testhelpers.Equal(t, srv.IdleTimeout, server.DefaultIdleTimeout)
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// запрос начинается до остановки и должен доработать
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := "http://" + listener.Addr().String()
+
+	// порядок остановки фоновых задач
+	stopped := make(chan string, 2)
+	hook := func(name string) server.Hook {
+		return server.Hook{Name: name, Stop: func(ctx context.Context) error {
+			stopped <- name
+			return nil
+		}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ctx, server.New(&server.Options{}, handler), listener, time.Second, lgr, hook("first"), hook("second"))
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		response, err := http.Get(addr)
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		inFlight <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	// пока запрос не завершен, задачи не останавливаются
+	select {
+	case name := <-stopped:
+		t.Fatalf("hook %q stopped before in-flight request finished", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	got := <-inFlight
+	if got.err != nil {
+		t.Fatalf("In-flight request failed: %v", got.err)
+	}
+	testhelpers.Equal(t, got.body, "done")
+
+	if err := <-serveErr; err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+	testhelpers.Equal(t, <-stopped, "first")
+	testhelpers.Equal(t, <-stopped, "second")
+
+	// новые соединения не принимаются
+	if _, err := http.Get(addr); err == nil {
+		t.Errorf("server must not accept requests after shutdown")
+	}
+}
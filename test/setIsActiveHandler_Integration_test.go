@@ -2,6 +2,7 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -26,6 +27,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 	usersRepository := &repository.UsersRepository{Db: db}
 	reviewersRepository := &repository.ReviewersRepository{Db: db}
 	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
 
 	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 
@@ -34,6 +36,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 		UsersRepository:        usersRepository,
 		ReviewersRepository:    reviewersRepository,
 		PullRequestsRepository: pullRequestsRepository,
+		Transactor:             transactor,
 		Lgr:                    lgr,
 	}
 
@@ -80,7 +83,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.User.IsActive, requestDTO.IsActive)
 
 		// проверяем запись в БД
-		user, err := usersRepository.GetUserById(nil, requestDTO.Id)
+		user, err := usersRepository.GetUserById(context.Background(), requestDTO.Id)
 		if err != nil {
 			t.Fatalf("Failed to get user from database: %v", err)
 		}
@@ -127,7 +130,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.User.IsActive, requestDTO.IsActive)
 
 		// проверяем запись в БД
-		user, err := usersRepository.GetUserById(nil, "u2")
+		user, err := usersRepository.GetUserById(context.Background(), "u2")
 		if err != nil {
 			t.Fatalf("Failed to get user from database: %v", err)
 		}
@@ -175,7 +178,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 		// Сначала проверяем текущий статус пользователя
 		userId := "u3"
 
-		user, err := usersRepository.GetUserById(nil, userId)
+		user, err := usersRepository.GetUserById(context.Background(), userId)
 		if err != nil {
 			t.Fatalf("Failed to get user from database: %v", err)
 		}
@@ -213,7 +216,7 @@ func TestSetIsActiveHandler(t *testing.T) {
 		testhelpers.Equal(t, responseDTO.User.IsActive, currentStatus)
 
 		// проверяем что в БД ничего не изменилось
-		userAfter, err := usersRepository.GetUserById(nil, userId)
+		userAfter, err := usersRepository.GetUserById(context.Background(), userId)
 		if err != nil {
 			t.Fatalf("Failed to get user from database: %v", err)
 		}
@@ -222,23 +225,28 @@ func TestSetIsActiveHandler(t *testing.T) {
 		testhelpers.Equal(t, userAfter.IsActive, currentStatus)
 	})
 
-	t.Run("invalid HTTP method", func(t *testing.T) {
-		request := httptest.NewRequest("GET", "/users/setIsActive", nil)
+	t.Run("versioned path with user in path", func(t *testing.T) {
+		// user_id в пути, в теле только флаг
+		router := http.NewServeMux()
+		router.HandleFunc("PUT /api/v1/users/{id}/active", userHandler.SetIsActiveByUserId)
+
+		request := httptest.NewRequest("PUT", "/api/v1/users/u2/active", bytes.NewReader([]byte(`{"is_active": false}`)))
 		responseWriter := httptest.NewRecorder()
 
-		userHandler.SetIsActive(responseWriter, request)
+		router.ServeHTTP(responseWriter, request)
 		responseResult := responseWriter.Result()
 
 		// код ответа должен совпадать
-		testhelpers.Equal(t, responseResult.StatusCode, http.StatusMethodNotAllowed)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
 
 		// десереализируем ответ
-		var responseDTO dto.ErrorResponseDTO
+		var responseDTO dto.UserDTO
 		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
 			t.Fatalf("Failed to parse response: %v", err)
 		}
 
-		// код ошибки должен совпадать
-		testhelpers.Equal(t, responseDTO.Error.Code, "WRONG_METHOD")
+		// пользователь берется из пути
+		testhelpers.Equal(t, responseDTO.User.UserId, "u2")
+		testhelpers.Equal(t, responseDTO.User.IsActive, false)
 	})
 }
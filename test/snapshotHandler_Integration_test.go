@@ -0,0 +1,165 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestSnapshotHandler(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
+	reviewersRepository := &repository.ReviewersRepository{Db: db}
+	eventsRepository := &repository.EventsRepository{Db: db}
+	snapshotRepository := &repository.SnapshotRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервисы
+	pullRequestService := &service.PullRequestsService{
+		UsersRepository:        usersRepository,
+		TeamsRepository:        teamsRepository,
+		PullRequestsRepository: pullRequestsRepository,
+		ReviewersRepository:    reviewersRepository,
+		EventsRepository:       eventsRepository,
+		Transactor:             transactor,
+		Lgr:                    lgr,
+	}
+
+	snapshotService := &service.SnapshotService{
+		SnapshotRepository:  snapshotRepository,
+		TeamsRepository:     teamsRepository,
+		ReviewersRepository: reviewersRepository,
+		EventsRepository:    eventsRepository,
+		Transactor:          transactor,
+		Lgr:                 lgr,
+	}
+
+	// создаем сам хендлер
+	adminHandler := handlers.AdminHandlers{
+		SnapshotService: snapshotService,
+	}
+
+	// Предварительно создаем тестовые данные
+	testutils.RunQuery(t, db, "./testdata/insertUsers.sql")
+
+	_, err := pullRequestService.AddPullRequest(context.Background(), &dto.RequestPullrequestDTO{
+		PullRequestId:   "pr-2001",
+		PullRequestName: "Snapshot feature",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create pull request: %v", err)
+	}
+
+	// выгружает снимок через хендлер
+	export := func(t *testing.T) *dto.SnapshotDTO {
+		request := httptest.NewRequest("GET", "/admin/export", nil)
+		responseWriter := httptest.NewRecorder()
+
+		adminHandler.Export(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var snapshot dto.SnapshotDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&snapshot); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		return &snapshot
+	}
+
+	// загружает снимок через хендлер
+	restore := func(t *testing.T, snapshot *dto.SnapshotDTO) *http.Response {
+		b, err := json.Marshal(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/admin/restore", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		adminHandler.Restore(responseWriter, request)
+		return responseWriter.Result()
+	}
+
+	snapshot := export(t)
+
+	t.Run("export contains all data", func(t *testing.T) {
+		testhelpers.Equal(t, snapshot.Version, dto.SnapshotVersion)
+		testhelpers.Equal(t, len(snapshot.Teams), 1)
+		testhelpers.Equal(t, len(snapshot.Users), 5)
+		testhelpers.Equal(t, len(snapshot.Memberships), 5)
+		testhelpers.Equal(t, len(snapshot.PullRequests), 1)
+
+		// каждое назначение ревьювера попадает в историю
+		testhelpers.Equal(t, len(snapshot.History), len(snapshot.Reviewers))
+	})
+
+	t.Run("restore into non-empty database", func(t *testing.T) {
+		responseResult := restore(t, snapshot)
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusConflict)
+	})
+
+	t.Run("restore with broken references", func(t *testing.T) {
+		broken := *snapshot
+		broken.Reviewers = append([]*dto.SnapshotReviewerDTO{{PullRequestId: "pr-2001", UserId: "u404"}}, snapshot.Reviewers...)
+
+		responseResult := restore(t, &broken)
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+
+		// десереализируем ответ
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// код ошибки должен совпадать
+		testhelpers.Equal(t, responseDTO.Error.Code, "INVALID_SNAPSHOT")
+	})
+
+	t.Run("successful restore into empty database", func(t *testing.T) {
+		// пересоздаем пустую схему
+		testutils.RunQuery(t, db, "./testdata/teardown.sql")
+		testutils.RunQuery(t, db, "./testdata/setup.sql")
+
+		responseResult := restore(t, snapshot)
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+		// повторная выгрузка должна совпасть с исходной
+		restored := export(t)
+		testhelpers.Equal(t, len(restored.Users), len(snapshot.Users))
+		testhelpers.Equal(t, len(restored.Memberships), len(snapshot.Memberships))
+		testhelpers.Equal(t, len(restored.Reviewers), len(snapshot.Reviewers))
+		testhelpers.Equal(t, len(restored.History), len(snapshot.History))
+		testhelpers.Equal(t, restored.PullRequests[0].PullRequestId, "pr-2001")
+		testhelpers.Equal(t, restored.PullRequests[0].Status, snapshot.PullRequests[0].Status)
+	})
+}
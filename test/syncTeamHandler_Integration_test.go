@@ -0,0 +1,242 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestSyncTeamHandler(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервис
+	teamService := &service.TeamsService{
+		UsersRepository: usersRepository,
+		TeamsRepository: teamsRepository,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	// создаем сам хендлер
+	teamHandler := handlers.TeamsHandlers{
+		TeamService: teamService,
+	}
+
+	// Предварительно создаем тестовые данные
+	testutils.RunQuery(t, db, "./testdata/insertUsers.sql")
+
+	t.Run("dry run doesn't change database", func(t *testing.T) {
+		// формируем запрос
+		requestDTO := dto.TeamDTO{
+			TeamName: "test-team",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u1", Username: "Alice", IsActive: false},
+				{UserId: "u6", Username: "Frank", IsActive: true},
+			},
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/team/add?mode=sync&dry_run=true", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		teamHandler.AddTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseTeamSyncDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// ответ должен быть помечен как dry-run
+		testhelpers.Equal(t, responseDTO.DryRun, true)
+
+		// команда уже существовала
+		testhelpers.Equal(t, responseDTO.Diff.TeamCreated, false)
+
+		// создается только u6
+		testhelpers.Equal(t, len(responseDTO.Diff.CreatedUsers), 1)
+
+		// у u1 меняется только активность
+		testhelpers.Equal(t, len(responseDTO.Diff.UpdatedUsers), 1)
+		testhelpers.Equal(t, responseDTO.Diff.UpdatedUsers[0].UserId, "u1")
+
+		// u2, u3, u4, u5 открепляются
+		testhelpers.Equal(t, len(responseDTO.Diff.DetachedUsers), 4)
+
+		// проверяем что в БД ничего не изменилось
+		userModels, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
+		if err != nil {
+			t.Fatalf("Failed to get team userModels: %v", err)
+		}
+
+		// кол-во участников должно остаться прежним
+		testhelpers.Equal(t, len(userModels), 5)
+
+		// u6 не должен появиться
+		exists, err := usersRepository.IsExist(context.Background(), "u6")
+		if err != nil {
+			t.Fatalf("Failed to check user existence: %v", err)
+		}
+		testhelpers.Equal(t, exists, false)
+	})
+
+	t.Run("successful team synchronization", func(t *testing.T) {
+		// формируем запрос
+		requestDTO := dto.TeamDTO{
+			TeamName: "test-team",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u1", Username: "Alice Smith", IsActive: true},
+				{UserId: "u2", Username: "Bob", IsActive: true},
+				{UserId: "u6", Username: "Frank", IsActive: true},
+			},
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/team/add?mode=sync", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		teamHandler.AddTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseTeamSyncDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// u1 и u2 обновляются, u6 создается, u3, u4, u5 открепляются
+		testhelpers.Equal(t, len(responseDTO.Diff.UpdatedUsers), 2)
+		testhelpers.Equal(t, len(responseDTO.Diff.CreatedUsers), 1)
+		testhelpers.Equal(t, len(responseDTO.Diff.DetachedUsers), 3)
+
+		// состав команды в БД должен совпадать с запросом
+		userModels, err := usersRepository.GetUsersByTeam(context.Background(), requestDTO.TeamName)
+		if err != nil {
+			t.Fatalf("Failed to get team userModels: %v", err)
+		}
+		testhelpers.Equal(t, len(userModels), len(requestDTO.Members))
+
+		// имя u1 должно обновиться
+		user, err := usersRepository.GetUserById(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.Username, "Alice Smith")
+
+		// открепленный пользователь остается в БД без команды
+		detached, err := usersRepository.GetUserById(context.Background(), "u3")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, detached.TeamName, "")
+	})
+
+	t.Run("repeated synchronization changes nothing", func(t *testing.T) {
+		// формируем тот же запрос повторно
+		requestDTO := dto.TeamDTO{
+			TeamName: "test-team",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u1", Username: "Alice Smith", IsActive: true},
+				{UserId: "u2", Username: "Bob", IsActive: true},
+				{UserId: "u6", Username: "Frank", IsActive: true},
+			},
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/team/add?mode=sync", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		teamHandler.AddTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseTeamSyncDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// изменений быть не должно
+		testhelpers.Equal(t, len(responseDTO.Diff.CreatedUsers), 0)
+		testhelpers.Equal(t, len(responseDTO.Diff.UpdatedUsers), 0)
+		testhelpers.Equal(t, len(responseDTO.Diff.DetachedUsers), 0)
+	})
+
+	t.Run("duplicate member in sync request", func(t *testing.T) {
+		requestDTO := dto.TeamDTO{
+			TeamName: "test-team",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u1", Username: "Alice", IsActive: true},
+				{UserId: "u1", Username: "Alice Duplicate", IsActive: true},
+			},
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/team/add?mode=sync", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		teamHandler.AddTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+
+		// десереализируем ответ
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// код ошибки должен совпадать
+		testhelpers.Equal(t, responseDTO.Error.Code, "DUPLICATED_MEMBER")
+	})
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"pr-service/internal/app"
+	"pr-service/internal/dto"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestConcurrentSetParent(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	services := app.NewServices(app.PostgresRepositories(db), lgr, nil, nil)
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	ctx := t.Context()
+
+	for _, teamName := range []string{"team-a", "team-b"} {
+		if _, err := services.Teams.AddTeamWithMembers(ctx, &dto.TeamDTO{TeamName: teamName, Members: []*dto.TeamMemberDTO{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// встречные переносы повторяются, чтобы они чаще пересекались во времени
+	for range 20 {
+		for _, teamName := range []string{"team-a", "team-b"} {
+			if err := teamsRepository.SetParent(ctx, teamName, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		start := make(chan struct{})
+		for i, move := range [][2]string{{"team-a", "team-b"}, {"team-b", "team-a"}} {
+			wg.Go(func() {
+				<-start
+				_, errs[i] = services.Teams.SetParentTeam(ctx, &dto.RequestSetParentDTO{TeamName: move[0], ParentTeamName: move[1]})
+			})
+		}
+		close(start)
+		wg.Wait()
+
+		// проходит ровно один перенос, второй видит цикл
+		succeeded := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, service.ErrTeamCycle):
+			default:
+				t.Fatalf("set parent: %v", err)
+			}
+		}
+		testhelpers.Equal(t, succeeded, 1)
+
+		for _, teamName := range []string{"team-a", "team-b"} {
+			ancestors, err := teamsRepository.GetAncestors(ctx, teamName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testhelpers.Equal(t, slices.Contains(ancestors, teamName), false)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+// withPrincipal имитирует запрос, прошедший auth.Middleware
+func withPrincipal(request *http.Request, userId, role string) *http.Request {
+	return request.WithContext(auth.WithPrincipal(request.Context(), &auth.Principal{UserId: userId, Role: role}))
+}
+
+func TestTeamLeads(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	reviewersRepository := &repository.ReviewersRepository{Db: db}
+	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервисы и хендлеры
+	teamsHandler := &handlers.TeamsHandlers{
+		TeamService: &service.TeamsService{
+			TeamsRepository: teamsRepository,
+			UsersRepository: usersRepository,
+			Transactor:      transactor,
+			Lgr:             lgr,
+		},
+	}
+	usersHandler := &handlers.UsersHandlers{
+		UserService: &service.UsersService{
+			UsersRepository:        usersRepository,
+			TeamsRepository:        teamsRepository,
+			ReviewersRepository:    reviewersRepository,
+			PullRequestsRepository: pullRequestsRepository,
+			Transactor:             transactor,
+			Lgr:                    lgr,
+		},
+	}
+	statsHandler := &handlers.StatsHandlers{
+		StatsService: &service.StatsService{
+			PullRequestsRepository: pullRequestsRepository,
+			ReviewersRepository:    reviewersRepository,
+			UsersRepository:        usersRepository,
+			TeamsRepository:        teamsRepository,
+			Lgr:                    lgr,
+		},
+	}
+
+	// test-team: u1-u5, department -> squad: u10-u13
+	testutils.RunQuery(t, db, "./testdata/InsertUsers.sql")
+	testutils.RunQuery(t, db, "./testdata/InsertTeamHierarchy.sql")
+
+	changeLead := func(method, teamName, userId, role string) *http.Response {
+		request := httptest.NewRequest(method, "/api/v1/teams/"+teamName+"/leads/u1", nil)
+		request.SetPathValue("name", teamName)
+		request.SetPathValue("user_id", "u1")
+		responseWriter := httptest.NewRecorder()
+
+		if method == http.MethodPut {
+			teamsHandler.AddTeamLead(responseWriter, withPrincipal(request, userId, role))
+		} else {
+			teamsHandler.RemoveTeamLead(responseWriter, withPrincipal(request, userId, role))
+		}
+		return responseWriter.Result()
+	}
+
+	setIsActive := func(userId string) int {
+		b, err := json.Marshal(dto.IsActiveUserDTO{IsActive: false})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userId+"/active", bytes.NewReader(b))
+		request.SetPathValue("id", userId)
+		responseWriter := httptest.NewRecorder()
+
+		usersHandler.SetIsActiveByUserId(responseWriter, withPrincipal(request, "u1", auth.RoleUser))
+		return responseWriter.Result().StatusCode
+	}
+
+	t.Run("only admin assigns leads", func(t *testing.T) {
+		testhelpers.Equal(t, changeLead(http.MethodPut, "test-team", "u1", auth.RoleUser).StatusCode, http.StatusForbidden)
+
+		responseResult := changeLead(http.MethodPut, "test-team", "admin", auth.RoleAdmin)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var responseDTO dto.TeamLeadsDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, len(responseDTO.Leads), 1)
+		testhelpers.Equal(t, responseDTO.Leads[0], "u1")
+	})
+
+	t.Run("lead manages only own team", func(t *testing.T) {
+		// участник своей команды
+		testhelpers.Equal(t, setIsActive("u3"), http.StatusOK)
+
+		// участник чужой команды
+		testhelpers.Equal(t, setIsActive("u10"), http.StatusForbidden)
+
+		for teamName, status := range map[string]int{"test-team": http.StatusOK, "squad": http.StatusForbidden} {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/teams/"+teamName, nil)
+			request.SetPathValue("name", teamName)
+			responseWriter := httptest.NewRecorder()
+
+			teamsHandler.GetTeamByName(responseWriter, withPrincipal(request, "u1", auth.RoleUser))
+			testhelpers.Equal(t, responseWriter.Result().StatusCode, status)
+		}
+	})
+
+	t.Run("lead sees only own team stats", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+		responseWriter := httptest.NewRecorder()
+
+		statsHandler.GetStats(responseWriter, withPrincipal(request, "u1", auth.RoleUser))
+		responseResult := responseWriter.Result()
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var responseDTO dto.StatsResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, len(responseDTO.Teams), 1)
+		testhelpers.Equal(t, responseDTO.Teams[0].TeamName, "test-team")
+
+		// не лиду статистика недоступна
+		request = httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+		responseWriter = httptest.NewRecorder()
+
+		statsHandler.GetStats(responseWriter, withPrincipal(request, "u3", auth.RoleUser))
+		testhelpers.Equal(t, responseWriter.Result().StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("lead can't pull users from other teams", func(t *testing.T) {
+		syncTeam := func(members ...*dto.TeamMemberDTO) int {
+			b, err := json.Marshal(dto.TeamDTO{TeamName: "test-team", Members: members})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/team/add?mode=sync", bytes.NewReader(b))
+			responseWriter := httptest.NewRecorder()
+
+			teamsHandler.AddTeam(responseWriter, withPrincipal(request, "u1", auth.RoleUser))
+			return responseWriter.Result().StatusCode
+		}
+
+		ownMembers := []*dto.TeamMemberDTO{
+			{UserId: "u1", Username: "Alice", IsActive: true},
+			{UserId: "u2", Username: "Bob", IsActive: false},
+			{UserId: "u3", Username: "Charlie", IsActive: false},
+			{UserId: "u4", Username: "David", IsActive: false},
+			{UserId: "u5", Username: "Ivan", IsActive: true},
+		}
+
+		// u10 из squad не переходит в команду лида и не меняется
+		status := syncTeam(append(ownMembers, &dto.TeamMemberDTO{UserId: "u10", Username: "Hijacked", IsActive: false})...)
+		testhelpers.Equal(t, status, http.StatusForbidden)
+
+		user, err := usersRepository.GetUserById(context.Background(), "u10")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.Username, "Kate")
+		testhelpers.Equal(t, user.IsActive, true)
+		testhelpers.Equal(t, len(user.Teams), 1)
+		testhelpers.Equal(t, user.Teams[0], "squad")
+
+		// участников своей команды лид синхронизирует как раньше
+		ownMembers[4] = &dto.TeamMemberDTO{UserId: "u5", Username: "Ivan Petrov", IsActive: true}
+		testhelpers.Equal(t, syncTeam(ownMembers...), http.StatusOK)
+	})
+
+	t.Run("removed lead loses access", func(t *testing.T) {
+		testhelpers.Equal(t, changeLead(http.MethodDelete, "test-team", "admin", auth.RoleAdmin).StatusCode, http.StatusOK)
+		testhelpers.Equal(t, setIsActive("u3"), http.StatusForbidden)
+
+		// повторное снятие - назначения уже нет
+		testhelpers.Equal(t, changeLead(http.MethodDelete, "test-team", "admin", auth.RoleAdmin).StatusCode, http.StatusNotFound)
+	})
+}
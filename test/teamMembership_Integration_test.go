@@ -0,0 +1,237 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestTeamMembership(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
+	reviewersRepository := &repository.ReviewersRepository{Db: db}
+	eventsRepository := &repository.EventsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервисы
+	teamService := &service.TeamsService{
+		UsersRepository: usersRepository,
+		TeamsRepository: teamsRepository,
+		Transactor:      transactor,
+		Lgr:             lgr,
+	}
+
+	pullRequestService := &service.PullRequestsService{
+		UsersRepository:        usersRepository,
+		TeamsRepository:        teamsRepository,
+		PullRequestsRepository: pullRequestsRepository,
+		ReviewersRepository:    reviewersRepository,
+		EventsRepository:       eventsRepository,
+		Transactor:             transactor,
+		Lgr:                    lgr,
+	}
+
+	// создаем хендлеры
+	teamHandler := handlers.TeamsHandlers{
+		TeamService: teamService,
+	}
+
+	pullRequestHandler := handlers.PullRequestsHandlers{
+		PullRequestService: pullRequestService,
+	}
+
+	// Предварительно создаем команду test-team с пользователями u1-u5
+	testutils.RunQuery(t, db, "./testdata/InsertUsers.sql")
+
+	// syncTeam синхронизирует состав команды через /team/add?mode=sync
+	syncTeam := func(t *testing.T, requestDTO dto.TeamDTO) dto.ResponseTeamSyncDTO {
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/team/add?mode=sync", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		teamHandler.AddTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var responseDTO dto.ResponseTeamSyncDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return responseDTO
+	}
+
+	// teamMembers возвращает отсортированные id участников из /team/get
+	teamMembers := func(t *testing.T, teamName string) []string {
+		request := httptest.NewRequest("GET", "/team/get?team_name="+teamName, nil)
+		responseWriter := httptest.NewRecorder()
+
+		teamHandler.GetTeam(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		var responseDTO dto.TeamDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		members := []string{}
+		for _, member := range responseDTO.Members {
+			members = append(members, member.UserId)
+		}
+		slices.Sort(members)
+		return members
+	}
+
+	t.Run("user in two teams is visible in both", func(t *testing.T) {
+		// u1 и u3 вступают в backend, u6 создается
+		responseDTO := syncTeam(t, dto.TeamDTO{
+			TeamName: "backend",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u1", Username: "Alice", IsActive: true},
+				{UserId: "u3", Username: "Charlie", IsActive: true},
+				{UserId: "u6", Username: "Frank", IsActive: true},
+			},
+		})
+
+		testhelpers.Equal(t, responseDTO.Diff.TeamCreated, true)
+		testhelpers.Equal(t, slices.Equal(responseDTO.Diff.AddedUsers, []string{"u1", "u3"}), true)
+		testhelpers.Equal(t, slices.Equal(responseDTO.Diff.CreatedUsers, []string{"u6"}), true)
+
+		// u1 виден в обеих командах
+		testhelpers.Equal(t, slices.Equal(teamMembers(t, "backend"), []string{"u1", "u3", "u6"}), true)
+		testhelpers.Equal(t, slices.Equal(teamMembers(t, "test-team"), []string{"u1", "u2", "u3", "u4", "u5"}), true)
+
+		// основная команда не меняется
+		user, err := usersRepository.GetUserById(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.TeamName, "test-team")
+		testhelpers.Equal(t, len(user.Teams), 2)
+	})
+
+	t.Run("team_name selects reviewers from that team", func(t *testing.T) {
+		// без team_name ревьюверы были бы из test-team: u3 и u5
+		requestDTO := dto.RequestPullrequestDTO{
+			PullRequestId:   "pr-3001",
+			PullRequestName: "Backend feature",
+			AuthorID:        "u1",
+			TeamName:        "backend",
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/pullRequest/create", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		pullRequestHandler.AddPullRequest(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusCreated)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponsePullrequestDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// pr привязан к выбранной команде
+		testhelpers.Equal(t, responseDTO.PR.TeamName, "backend")
+
+		// ревьюверы только из backend
+		reviewerIds, err := reviewersRepository.GetReviewersIdByPullRequestId(context.Background(), requestDTO.PullRequestId)
+		if err != nil {
+			t.Fatalf("Failed to get reviewers from database: %v", err)
+		}
+		slices.Sort(reviewerIds)
+		testhelpers.Equal(t, slices.Equal(reviewerIds, []string{"u3", "u6"}), true)
+	})
+
+	t.Run("unknown team_name", func(t *testing.T) {
+		b, err := json.Marshal(dto.RequestPullrequestDTO{
+			PullRequestId:   "pr-3002",
+			PullRequestName: "Lost feature",
+			AuthorID:        "u1",
+			TeamName:        "no-such-team",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/pullRequest/create", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		pullRequestHandler.AddPullRequest(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("primary team falls back on removal", func(t *testing.T) {
+		// u1 вступает еще и в platform, она создается позже backend
+		syncTeam(t, dto.TeamDTO{
+			TeamName: "platform",
+			Members:  []*dto.TeamMemberDTO{{UserId: "u1", Username: "Alice", IsActive: true}},
+		})
+
+		// u1 убирают из основной команды
+		responseDTO := syncTeam(t, dto.TeamDTO{
+			TeamName: "test-team",
+			Members: []*dto.TeamMemberDTO{
+				{UserId: "u2", Username: "Bob", IsActive: false},
+				{UserId: "u3", Username: "Charlie", IsActive: true},
+				{UserId: "u4", Username: "David", IsActive: false},
+				{UserId: "u5", Username: "Ivan", IsActive: true},
+			},
+		})
+		testhelpers.Equal(t, slices.Equal(responseDTO.Diff.DetachedUsers, []string{"u1"}), true)
+
+		// основной становится первая по алфавиту из оставшихся команд
+		user, err := usersRepository.GetUserById(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.TeamName, "backend")
+		testhelpers.Equal(t, len(user.Teams), 2)
+
+		// u1 пропадает только из test-team
+		testhelpers.Equal(t, slices.Contains(teamMembers(t, "test-team"), "u1"), false)
+		testhelpers.Equal(t, slices.Contains(teamMembers(t, "backend"), "u1"), true)
+		testhelpers.Equal(t, slices.Contains(teamMembers(t, "platform"), "u1"), true)
+	})
+}
@@ -1,5 +1,8 @@
 INSERT INTO teams (team_name) 
 VALUES ('solo-team');
 
-INSERT INTO users (user_id, username, team_name, is_active) 
-VALUES ('u0', 'Dima', 'solo-team', true);
+INSERT INTO users (user_id, username, is_active) 
+VALUES ('u0', 'Dima', true);
+
+INSERT INTO team_members (team_name, user_id, is_primary)
+VALUES ('solo-team', 'u0', true);
@@ -0,0 +1,17 @@
+INSERT INTO teams (team_name) 
+VALUES ('department');
+
+INSERT INTO teams (team_name, parent_team_name) 
+VALUES ('squad', 'department');
+
+INSERT INTO users (user_id, username, is_active) 
+VALUES ('u10', 'Kate', true),
+('u11', 'Leo', true),
+('u12', 'Mike', true),
+('u13', 'Nina', false);
+
+INSERT INTO team_members (team_name, user_id, is_primary)
+VALUES ('squad', 'u10', true),
+('squad', 'u11', true),
+('squad', 'u13', true),
+('department', 'u12', true);
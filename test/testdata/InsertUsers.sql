@@ -1,9 +1,16 @@
 INSERT INTO teams (team_name) 
 VALUES ('test-team');
 
-INSERT INTO users (user_id, username, team_name, is_active) 
-VALUES ('u1', 'Alice', 'test-team', true),
-('u2', 'Bob', 'test-team', false),
-('u3', 'Charlie', 'test-team', true),
-('u4', 'David', 'test-team', false),
-('u5', 'Ivan', 'test-team', true);
+INSERT INTO users (user_id, username, is_active) 
+VALUES ('u1', 'Alice', true),
+('u2', 'Bob', false),
+('u3', 'Charlie', true),
+('u4', 'David', false),
+('u5', 'Ivan', true);
+
+INSERT INTO team_members (team_name, user_id, is_primary)
+VALUES ('test-team', 'u1', true),
+('test-team', 'u2', true),
+('test-team', 'u3', true),
+('test-team', 'u4', true),
+('test-team', 'u5', true);
@@ -1,15 +1,29 @@
 CREATE TABLE IF NOT EXISTS teams (
-	team_name VARCHAR(255) PRIMARY KEY
+	team_name VARCHAR(255) PRIMARY KEY,
+	parent_team_name VARCHAR(255) NULL,
+	FOREIGN KEY(parent_team_name) REFERENCES teams(team_name)
 );
 
 CREATE TABLE IF NOT EXISTS users (
 	user_id VARCHAR(255) PRIMARY KEY,
 	username VARCHAR(255) NOT NULL,
 	is_active BOOLEAN NOT NULL,
+	email VARCHAR(255) NULL,
+	handles JSONB NOT NULL DEFAULT '{}',
+	deleted_at TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
 	team_name VARCHAR(255) NOT NULL,
-	FOREIGN KEY(team_name) REFERENCES teams(team_name)
+	user_id VARCHAR(255) NOT NULL,
+	is_primary BOOLEAN NOT NULL DEFAULT false,
+	PRIMARY KEY(team_name, user_id),
+	FOREIGN KEY(team_name) REFERENCES teams(team_name),
+	FOREIGN KEY(user_id) REFERENCES users(user_id)
 );
 
+CREATE UNIQUE INDEX IF NOT EXISTS team_members_primary_idx ON team_members(user_id) WHERE is_primary;
+
 CREATE TABLE IF NOT EXISTS pull_requests_status (
 	pr_status_id SERIAL PRIMARY KEY,
 	status VARCHAR(255) NOT NULL 
@@ -22,8 +36,10 @@ CREATE TABLE IF NOT EXISTS pull_requests (
 	status_id INT NOT NULL,
 	created_at TIMESTAMP NOT NULL,
 	merged_at TIMESTAMP NULL,
+	team_name VARCHAR(255) NULL,
 	FOREIGN KEY(author_id) REFERENCES users(user_id),
-	FOREIGN KEY(status_id) REFERENCES pull_requests_status(pr_status_id)
+	FOREIGN KEY(status_id) REFERENCES pull_requests_status(pr_status_id),
+	FOREIGN KEY(team_name) REFERENCES teams(team_name)
 );
 
 
@@ -36,4 +52,38 @@ CREATE TABLE IF NOT EXISTS reviewers (
 	FOREIGN KEY(pull_request_id) REFERENCES pull_requests(pull_request_id)
 );
 
+CREATE TABLE IF NOT EXISTS pull_request_events (
+	event_id SERIAL PRIMARY KEY,
+	pull_request_id VARCHAR(255) NOT NULL,
+	event_type VARCHAR(32) NOT NULL,
+	user_id VARCHAR(255) NULL,
+	old_user_id VARCHAR(255) NULL,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY(pull_request_id) REFERENCES pull_requests(pull_request_id),
+	FOREIGN KEY(user_id) REFERENCES users(user_id),
+	FOREIGN KEY(old_user_id) REFERENCES users(user_id)
+);
+
+CREATE TABLE IF NOT EXISTS team_leads (
+	team_name VARCHAR(255) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	PRIMARY KEY(team_name, user_id),
+	FOREIGN KEY(team_name) REFERENCES teams(team_name),
+	FOREIGN KEY(user_id) REFERENCES users(user_id)
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	key_id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	key_hash CHAR(64) NOT NULL UNIQUE,
+	scopes TEXT[] NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NULL,
+	last_used_at TIMESTAMP NULL,
+	revoked_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_active_name_idx ON api_keys(name) WHERE revoked_at IS NULL;
+
 INSERT INTO pull_requests_status(pr_status_id, status) VALUES(1, 'OPEN'), (2, 'MERGED');
\ No newline at end of file
@@ -1,5 +1,9 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS team_leads;
+DROP TABLE IF EXISTS pull_request_events;
 DROP TABLE IF EXISTS reviewers;
 DROP TABLE IF EXISTS pull_requests;
+DROP TABLE IF EXISTS team_members;
 DROP TABLE IF EXISTS users;
 DROP TABLE IF EXISTS pull_requests_status;
 DROP TABLE IF EXISTS teams;
\ No newline at end of file
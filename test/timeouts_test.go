@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/routes.go"
+	"pr-service/internal/server"
+	"pr-service/internal/testhelpers"
+)
+
+// blockingAPIKeys имитирует долгий запрос к БД: ListKeys ждет отмены контекста
+type blockingAPIKeys struct {
+	canceled chan error
+}
+
+func (bk *blockingAPIKeys) CreateKey(ctx context.Context, requestDTO *dto.RequestCreateAPIKeyDTO) (*dto.ResponseCreateAPIKeyDTO, error) {
+	return nil, nil
+}
+
+func (bk *blockingAPIKeys) ListKeys(ctx context.Context) (*dto.ResponseAPIKeysDTO, error) {
+	<-ctx.Done()
+	bk.canceled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (bk *blockingAPIKeys) RevokeKey(ctx context.Context, keyId int) (*dto.APIKeyDTO, error) {
+	return nil, nil
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Run("configured and slow routes", func(t *testing.T) {
+		timeouts := routes.NewTimeouts(server.DefaultRequestTimeout, map[string]time.Duration{
+			"GET /api/v1/stats": 5 * time.Second,
+			"POST /import":      0,
+		})
+		testhelpers.Equal(t, timeouts.For(routes.Route{Method: http.MethodGet, Path: "/api/v1/teams"}), server.DefaultRequestTimeout)
+		testhelpers.Equal(t, timeouts.For(routes.Route{Method: http.MethodGet, Path: "/api/v1/stats"}), 5*time.Second)
+		testhelpers.Equal(t, timeouts.For(routes.Route{Method: http.MethodGet, Path: "/api/v1/admin/export"}), time.Minute)
+		testhelpers.Equal(t, timeouts.For(routes.Route{Method: http.MethodPost, Path: "/import"}), time.Duration(0))
+	})
+
+	authenticator, err := auth.NewStaticTokens("admin-token=admin")
+	if err != nil {
+		t.Fatalf("Failed to parse tokens: %v", err)
+	}
+
+	apiKeys := &blockingAPIKeys{canceled: make(chan error, 1)}
+	timeouts := &routes.Timeouts{Default: 20 * time.Millisecond}
+	router := routes.NewRouter(authenticator, nil, timeouts, false, &handlers.TeamsHandlers{}, &handlers.UsersHandlers{}, &handlers.PullRequestsHandlers{},
+		&handlers.StatsHandlers{}, &handlers.ImportHandlers{}, &handlers.AdminHandlers{}, &handlers.APIKeysHandlers{APIKeysService: apiKeys}, &handlers.MetricsHandlers{}, &handlers.HealthHandlers{})
+
+	t.Run("expired deadline answers TIMEOUT", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/admin/api-keys", nil)
+		request.Header.Set("Authorization", "Bearer admin-token")
+		responseWriter := httptest.NewRecorder()
+
+		router.ServeHTTP(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		testhelpers.Equal(t, <-apiKeys.canceled, context.DeadlineExceeded)
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusGatewayTimeout)
+
+		// тело ответа в формате ErrorResponseDTO
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		testhelpers.Equal(t, responseDTO.Error.Code, "TIMEOUT")
+	})
+
+	t.Run("client disconnect cancels the service call", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/admin/api-keys", nil).WithContext(ctx)
+		request.Header.Set("Authorization", "Bearer admin-token")
+
+		// net/http отменяет контекст запроса, когда клиент закрывает соединение
+		cancel()
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		testhelpers.Equal(t, <-apiKeys.canceled, context.Canceled)
+	})
+}
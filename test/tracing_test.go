@@ -0,0 +1,211 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/tracing"
+)
+
+// memoryExporter складывает спаны в память
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.SpanData
+}
+
+func (me *memoryExporter) Export(ctx context.Context, spans []*tracing.SpanData) error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.spans = append(me.spans, spans...)
+	return nil
+}
+
+func (me *memoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// fakeConnector - драйвер БД без сервера: любой Exec успешен
+type fakeConnector struct{}
+
+func (fc *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{}, nil }
+
+func (fc *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{}
+
+func (fc *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (fc *fakeConn) Close() error { return nil }
+
+func (fc *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (fc *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func TestTraceParent(t *testing.T) {
+	traceId, parentId, sampled, ok := tracing.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	testhelpers.Equal(t, ok, true)
+	testhelpers.Equal(t, sampled, true)
+	testhelpers.Equal(t, traceId.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	testhelpers.Equal(t, parentId.String(), "00f067aa0ba902b7")
+
+	_, _, sampled, ok = tracing.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	testhelpers.Equal(t, ok, true)
+	testhelpers.Equal(t, sampled, false)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-xyz92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, _, _, ok := tracing.ParseTraceParent(header); ok {
+			t.Errorf("traceparent %q must be rejected", header)
+		}
+	}
+}
+
+func TestTracingSpans(t *testing.T) {
+	lgr := slog.New(slog.NewTextHandler(io.Discard, nil))
+	exporter := &memoryExporter{}
+	tracer := tracing.NewTracer(exporter, 1, lgr)
+
+	db := sql.OpenDB(tracing.WrapConnector(&fakeConnector{}))
+	defer db.Close()
+
+	// хендлер вызывает "сервис", который выполняет SQL запрос
+	router := http.NewServeMux()
+	router.HandleFunc("POST /api/v1/pull-requests", func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "PullRequestsService.AddPullRequest")
+		defer span.End()
+
+		if _, err := db.ExecContext(ctx, "INSERT INTO pull_requests\n\t(id) VALUES ($1)", "pr-1"); err != nil {
+			t.Errorf("Failed to exec: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	// внешний middleware видит шаблон маршрута, как access log и метрики
+	var seenRoute string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracer.Middleware(router).ServeHTTP(w, r)
+		seenRoute = r.Pattern
+	})
+
+	call := func(traceParent string) {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/pull-requests", nil)
+		if traceParent != "" {
+			request.Header.Set(tracing.TraceParentHeader, traceParent)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	call("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	// клиент не записывает трейс - спанов нет
+	call("00-4bf92f3577b34da6a3ce929d0e0e4737-00f067aa0ba902b7-00")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shutdown tracer: %v", err)
+	}
+
+	testhelpers.Equal(t, seenRoute, "POST /api/v1/pull-requests")
+
+	// спаны завершаются изнутри наружу: SQL, сервис, HTTP
+	testhelpers.Equal(t, len(exporter.spans), 3)
+	sqlSpan, serviceSpan, httpSpan := exporter.spans[0], exporter.spans[1], exporter.spans[2]
+
+	testhelpers.Equal(t, httpSpan.Name, "POST /api/v1/pull-requests")
+	testhelpers.Equal(t, httpSpan.Kind, tracing.SpanKindServer)
+	testhelpers.Equal(t, httpSpan.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	testhelpers.Equal(t, httpSpan.ParentSpanID.String(), "00f067aa0ba902b7")
+
+	testhelpers.Equal(t, serviceSpan.Name, "PullRequestsService.AddPullRequest")
+	testhelpers.Equal(t, serviceSpan.TraceID, httpSpan.TraceID)
+	testhelpers.Equal(t, serviceSpan.ParentSpanID, httpSpan.SpanID)
+
+	testhelpers.Equal(t, sqlSpan.Name, "INSERT")
+	testhelpers.Equal(t, sqlSpan.Kind, tracing.SpanKindClient)
+	testhelpers.Equal(t, sqlSpan.ParentSpanID, serviceSpan.SpanID)
+
+	attributes := map[string]any{}
+	for _, attribute := range sqlSpan.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+	testhelpers.Equal(t, attributes["db.statement"], any("INSERT INTO pull_requests (id) VALUES ($1)"))
+
+	t.Run("spans outside of traced requests are no-op", func(t *testing.T) {
+		ctx, span := tracing.Start(context.Background(), "UsersService.GetUser")
+		span.SetAttributes(tracing.String("user_id", "u1"))
+		span.End()
+		if span != nil || tracing.SpanFromContext(ctx) != nil {
+			t.Errorf("span must be nil without a traced request")
+		}
+	})
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]any
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testhelpers.Equal(t, r.URL.Path, "/v1/traces")
+		testhelpers.Equal(t, r.Header.Get("Content-Type"), "application/json")
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to parse OTLP request: %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	exporter, err := tracing.NewExporter("otlp", collector.URL+"/v1/traces", "pr-service")
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+
+	traceId, parentId, _, _ := tracing.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	start := time.Unix(1700000000, 0)
+	err = exporter.Export(context.Background(), []*tracing.SpanData{{
+		TraceID:    traceId,
+		SpanID:     parentId,
+		Name:       "SELECT",
+		Kind:       tracing.SpanKindClient,
+		Start:      start,
+		End:        start.Add(time.Millisecond),
+		Attributes: []tracing.Attribute{tracing.Int("rows", 2)},
+		Error:      "canceled",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	resourceSpans := body["resourceSpans"].([]any)[0].(map[string]any)
+	span := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	testhelpers.Equal(t, span["traceId"], any("4bf92f3577b34da6a3ce929d0e0e4736"))
+	testhelpers.Equal(t, span["startTimeUnixNano"], any("1700000000000000000"))
+	testhelpers.Equal(t, span["kind"], any(float64(tracing.SpanKindClient)))
+	testhelpers.Equal(t, span["status"].(map[string]any)["code"], any(float64(2)))
+	testhelpers.Equal(t, span["attributes"].([]any)[0].(map[string]any)["value"].(map[string]any)["intValue"], any("2"))
+
+	if _, err := tracing.NewExporter("jaeger", "", "pr-service"); err == nil {
+		t.Errorf("unknown exporter must be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"pr-service/internal/repository"
+	"pr-service/internal/repository/memory"
+	"pr-service/internal/testhelpers"
+)
+
+func TestTransactor(t *testing.T) {
+	ctx := t.Context()
+	store := memory.NewStore()
+	transactor := &repository.Transactor{Db: store.DB()}
+	teamsRepository := &memory.TeamsRepository{Store: store}
+
+	t.Run("serialization failure is retried", func(t *testing.T) {
+		attempts := 0
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			attempts++
+			if err := teamsRepository.AddTeam(ctx, "retried"); err != nil {
+				return err
+			}
+
+			// запись первой попытки откатывается вместе с ней
+			if attempts == 1 {
+				return &pq.Error{Code: "40001"}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, attempts, 2)
+
+		isExist, err := teamsRepository.IsExist(ctx, "retried")
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, isExist, true)
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		attempts := 0
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			attempts++
+			return &pq.Error{Code: "40P01"}
+		})
+
+		var sqlError *pq.Error
+		testhelpers.Equal(t, errors.As(err, &sqlError), true)
+		testhelpers.Equal(t, attempts, 3)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		attempts := 0
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			attempts++
+			return teamsRepository.AddTeam(ctx, "retried")
+		})
+		testhelpers.Equal(t, errors.Is(err, repository.ErrDuplicatedTeamName), true)
+		testhelpers.Equal(t, attempts, 1)
+	})
+
+	t.Run("nested call joins the outer transaction", func(t *testing.T) {
+		errRollback := errors.New("rollback")
+		err := transactor.WithTx(ctx, nil, func(outer context.Context) error {
+			err := transactor.WithTx(outer, nil, func(inner context.Context) error {
+				testhelpers.Equal(t, repository.TxFromContext(inner), repository.TxFromContext(outer))
+				return teamsRepository.AddTeam(inner, "nested")
+			})
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		testhelpers.Equal(t, errors.Is(err, errRollback), true)
+
+		// откат внешней транзакции отменяет и вложенную запись
+		isExist, err := teamsRepository.IsExist(ctx, "nested")
+		if err != nil {
+			t.Fatal(err)
+		}
+		testhelpers.Equal(t, isExist, false)
+	})
+
+	t.Run("after commit actions", func(t *testing.T) {
+		actions := []string{}
+		attempts := 0
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			attempts++
+			repository.AfterCommit(ctx, func() { actions = append(actions, fmt.Sprintf("attempt %d", attempts)) })
+			if attempts == 1 {
+				return &pq.Error{Code: "40001"}
+			}
+			testhelpers.Equal(t, len(actions), 0)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// действие отмененной попытки не выполняется, а у успешной - только после коммита
+		testhelpers.Equal(t, slices.Equal(actions, []string{"attempt 2"}), true)
+
+		// после отката действие не выполняется, без транзакции - выполняется сразу
+		errRollback := errors.New("rollback")
+		err = transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			repository.AfterCommit(ctx, func() { actions = append(actions, "rolled back") })
+			return errRollback
+		})
+		testhelpers.Equal(t, errors.Is(err, errRollback), true)
+
+		repository.AfterCommit(ctx, func() { actions = append(actions, "no tx") })
+		testhelpers.Equal(t, slices.Equal(actions, []string{"attempt 2", "no tx"}), true)
+	})
+
+	t.Run("panic rolls back", func(t *testing.T) {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("panic must be propagated")
+				}
+			}()
+			transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+				if err := teamsRepository.AddTeam(ctx, "panicked"); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+
+		// хранилище не ждет незавершенную транзакцию
+		err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			isExist, err := teamsRepository.IsExist(ctx, "panicked")
+			if err != nil {
+				return err
+			}
+			testhelpers.Equal(t, isExist, false)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
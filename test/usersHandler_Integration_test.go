@@ -0,0 +1,360 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"pr-service/internal/dto"
+	"pr-service/internal/handlers"
+	"pr-service/internal/models"
+	"pr-service/internal/repository"
+	"pr-service/internal/service"
+	"pr-service/internal/testhelpers"
+	"pr-service/internal/testutils"
+)
+
+func TestUsersHandler(t *testing.T) {
+	// тестовая база данных на время теста
+	db := testutils.NewTestDB(t)
+	defer testutils.DeleteDb(t, db)
+
+	// создаем репозитории
+	usersRepository := &repository.UsersRepository{Db: db}
+	teamsRepository := &repository.TeamsRepository{Db: db}
+	pullRequestsRepository := &repository.PullRequestsRepository{Db: db}
+	reviewersRepository := &repository.ReviewersRepository{Db: db}
+	eventsRepository := &repository.EventsRepository{Db: db}
+	transactor := &repository.Transactor{Db: db}
+
+	lgr := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// создаем сервисы, удаление переназначает ревью через сервис pr
+	pullRequestService := &service.PullRequestsService{
+		UsersRepository:        usersRepository,
+		TeamsRepository:        teamsRepository,
+		PullRequestsRepository: pullRequestsRepository,
+		ReviewersRepository:    reviewersRepository,
+		EventsRepository:       eventsRepository,
+		Transactor:             transactor,
+		Lgr:                    lgr,
+	}
+
+	userService := &service.UsersService{
+		UsersRepository:        usersRepository,
+		TeamsRepository:        teamsRepository,
+		ReviewersRepository:    reviewersRepository,
+		PullRequestsRepository: pullRequestsRepository,
+		Transactor:             transactor,
+		PullRequestsService:    pullRequestService,
+		Lgr:                    lgr,
+	}
+
+	// создаем сам хендлер
+	userHandler := handlers.UsersHandlers{
+		UserService: userService,
+	}
+
+	// Предварительно создаем команду и пользователей для тестов
+	testutils.RunQuery(t, db, "./testdata/InsertUsers.sql")
+
+	t.Run("get user", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/users/get?user_id=u1", nil)
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		userHandler.GetUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.UserDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// профиль должен совпадать с данными в БД
+		testhelpers.Equal(t, responseDTO.User.UserId, "u1")
+		testhelpers.Equal(t, responseDTO.User.Username, "Alice")
+		testhelpers.Equal(t, responseDTO.User.TeamName, "test-team")
+		testhelpers.Equal(t, responseDTO.User.IsActive, true)
+	})
+
+	t.Run("get user without user_id", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/users/get", nil)
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.GetUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+
+		// десереализируем ответ
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// код ошибки должен совпадать
+		testhelpers.Equal(t, responseDTO.Error.Code, "MISSING_PARAM")
+	})
+
+	t.Run("get unknown user", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/users/get?user_id=u999", nil)
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.GetUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("update user profile", func(t *testing.T) {
+		// меняем только имя и почту, команда не передается
+		username, email := "Alice Cooper", "alice@example.com"
+		requestDTO := dto.RequestUpdateUserDTO{
+			UserId:   "u1",
+			Username: &username,
+			Email:    &email,
+			Handles:  map[string]string{"github": "alice"},
+		}
+
+		b, err := json.Marshal(requestDTO)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/update", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		userHandler.UpdateUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.UserDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		testhelpers.Equal(t, responseDTO.User.Username, username)
+		testhelpers.Equal(t, responseDTO.User.Email, email)
+
+		// проверяем запись в БД
+		user, err := usersRepository.GetUserById(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+
+		testhelpers.Equal(t, user.Username, username)
+		testhelpers.Equal(t, user.Email, email)
+		testhelpers.Equal(t, user.Handles["github"], "alice")
+
+		// непереданные поля не меняются
+		testhelpers.Equal(t, user.TeamName, "test-team")
+		testhelpers.Equal(t, user.IsActive, true)
+	})
+
+	t.Run("update user with unknown team", func(t *testing.T) {
+		teamName := "no-such-team"
+		b, err := json.Marshal(dto.RequestUpdateUserDTO{UserId: "u1", TeamName: &teamName})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/update", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.UpdateUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusNotFound)
+
+		// основная команда не должна измениться
+		user, err := usersRepository.GetUserById(context.Background(), "u1")
+		if err != nil {
+			t.Fatalf("Failed to get user from database: %v", err)
+		}
+		testhelpers.Equal(t, user.TeamName, "test-team")
+	})
+
+	t.Run("update user with invalid body", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/users/update", bytes.NewReader([]byte(`{"user_id": ""}`)))
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.UpdateUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusBadRequest)
+	})
+
+	// u1 создает pr, ревьюверами становятся активные u3 и u5
+	if _, err := pullRequestService.AddPullRequest(context.Background(), &dto.RequestPullrequestDTO{
+		PullRequestId:   "pr-2001",
+		PullRequestName: "Refactor users",
+		AuthorID:        "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create pull request: %v", err)
+	}
+
+	t.Run("delete user with open reviews", func(t *testing.T) {
+		b, err := json.Marshal(dto.RequestDeleteUserDTO{UserId: "u3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/delete", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос без reassign_reviews
+		userHandler.DeleteUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusConflict)
+
+		// десереализируем ответ
+		var responseDTO dto.ErrorResponseDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// код ошибки должен совпадать
+		testhelpers.Equal(t, responseDTO.Error.Code, "HAS_OPEN_REVIEWS")
+
+		// пользователь должен остаться в БД
+		exists, err := usersRepository.IsExist(context.Background(), "u3")
+		if err != nil {
+			t.Fatalf("Failed to check user existence: %v", err)
+		}
+		testhelpers.Equal(t, exists, true)
+	})
+
+	t.Run("delete user and reassign reviews", func(t *testing.T) {
+		// u2 становится единственным свободным кандидатом на замену
+		if err := usersRepository.UpdateUserIsActive(context.Background(), "u2", true); err != nil {
+			t.Fatalf("Failed to activate user: %v", err)
+		}
+
+		b, err := json.Marshal(dto.RequestDeleteUserDTO{UserId: "u3", ReassignReviews: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/delete", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		// делаем запрос
+		userHandler.DeleteUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseDeleteUserDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// ревью u3 переходит к u2
+		testhelpers.Equal(t, responseDTO.UserId, "u3")
+		testhelpers.Equal(t, len(responseDTO.Reassigned), 1)
+		testhelpers.Equal(t, responseDTO.Reassigned[0].PullRequestId, "pr-2001")
+		testhelpers.Equal(t, responseDTO.Reassigned[0].ReplacedBy, "u2")
+
+		// ревьюверы pr в БД
+		reviewers, err := reviewersRepository.GetReviewersIdByPullRequestId(context.Background(), "pr-2001")
+		if err != nil {
+			t.Fatalf("Failed to get reviewers from database: %v", err)
+		}
+		slices.Sort(reviewers)
+		testhelpers.Equal(t, slices.Equal(reviewers, []string{"u2", "u5"}), true)
+
+		// пользователь удален
+		exists, err := usersRepository.IsExist(context.Background(), "u3")
+		if err != nil {
+			t.Fatalf("Failed to check user existence: %v", err)
+		}
+		testhelpers.Equal(t, exists, false)
+	})
+
+	t.Run("delete user without reviews", func(t *testing.T) {
+		// u4 - лид своей команды
+		if err := teamsRepository.AddTeamLead(context.Background(), "test-team", "u4"); err != nil {
+			t.Fatalf("Failed to add team lead: %v", err)
+		}
+
+		b, err := json.Marshal(dto.RequestDeleteUserDTO{UserId: "u4"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/delete", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.DeleteUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusOK)
+
+		// десереализируем ответ
+		var responseDTO dto.ResponseDeleteUserDTO
+		if err := json.NewDecoder(responseResult.Body).Decode(&responseDTO); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// переназначать нечего
+		testhelpers.Equal(t, len(responseDTO.Reassigned), 0)
+
+		// удаленный пользователь больше не лид и не участник команды
+		ledTeams, err := teamsRepository.GetLedTeams(context.Background(), "u4")
+		if err != nil {
+			t.Fatalf("Failed to get led teams: %v", err)
+		}
+		testhelpers.Equal(t, len(ledTeams), 0)
+
+		members, err := usersRepository.GetUsersByTeam(context.Background(), "test-team")
+		if err != nil {
+			t.Fatalf("Failed to get team members: %v", err)
+		}
+		testhelpers.Equal(t, slices.ContainsFunc(members, func(user *models.UserModel) bool { return user.Id == "u4" }), false)
+
+		// активность удаленного пользователя не меняется
+		err = usersRepository.UpdateUserIsActive(context.Background(), "u4", true)
+		testhelpers.Equal(t, errors.Is(err, repository.ErrNoRecord), true)
+	})
+
+	t.Run("delete unknown user", func(t *testing.T) {
+		b, err := json.Marshal(dto.RequestDeleteUserDTO{UserId: "u999"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := httptest.NewRequest("POST", "/users/delete", bytes.NewReader(b))
+		responseWriter := httptest.NewRecorder()
+
+		userHandler.DeleteUser(responseWriter, request)
+		responseResult := responseWriter.Result()
+
+		// код ответа должен совпадать
+		testhelpers.Equal(t, responseResult.StatusCode, http.StatusNotFound)
+	})
+}